@@ -0,0 +1,158 @@
+// Package audit records who changed the transactions and categories, and how
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"quattrinitrack/database"
+	"reflect"
+	"time"
+)
+
+// Audited entities
+const (
+	EntityTransaction = "transaction"
+	EntityCategory    = "category"
+)
+
+// Actions recorded in the audit log
+const (
+	Insert  = "insert"
+	Update  = "update"
+	Delete  = "delete"
+	Restore = "restore"
+	Purge   = "purge"
+)
+
+// Recorder is what writing the audit log needs.
+type Recorder interface {
+	InsertAuditEntry(ctx context.Context, arg database.InsertAuditEntryParams) error
+}
+
+// Transaction is the state of a transaction kept in the audit log. Its tags,
+// splits and attachments are not part of it; changes to its tags are recorded
+// as updates of a "tags" field.
+type Transaction struct {
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Cost         float64    `json:"cost"`
+	Date         time.Time  `json:"date"`
+	CategoriesID int64      `json:"categories_id"`
+	PayeeID      *int64     `json:"payee_id"`
+	Notes        string     `json:"notes"`
+	DeletedAt    *time.Time `json:"deleted_at"`
+}
+
+// Category is the state of a category kept in the audit log.
+type Category struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	ParentID  *int64     `json:"parent_id"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+func NewTransaction(t database.Transaction) Transaction {
+	return Transaction{
+		ID:           t.ID,
+		Name:         t.Name,
+		Cost:         t.Cost,
+		Date:         t.Date,
+		CategoriesID: t.CategoriesID,
+		PayeeID:      nullInt(t.PayeeID),
+		Notes:        t.Notes,
+		DeletedAt:    nullTime(t.DeletedAt),
+	}
+}
+
+func NewCategory(c database.Category) Category {
+	return Category{
+		ID:        c.ID,
+		Name:      c.Name,
+		ParentID:  nullInt(c.ParentID),
+		DeletedAt: nullTime(c.DeletedAt),
+	}
+}
+
+func nullInt(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// Record appends an entry to the audit log. A nil before or after is stored
+// as NULL. The acting user is taken from the request context; entries
+// without one are made by the server itself, like the trash purge.
+func Record(ctx context.Context, r Recorder, entity string, id int64, action string, before, after any) error {
+	params := database.InsertAuditEntryParams{
+		Entity:    entity,
+		EntityID:  id,
+		Action:    action,
+		CreatedAt: time.Now().UTC(),
+	}
+	if userID, ok := ctx.Value("userID").(int64); ok {
+		params.UserID = sql.NullInt64{Int64: userID, Valid: true}
+	}
+
+	var err error
+	if params.Before, err = marshal(before); err != nil {
+		return err
+	}
+	if params.After, err = marshal(after); err != nil {
+		return err
+	}
+	return r.InsertAuditEntry(ctx, params)
+}
+
+// RecordUpdate appends an update entry holding only the fields that differ
+// between before and after. Nothing is recorded when none does.
+func RecordUpdate(ctx context.Context, r Recorder, entity string, id int64, before, after any) error {
+	old, err := fields(before)
+	if err != nil {
+		return err
+	}
+	changed, err := fields(after)
+	if err != nil {
+		return err
+	}
+	for name, value := range changed {
+		if reflect.DeepEqual(old[name], value) {
+			delete(old, name)
+			delete(changed, name)
+		}
+	}
+	if len(changed) == 0 && len(old) == 0 {
+		return nil
+	}
+	return Record(ctx, r, entity, id, Update, old, changed)
+}
+
+func marshal(value any) (sql.NullString, error) {
+	if value == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+// fields turns a snapshot into its JSON fields.
+func fields(value any) (map[string]any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
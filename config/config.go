@@ -2,7 +2,7 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
 
 	"github.com/lpernett/godotenv"
@@ -13,12 +13,14 @@ var JWTSecret []byte
 func LoadEnv() {
 	err := godotenv.Load()
 	if err != nil {
-		log.Fatalf("Error loading .env file %v", err)
+		slog.Error("Error loading .env file", "error", err)
+		os.Exit(1)
 	}
 
 	secret := os.Getenv("JWT_SECRET")
 	if secret == "" {
-		log.Fatalf("JWT_SECRET not set in environment file")
+		slog.Error("JWT_SECRET not set in environment file")
+		os.Exit(1)
 	}
 
 	JWTSecret = []byte(secret)
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lpernett/godotenv"
+)
+
+// LogConfig holds the settings of the optional log file sink.
+type LogConfig struct {
+	// File is the path of the active log file, logging to file is off when empty
+	File string
+	// MaxSize is the size in bytes after which the file is rotated
+	MaxSize int64
+	// RotateEvery is the age after which the file is rotated, even if small
+	RotateEvery time.Duration
+	// MaxAge is how long rotated files are kept
+	MaxAge time.Duration
+	// MaxBackups is how many rotated files are kept
+	MaxBackups int
+	// Compress gzips rotated files
+	Compress bool
+}
+
+// LoadLogConfig reads the log file settings from the environment, loading the
+// .env file when there is one. It does not need JWT_SECRET, so it can be called
+// before LoadEnv.
+func LoadLogConfig() (LogConfig, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return LogConfig{}, err
+	}
+
+	cfg := LogConfig{
+		File:     os.Getenv("LOG_FILE"),
+		Compress: true,
+	}
+
+	sizeMB, err := envInt("LOG_MAX_SIZE_MB", 10)
+	if err != nil {
+		return cfg, err
+	}
+	rotateHours, err := envInt("LOG_ROTATE_HOURS", 24)
+	if err != nil {
+		return cfg, err
+	}
+	maxAgeDays, err := envInt("LOG_MAX_AGE_DAYS", 30)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.MaxBackups, err = envInt("LOG_MAX_BACKUPS", 10)
+	if err != nil {
+		return cfg, err
+	}
+
+	if value := os.Getenv("LOG_COMPRESS"); value != "" {
+		cfg.Compress, err = strconv.ParseBool(value)
+		if err != nil {
+			return cfg, fmt.Errorf("LOG_COMPRESS: %w", err)
+		}
+	}
+
+	cfg.MaxSize = int64(sizeMB) << 20
+	cfg.RotateEvery = time.Duration(rotateHours) * time.Hour
+	cfg.MaxAge = time.Duration(maxAgeDays) * 24 * time.Hour
+
+	return cfg, nil
+}
+
+// envInt reads a non-negative integer variable, returning def when unset.
+func envInt(name string, def int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%s must not be negative", name)
+	}
+	return n, nil
+}
@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+// TrashRetention is how long deleted transactions and categories are kept in
+// the trash before they are purged. Zero keeps them until they are restored.
+var TrashRetention = 30 * 24 * time.Hour
+
+// LoadTrashConfig reads the retention of the trash, in days, from
+// TRASH_RETENTION_DAYS. It must be called after LoadEnv.
+func LoadTrashConfig() error {
+	days, err := envInt("TRASH_RETENTION_DAYS", 30)
+	if err != nil {
+		return err
+	}
+	TrashRetention = time.Duration(days) * 24 * time.Hour
+	return nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+const defaultTUIConfigPath = "tui.json"
+
+// TUIConfig holds the user preferences of the terminal interface.
+type TUIConfig struct {
+	// Theme is the name of a preset: dark, light or high-contrast
+	Theme string `json:"theme"`
+	// Colors overrides single colors of the theme, e.g. "accent": "#00ff00"
+	Colors map[string]string `json:"colors"`
+	// Keys maps an action name to the keys that trigger it, e.g. "quit": ["q"]
+	Keys map[string][]string `json:"keys"`
+}
+
+// LoadTUIConfig reads the TUI config file named by TUI_CONFIG, defaulting to
+// tui.json. A missing file is not an error and yields the defaults.
+func LoadTUIConfig() (TUIConfig, error) {
+	var cfg TUIConfig
+
+	path := os.Getenv("TUI_CONFIG")
+	if path == "" {
+		path = defaultTUIConfigPath
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
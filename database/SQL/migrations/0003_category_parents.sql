@@ -0,0 +1,16 @@
+-- Names are now unique among the children of a parent, so the table is rebuilt
+-- without its UNIQUE (name) constraint.
+CREATE TABLE categories_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL,
+  parent_id INTEGER REFERENCES categories(id)
+);
+
+INSERT INTO categories_new (id, name)
+SELECT id, name FROM categories;
+
+DROP TABLE categories;
+ALTER TABLE categories_new RENAME TO categories;
+
+CREATE UNIQUE INDEX categories_parent_name ON categories(COALESCE(parent_id, 0), name);
+CREATE INDEX categories_parent_id ON categories(parent_id);
@@ -0,0 +1,20 @@
+CREATE TABLE transaction_splits (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+  categories_id INTEGER NOT NULL REFERENCES categories(id),
+  amount REAL NOT NULL CHECK (amount > 0)
+);
+
+CREATE INDEX transaction_splits_transaction_id ON transaction_splits(transaction_id);
+CREATE INDEX transaction_splits_categories_id ON transaction_splits(categories_id);
+
+-- What each category receives from each transaction: the split lines of a
+-- split transaction, the whole cost of any other.
+CREATE VIEW transaction_allocations AS
+SELECT s.transaction_id, s.categories_id, s.amount, t.date
+FROM transaction_splits s
+JOIN transactions t ON t.id = s.transaction_id
+UNION ALL
+SELECT t.id, t.categories_id, t.cost, t.date
+FROM transactions t
+WHERE NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id);
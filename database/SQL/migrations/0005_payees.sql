@@ -0,0 +1,20 @@
+CREATE TABLE payees (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+-- The names a payee appears under, such as "AMAZON EU SARL" for Amazon. The
+-- name of a payee is one of its aliases too. Aliases are matched on their
+-- normalized form, which the handlers compute.
+CREATE TABLE payee_aliases (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  payee_id INTEGER NOT NULL REFERENCES payees(id) ON DELETE CASCADE,
+  alias TEXT NOT NULL,
+  normalized TEXT NOT NULL UNIQUE
+);
+
+CREATE INDEX payee_aliases_payee_id ON payee_aliases(payee_id);
+
+ALTER TABLE transactions ADD COLUMN payee_id INTEGER REFERENCES payees(id) ON DELETE SET NULL;
+
+CREATE INDEX transactions_payee_id ON transactions(payee_id);
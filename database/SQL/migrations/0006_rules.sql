@@ -0,0 +1,23 @@
+-- Rules assign a category and tags to the transactions they match. A rule
+-- matches when its pattern is found in the name or payee of a transaction and
+-- the cost and day of the transaction are within its bounds; the bounds left
+-- null, and a weekdays mask of 0, match anything.
+CREATE TABLE rules (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL,
+  priority INTEGER NOT NULL DEFAULT 0,
+  match_field TEXT NOT NULL DEFAULT 'name' CHECK (match_field IN ('name', 'payee')),
+  match_type TEXT NOT NULL DEFAULT 'contains' CHECK (match_type IN ('contains', 'regex')),
+  pattern TEXT NOT NULL DEFAULT '',
+  amount_min REAL,
+  amount_max REAL,
+  -- Bit n is set for the days of time.Weekday n, Sunday being 0
+  weekdays INTEGER NOT NULL DEFAULT 0,
+  categories_id INTEGER REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE TABLE rule_tags (
+  rule_id INTEGER NOT NULL REFERENCES rules(id) ON DELETE CASCADE,
+  tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+  PRIMARY KEY (rule_id, tag_id)
+);
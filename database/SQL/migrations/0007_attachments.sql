@@ -0,0 +1,16 @@
+ALTER TABLE transactions ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+
+-- Files kept with a transaction, such as the receipt. They are stored in the
+-- database, so that a backup of it keeps them too. The content type is the one
+-- sniffed from the data on upload, not the one the client claimed.
+CREATE TABLE attachments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+  filename TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  size INTEGER NOT NULL,
+  data BLOB NOT NULL,
+  created_at DATETIME NOT NULL
+);
+
+CREATE INDEX attachments_transaction_id ON attachments(transaction_id);
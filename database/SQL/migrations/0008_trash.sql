@@ -0,0 +1,25 @@
+-- Deleted transactions and categories go to the trash: they keep their rows,
+-- with the time they were deleted, until they are restored or purged.
+ALTER TABLE transactions ADD COLUMN deleted_at DATETIME;
+ALTER TABLE categories ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX transactions_deleted_at ON transactions(deleted_at);
+CREATE INDEX categories_deleted_at ON categories(deleted_at);
+
+-- A category in the trash no longer holds its name
+DROP INDEX categories_parent_name;
+CREATE UNIQUE INDEX categories_parent_name ON categories(COALESCE(parent_id, 0), name) WHERE deleted_at IS NULL;
+
+-- Transactions in the trash are left out of the allocations, and so of the
+-- reports and filters reading from them.
+DROP VIEW transaction_allocations;
+CREATE VIEW transaction_allocations AS
+SELECT s.transaction_id, s.categories_id, s.amount, t.date
+FROM transaction_splits s
+JOIN transactions t ON t.id = s.transaction_id
+WHERE t.deleted_at IS NULL
+UNION ALL
+SELECT t.id, t.categories_id, t.cost, t.date
+FROM transactions t
+WHERE t.deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id);
@@ -0,0 +1,25 @@
+-- Every change to a transaction or a category, with the user who made it and
+-- the record before and after, as JSON. A null user is the server itself, e.g.
+-- when the trash is purged. Entries are never changed nor removed.
+CREATE TABLE audit_log (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER REFERENCES users(id),
+  entity TEXT NOT NULL CHECK (entity IN ('transaction', 'category')),
+  entity_id INTEGER NOT NULL,
+  action TEXT NOT NULL CHECK (action IN ('insert', 'update', 'delete', 'restore', 'purge')),
+  before TEXT,
+  after TEXT,
+  created_at DATETIME NOT NULL
+);
+
+CREATE INDEX audit_log_entity ON audit_log(entity, entity_id);
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+  SELECT RAISE(ABORT, 'audit log entries cannot be changed');
+END;
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+  SELECT RAISE(ABORT, 'audit log entries cannot be removed');
+END;
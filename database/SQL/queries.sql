@@ -1,47 +1,191 @@
--- name: InsertTransaction :exec
-INSERT INTO transactions(name, cost, date, categories_id)
-VALUES (?, ?, ?, ?);
+-- name: InsertTransaction :one
+INSERT INTO transactions(name, cost, date, categories_id, payee_id, notes)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id;
 
 -- name: GetAllTransactions :many
-SELECT * FROM transactions;
+SELECT * FROM transactions
+WHERE deleted_at IS NULL;
 
 -- name: GetTransactionByID :one
 SELECT *
 FROM transactions
-WHERE id = ?;
+WHERE id = ? AND deleted_at IS NULL;
 
 -- name: GetTransactionByName :many
 SELECT *
 FROM transactions
-WHERE name = ?;
+WHERE name = ? AND deleted_at IS NULL;
 
 -- name: GetTransactionByCategoryID :many
+-- Split transactions are found from the category of any of their lines.
 SELECT *
 FROM transactions
-WHERE categories_id = ?;
+WHERE id IN (
+  SELECT transaction_id FROM transaction_allocations WHERE categories_id = ?
+);
 
--- name: DeleteTransaction :exec
-DELETE
+-- name: InsertTransactionSplit :exec
+INSERT INTO transaction_splits(transaction_id, categories_id, amount)
+VALUES (?, ?, ?);
+
+-- name: GetTransactionSplits :many
+SELECT *
+FROM transaction_splits
+WHERE transaction_id IN (sqlc.slice(ids))
+ORDER BY transaction_id, id;
+
+-- name: TrashTransaction :exec
+UPDATE transactions
+SET deleted_at = ?
+WHERE id = ?;
+
+-- name: GetTrashedTransactions :many
+SELECT *
+FROM transactions
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC;
+
+-- name: GetTrashedTransactionByID :one
+SELECT *
 FROM transactions
+WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: CountTrashedTransactionCategories :one
+-- The categories of the transaction, or of its split lines, that are in the
+-- trash.
+SELECT COUNT(*)
+FROM categories
+WHERE deleted_at IS NOT NULL
+  AND id IN (
+    SELECT t.categories_id FROM transactions t WHERE t.id = sqlc.arg(id)
+    UNION
+    SELECT s.categories_id FROM transaction_splits s WHERE s.transaction_id = sqlc.arg(id)
+  );
+
+-- name: RestoreTransaction :exec
+UPDATE transactions
+SET deleted_at = NULL
 WHERE id = ?;
 
--- name: InsertCategory :exec
-INSERT INTO categories(name)
-VALUES (?);
+-- name: PurgeTransactions :many
+DELETE
+FROM transactions
+WHERE deleted_at < ?
+RETURNING *;
+
+-- name: InsertCategory :one
+INSERT INTO categories(name, parent_id)
+VALUES (?, ?)
+RETURNING id;
+
+-- name: UpdateCategory :exec
+UPDATE categories
+SET name = ?, parent_id = ?
+WHERE id = ?;
 
 -- name: GetAllCategories :many
-SELECT * FROM categories;
+SELECT * FROM categories
+WHERE deleted_at IS NULL;
+
+-- name: GetCategoriesByIDs :many
+SELECT * FROM categories
+WHERE id IN (sqlc.slice(ids)) AND deleted_at IS NULL;
 
 -- name: GetCategoryByID :one
 SELECT *
 FROM categories
+WHERE id = ? AND deleted_at IS NULL;
+
+-- name: GetCategoryAncestors :many
+-- The category itself comes first. UNION stops on a cycle, should one
+-- exist already.
+WITH RECURSIVE ancestors(id, parent_id) AS (
+  SELECT c.id, c.parent_id FROM categories c WHERE c.id = sqlc.arg(id)
+  UNION
+  SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+)
+SELECT id FROM ancestors;
+
+-- name: GetCategoryReport :many
+SELECT c.id, c.name, c.parent_id, COUNT(a.transaction_id) AS transactions, CAST(COALESCE(SUM(a.amount), 0) AS REAL) AS total
+FROM categories c
+LEFT JOIN transaction_allocations a ON a.categories_id = c.id
+  AND a.date >= sqlc.arg(date_from)
+  AND a.date < sqlc.arg(date_to)
+WHERE c.deleted_at IS NULL
+GROUP BY c.id, c.name, c.parent_id
+ORDER BY c.name;
+
+-- name: TrashCategory :exec
+UPDATE categories
+SET deleted_at = ?
 WHERE id = ?;
 
--- name: DeleteCategory :exec
-DELETE
+-- name: CountCategoryUses :one
+-- Transactions, split lines included, and subcategories that are not in the
+-- trash.
+SELECT
+  (SELECT COUNT(DISTINCT a.transaction_id) FROM transaction_allocations a WHERE a.categories_id = sqlc.arg(id)) AS transactions,
+  (SELECT COUNT(*) FROM categories c WHERE c.parent_id = sqlc.arg(id) AND c.deleted_at IS NULL) AS subcategories;
+
+-- name: MoveCategoryTransactions :many
+-- Transactions in the trash keep their category.
+UPDATE transactions
+SET categories_id = sqlc.arg(to_id)
+WHERE categories_id = sqlc.arg(from_id) AND deleted_at IS NULL
+RETURNING id;
+
+-- name: MoveCategorySplits :many
+UPDATE transaction_splits
+SET categories_id = sqlc.arg(to_id)
+WHERE categories_id = sqlc.arg(from_id)
+  AND transaction_id IN (SELECT id FROM transactions WHERE deleted_at IS NULL)
+RETURNING transaction_id;
+
+-- name: MoveSubcategories :many
+UPDATE categories
+SET parent_id = sqlc.arg(to_id)
+WHERE parent_id = sqlc.arg(from_id) AND deleted_at IS NULL
+RETURNING id;
+
+-- name: MoveCategoryRules :exec
+UPDATE rules
+SET categories_id = sqlc.arg(to_id)
+WHERE categories_id = sqlc.arg(from_id);
+
+-- name: GetTrashedCategories :many
+SELECT *
+FROM categories
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC;
+
+-- name: GetTrashedCategoryByID :one
+SELECT *
 FROM categories
+WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: RestoreCategory :exec
+UPDATE categories
+SET deleted_at = NULL
 WHERE id = ?;
 
+-- name: PurgeCategories :many
+-- Categories still used by a transaction, even one in the trash, or by a
+-- subcategory kept longer are left for a later purge.
+DELETE
+FROM categories
+WHERE deleted_at < sqlc.arg(before)
+  AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.categories_id = categories.id)
+  AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.categories_id = categories.id)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM categories c
+    WHERE c.parent_id = categories.id
+      AND (c.deleted_at IS NULL OR c.deleted_at >= sqlc.arg(before))
+  )
+RETURNING *;
+
 -- name: CreateUser :one
 INSERT INTO users (email, password_hash)
 VALUES (?, ?)
@@ -49,3 +193,290 @@ RETURNING id, email;
 
 -- name: GetUserByEmail :one
 SELECT id, email, password_hash FROM users WHERE email = ?;
+
+-- name: GetTransactionsPage :many
+SELECT t.id, t.name, t.cost, t.date, t.categories_id, t.payee_id, t.notes, t.deleted_at
+FROM transactions t
+JOIN categories c ON c.id = t.categories_id
+WHERE t.deleted_at IS NULL
+  AND (t.name LIKE sqlc.arg(pattern) ESCAPE '\' OR c.name LIKE sqlc.arg(pattern) ESCAPE '\')
+  AND t.date >= sqlc.arg(date_from)
+  AND t.date < sqlc.arg(date_to)
+  AND (sqlc.arg(tag) = '' OR EXISTS (
+    SELECT 1
+    FROM transaction_tags tt
+    JOIN tags g ON g.id = tt.tag_id
+    WHERE tt.transaction_id = t.id AND g.name = sqlc.arg(tag)
+  ))
+ORDER BY
+  CASE WHEN sqlc.arg(sort_key) = 'date' AND NOT sqlc.arg(descending) THEN t.date END ASC,
+  CASE WHEN sqlc.arg(sort_key) = 'date' AND sqlc.arg(descending) THEN t.date END DESC,
+  CASE WHEN sqlc.arg(sort_key) = 'cost' AND NOT sqlc.arg(descending) THEN t.cost END ASC,
+  CASE WHEN sqlc.arg(sort_key) = 'cost' AND sqlc.arg(descending) THEN t.cost END DESC,
+  CASE WHEN sqlc.arg(sort_key) = 'name' AND NOT sqlc.arg(descending) THEN t.name END COLLATE NOCASE ASC,
+  CASE WHEN sqlc.arg(sort_key) = 'name' AND sqlc.arg(descending) THEN t.name END COLLATE NOCASE DESC,
+  CASE WHEN sqlc.arg(sort_key) = 'category' AND NOT sqlc.arg(descending) THEN c.name END COLLATE NOCASE ASC,
+  CASE WHEN sqlc.arg(sort_key) = 'category' AND sqlc.arg(descending) THEN c.name END COLLATE NOCASE DESC,
+  t.id ASC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountTransactions :one
+SELECT COUNT(*)
+FROM transactions t
+JOIN categories c ON c.id = t.categories_id
+WHERE t.deleted_at IS NULL
+  AND (t.name LIKE sqlc.arg(pattern) ESCAPE '\' OR c.name LIKE sqlc.arg(pattern) ESCAPE '\')
+  AND t.date >= sqlc.arg(date_from)
+  AND t.date < sqlc.arg(date_to)
+  AND (sqlc.arg(tag) = '' OR EXISTS (
+    SELECT 1
+    FROM transaction_tags tt
+    JOIN tags g ON g.id = tt.tag_id
+    WHERE tt.transaction_id = t.id AND g.name = sqlc.arg(tag)
+  ));
+
+-- name: InsertTag :exec
+INSERT INTO tags(name)
+VALUES (?);
+
+-- name: EnsureTag :exec
+INSERT OR IGNORE INTO tags(name)
+VALUES (?);
+
+-- name: GetAllTags :many
+SELECT * FROM tags
+ORDER BY name;
+
+-- name: GetTagByID :one
+SELECT *
+FROM tags
+WHERE id = ?;
+
+-- name: GetTagByName :one
+SELECT *
+FROM tags
+WHERE name = ?;
+
+-- name: RenameTag :exec
+UPDATE tags
+SET name = ?
+WHERE id = ?;
+
+-- name: DeleteTag :exec
+DELETE
+FROM tags
+WHERE id = ?;
+
+-- name: TagTransaction :exec
+INSERT OR IGNORE INTO transaction_tags(transaction_id, tag_id)
+VALUES (?, ?);
+
+-- name: GetTransactionTags :many
+SELECT tt.transaction_id, g.name
+FROM transaction_tags tt
+JOIN tags g ON g.id = tt.tag_id
+WHERE tt.transaction_id IN (sqlc.slice(ids))
+ORDER BY g.name;
+
+-- name: GetTagTransactionIDs :many
+SELECT transaction_id
+FROM transaction_tags
+WHERE tag_id = ?
+ORDER BY transaction_id;
+
+-- name: GetTagReport :many
+SELECT g.id, g.name, COUNT(t.id) AS transactions, CAST(COALESCE(SUM(t.cost), 0) AS REAL) AS total
+FROM tags g
+LEFT JOIN transaction_tags tt ON tt.tag_id = g.id
+LEFT JOIN transactions t ON t.id = tt.transaction_id
+  AND t.deleted_at IS NULL
+  AND t.date >= sqlc.arg(date_from)
+  AND t.date < sqlc.arg(date_to)
+GROUP BY g.id, g.name
+ORDER BY total DESC, g.name;
+
+-- name: InsertPayee :one
+INSERT INTO payees(name)
+VALUES (?)
+RETURNING id;
+
+-- name: GetAllPayees :many
+SELECT * FROM payees
+ORDER BY name;
+
+-- name: GetPayeesByIDs :many
+SELECT * FROM payees
+WHERE id IN (sqlc.slice(ids));
+
+-- name: GetPayeeByID :one
+SELECT *
+FROM payees
+WHERE id = ?;
+
+-- name: GetPayeeTransactionIDs :many
+SELECT id
+FROM transactions
+WHERE payee_id = ?
+ORDER BY id;
+
+-- name: DeletePayee :exec
+DELETE
+FROM payees
+WHERE id = ?;
+
+-- name: InsertPayeeAlias :exec
+INSERT INTO payee_aliases(payee_id, alias, normalized)
+VALUES (?, ?, ?);
+
+-- name: GetPayeeAliases :many
+SELECT *
+FROM payee_aliases
+ORDER BY payee_id, alias;
+
+-- name: GetPayeeIDByAlias :one
+SELECT payee_id
+FROM payee_aliases
+WHERE normalized = ?;
+
+-- name: DeletePayeeAlias :exec
+DELETE
+FROM payee_aliases
+WHERE id = ?;
+
+-- name: GetTransactionsWithoutPayee :many
+SELECT id, name
+FROM transactions
+WHERE payee_id IS NULL;
+
+-- name: SetTransactionPayee :exec
+UPDATE transactions
+SET payee_id = ?
+WHERE id = ?;
+
+-- name: GetPayeeReport :many
+SELECT p.id, p.name, COUNT(t.id) AS transactions, CAST(COALESCE(SUM(t.cost), 0) AS REAL) AS total
+FROM payees p
+LEFT JOIN transactions t ON t.payee_id = p.id
+  AND t.deleted_at IS NULL
+  AND t.date >= sqlc.arg(date_from)
+  AND t.date < sqlc.arg(date_to)
+GROUP BY p.id, p.name
+ORDER BY total DESC, p.name;
+
+-- name: EnsureRootCategory :exec
+INSERT OR IGNORE INTO categories(name)
+VALUES (?);
+
+-- name: GetRootCategoryByName :one
+SELECT *
+FROM categories
+WHERE parent_id IS NULL AND name = ? AND deleted_at IS NULL;
+
+-- name: SetTransactionCategory :exec
+UPDATE transactions
+SET categories_id = ?
+WHERE id = ?;
+
+-- name: GetUncategorizedTransactions :many
+-- Split transactions are left out, their lines having categories of their own.
+SELECT t.id, t.name, t.cost, t.date, t.categories_id, p.name AS payee_name
+FROM transactions t
+LEFT JOIN payees p ON p.id = t.payee_id
+WHERE t.categories_id = ?
+  AND t.deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id)
+ORDER BY t.date, t.id;
+
+-- name: InsertRule :one
+INSERT INTO rules(name, priority, match_field, match_type, pattern, amount_min, amount_max, weekdays, categories_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: GetAllRules :many
+-- Rules giving a category that is in the trash are left out until it is
+-- restored.
+SELECT *
+FROM rules
+WHERE categories_id IS NULL
+  OR categories_id IN (SELECT id FROM categories WHERE deleted_at IS NULL)
+ORDER BY priority, id;
+
+-- name: GetRuleByID :one
+SELECT *
+FROM rules
+WHERE id = ?;
+
+-- name: DeleteRule :exec
+DELETE
+FROM rules
+WHERE id = ?;
+
+-- name: AddRuleTag :exec
+INSERT OR IGNORE INTO rule_tags(rule_id, tag_id)
+VALUES (?, ?);
+
+-- name: GetRuleTags :many
+SELECT rt.rule_id, rt.tag_id, g.name
+FROM rule_tags rt
+JOIN tags g ON g.id = rt.tag_id
+ORDER BY g.name;
+
+-- name: GetCategorySamples :many
+-- Each line of a split transaction is a sample of its own category. The top
+-- level category with the given name is left out.
+SELECT t.name, a.amount, a.categories_id
+FROM transaction_allocations a
+JOIN transactions t ON t.id = a.transaction_id
+JOIN categories c ON c.id = a.categories_id
+WHERE c.parent_id IS NOT NULL OR c.name <> ?;
+
+-- name: SetTransactionNotes :exec
+UPDATE transactions
+SET notes = ?
+WHERE id = ?;
+
+-- name: InsertAttachment :one
+INSERT INTO attachments(transaction_id, filename, content_type, size, data, created_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: GetAttachments :many
+-- The data is left out, the listing only describing the files.
+SELECT id, transaction_id, filename, content_type, size, created_at
+FROM attachments
+WHERE transaction_id IN (sqlc.slice(ids))
+ORDER BY transaction_id, id;
+
+-- name: GetAttachmentByID :one
+SELECT *
+FROM attachments
+WHERE id = ?;
+
+-- name: DeleteAttachment :exec
+DELETE
+FROM attachments
+WHERE id = ?;
+
+-- name: InsertAuditEntry :exec
+INSERT INTO audit_log(user_id, entity, entity_id, action, before, after, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetAuditEntries :many
+-- Most recent first, with the email of the user who made the change.
+SELECT a.id, a.user_id, u.email AS user_email, a.entity, a.entity_id, a.action, a.before, a.after, a.created_at
+FROM audit_log a
+LEFT JOIN users u ON u.id = a.user_id
+ORDER BY a.id DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountAuditEntries :one
+SELECT COUNT(*)
+FROM audit_log;
+
+-- name: GetEntityAuditEntries :many
+-- Oldest first, so that the history reads in order.
+SELECT a.id, a.user_id, u.email AS user_email, a.entity, a.entity_id, a.action, a.before, a.after, a.created_at
+FROM audit_log a
+LEFT JOIN users u ON u.id = a.user_id
+WHERE a.entity = ? AND a.entity_id = ?
+ORDER BY a.id;
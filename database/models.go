@@ -5,10 +5,69 @@
 package database
 
 import (
+	"database/sql"
 	"time"
 )
 
+type Attachment struct {
+	ID            int64
+	TransactionID int64
+	Filename      string
+	ContentType   string
+	Size          int64
+	Data          []byte
+	CreatedAt     time.Time
+}
+
+type AuditLog struct {
+	ID        int64
+	UserID    sql.NullInt64
+	Entity    string
+	EntityID  int64
+	Action    string
+	Before    sql.NullString
+	After     sql.NullString
+	CreatedAt time.Time
+}
+
 type Category struct {
+	ID        int64
+	Name      string
+	ParentID  sql.NullInt64
+	DeletedAt sql.NullTime
+}
+
+type Payee struct {
+	ID   int64
+	Name string
+}
+
+type PayeeAlias struct {
+	ID         int64
+	PayeeID    int64
+	Alias      string
+	Normalized string
+}
+
+type Rule struct {
+	ID           int64
+	Name         string
+	Priority     int64
+	MatchField   string
+	MatchType    string
+	Pattern      string
+	AmountMin    sql.NullFloat64
+	AmountMax    sql.NullFloat64
+	Weekdays     int64
+	CategoriesID sql.NullInt64
+}
+
+type RuleTag struct {
+	RuleID int64
+	TagID  int64
+}
+
+type Tag struct {
 	ID   int64
 	Name string
 }
@@ -19,6 +78,28 @@ type Transaction struct {
 	Cost         float64
 	Date         time.Time
 	CategoriesID int64
+	PayeeID      sql.NullInt64
+	Notes        string
+	DeletedAt    sql.NullTime
+}
+
+type TransactionAllocation struct {
+	TransactionID int64
+	CategoriesID  int64
+	Amount        float64
+	Date          time.Time
+}
+
+type TransactionSplit struct {
+	ID            int64
+	TransactionID int64
+	CategoriesID  int64
+	Amount        float64
+}
+
+type TransactionTag struct {
+	TransactionID int64
+	TagID         int64
 }
 
 type User struct {
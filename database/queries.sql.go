@@ -7,9 +7,113 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"strings"
 	"time"
 )
 
+const addRuleTag = `-- name: AddRuleTag :exec
+INSERT OR IGNORE INTO rule_tags(rule_id, tag_id)
+VALUES (?, ?)
+`
+
+type AddRuleTagParams struct {
+	RuleID int64
+	TagID  int64
+}
+
+func (q *Queries) AddRuleTag(ctx context.Context, arg AddRuleTagParams) error {
+	_, err := q.db.ExecContext(ctx, addRuleTag, arg.RuleID, arg.TagID)
+	return err
+}
+
+const countAuditEntries = `-- name: CountAuditEntries :one
+SELECT COUNT(*)
+FROM audit_log
+`
+
+func (q *Queries) CountAuditEntries(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAuditEntries)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countCategoryUses = `-- name: CountCategoryUses :one
+SELECT
+  (SELECT COUNT(DISTINCT a.transaction_id) FROM transaction_allocations a WHERE a.categories_id = ?1) AS transactions,
+  (SELECT COUNT(*) FROM categories c WHERE c.parent_id = ?1 AND c.deleted_at IS NULL) AS subcategories
+`
+
+type CountCategoryUsesRow struct {
+	Transactions  int64
+	Subcategories int64
+}
+
+// Transactions, split lines included, and subcategories that are not in the
+// trash.
+func (q *Queries) CountCategoryUses(ctx context.Context, id int64) (CountCategoryUsesRow, error) {
+	row := q.db.QueryRowContext(ctx, countCategoryUses, id)
+	var i CountCategoryUsesRow
+	err := row.Scan(&i.Transactions, &i.Subcategories)
+	return i, err
+}
+
+const countTransactions = `-- name: CountTransactions :one
+SELECT COUNT(*)
+FROM transactions t
+JOIN categories c ON c.id = t.categories_id
+WHERE t.deleted_at IS NULL
+  AND (t.name LIKE ?1 ESCAPE '\' OR c.name LIKE ?1 ESCAPE '\')
+  AND t.date >= ?2
+  AND t.date < ?3
+  AND (?4 = '' OR EXISTS (
+    SELECT 1
+    FROM transaction_tags tt
+    JOIN tags g ON g.id = tt.tag_id
+    WHERE tt.transaction_id = t.id AND g.name = ?4
+  ))
+`
+
+type CountTransactionsParams struct {
+	Pattern  string
+	DateFrom time.Time
+	DateTo   time.Time
+	Tag      string
+}
+
+func (q *Queries) CountTransactions(ctx context.Context, arg CountTransactionsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTransactions,
+		arg.Pattern,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.Tag,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countTrashedTransactionCategories = `-- name: CountTrashedTransactionCategories :one
+SELECT COUNT(*)
+FROM categories
+WHERE deleted_at IS NOT NULL
+  AND id IN (
+    SELECT t.categories_id FROM transactions t WHERE t.id = ?1
+    UNION
+    SELECT s.categories_id FROM transaction_splits s WHERE s.transaction_id = ?1
+  )
+`
+
+// The categories of the transaction, or of its split lines, that are in the
+// trash.
+func (q *Queries) CountTrashedTransactionCategories(ctx context.Context, id int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countTrashedTransactionCategories, id)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (email, password_hash)
 VALUES (?, ?)
@@ -33,30 +137,84 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateU
 	return i, err
 }
 
-const deleteCategory = `-- name: DeleteCategory :exec
+const deleteAttachment = `-- name: DeleteAttachment :exec
 DELETE
-FROM categories
+FROM attachments
 WHERE id = ?
 `
 
-func (q *Queries) DeleteCategory(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteCategory, id)
+func (q *Queries) DeleteAttachment(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteAttachment, id)
 	return err
 }
 
-const deleteTransaction = `-- name: DeleteTransaction :exec
+const deletePayee = `-- name: DeletePayee :exec
 DELETE
-FROM transactions
+FROM payees
+WHERE id = ?
+`
+
+func (q *Queries) DeletePayee(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deletePayee, id)
+	return err
+}
+
+const deletePayeeAlias = `-- name: DeletePayeeAlias :exec
+DELETE
+FROM payee_aliases
+WHERE id = ?
+`
+
+func (q *Queries) DeletePayeeAlias(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deletePayeeAlias, id)
+	return err
+}
+
+const deleteRule = `-- name: DeleteRule :exec
+DELETE
+FROM rules
+WHERE id = ?
+`
+
+func (q *Queries) DeleteRule(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteRule, id)
+	return err
+}
+
+const deleteTag = `-- name: DeleteTag :exec
+DELETE
+FROM tags
 WHERE id = ?
 `
 
-func (q *Queries) DeleteTransaction(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteTransaction, id)
+func (q *Queries) DeleteTag(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteTag, id)
+	return err
+}
+
+const ensureRootCategory = `-- name: EnsureRootCategory :exec
+INSERT OR IGNORE INTO categories(name)
+VALUES (?)
+`
+
+func (q *Queries) EnsureRootCategory(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, ensureRootCategory, name)
+	return err
+}
+
+const ensureTag = `-- name: EnsureTag :exec
+INSERT OR IGNORE INTO tags(name)
+VALUES (?)
+`
+
+func (q *Queries) EnsureTag(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, ensureTag, name)
 	return err
 }
 
 const getAllCategories = `-- name: GetAllCategories :many
-SELECT id, name FROM categories
+SELECT id, name, parent_id, deleted_at FROM categories
+WHERE deleted_at IS NULL
 `
 
 func (q *Queries) GetAllCategories(ctx context.Context) ([]Category, error) {
@@ -68,6 +226,39 @@ func (q *Queries) GetAllCategories(ctx context.Context) ([]Category, error) {
 	var items []Category
 	for rows.Next() {
 		var i Category
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ParentID,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAllPayees = `-- name: GetAllPayees :many
+SELECT id, name FROM payees
+ORDER BY name
+`
+
+func (q *Queries) GetAllPayees(ctx context.Context) ([]Payee, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPayees)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Payee
+	for rows.Next() {
+		var i Payee
 		if err := rows.Scan(&i.ID, &i.Name); err != nil {
 			return nil, err
 		}
@@ -82,24 +273,35 @@ func (q *Queries) GetAllCategories(ctx context.Context) ([]Category, error) {
 	return items, nil
 }
 
-const getAllTransactions = `-- name: GetAllTransactions :many
-SELECT id, name, cost, date, categories_id FROM transactions
+const getAllRules = `-- name: GetAllRules :many
+SELECT id, name, priority, match_field, match_type, pattern, amount_min, amount_max, weekdays, categories_id
+FROM rules
+WHERE categories_id IS NULL
+  OR categories_id IN (SELECT id FROM categories WHERE deleted_at IS NULL)
+ORDER BY priority, id
 `
 
-func (q *Queries) GetAllTransactions(ctx context.Context) ([]Transaction, error) {
-	rows, err := q.db.QueryContext(ctx, getAllTransactions)
+// Rules giving a category that is in the trash are left out until it is
+// restored.
+func (q *Queries) GetAllRules(ctx context.Context) ([]Rule, error) {
+	rows, err := q.db.QueryContext(ctx, getAllRules)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Transaction
+	var items []Rule
 	for rows.Next() {
-		var i Transaction
+		var i Rule
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
-			&i.Cost,
-			&i.Date,
+			&i.Priority,
+			&i.MatchField,
+			&i.MatchType,
+			&i.Pattern,
+			&i.AmountMin,
+			&i.AmountMax,
+			&i.Weekdays,
 			&i.CategoriesID,
 		); err != nil {
 			return nil, err
@@ -115,27 +317,41 @@ func (q *Queries) GetAllTransactions(ctx context.Context) ([]Transaction, error)
 	return items, nil
 }
 
-const getCategoryByID = `-- name: GetCategoryByID :one
-SELECT id, name
-FROM categories
-WHERE id = ?
+const getAllTags = `-- name: GetAllTags :many
+SELECT id, name FROM tags
+ORDER BY name
 `
 
-func (q *Queries) GetCategoryByID(ctx context.Context, id int64) (Category, error) {
-	row := q.db.QueryRowContext(ctx, getCategoryByID, id)
-	var i Category
-	err := row.Scan(&i.ID, &i.Name)
-	return i, err
+func (q *Queries) GetAllTags(ctx context.Context) ([]Tag, error) {
+	rows, err := q.db.QueryContext(ctx, getAllTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Tag
+	for rows.Next() {
+		var i Tag
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getTransactionByCategoryID = `-- name: GetTransactionByCategoryID :many
-SELECT id, name, cost, date, categories_id
-FROM transactions
-WHERE categories_id = ?
+const getAllTransactions = `-- name: GetAllTransactions :many
+SELECT id, name, cost, date, categories_id, payee_id, notes, deleted_at FROM transactions
+WHERE deleted_at IS NULL
 `
 
-func (q *Queries) GetTransactionByCategoryID(ctx context.Context, categoriesID int64) ([]Transaction, error) {
-	rows, err := q.db.QueryContext(ctx, getTransactionByCategoryID, categoriesID)
+func (q *Queries) GetAllTransactions(ctx context.Context) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, getAllTransactions)
 	if err != nil {
 		return nil, err
 	}
@@ -149,6 +365,9 @@ func (q *Queries) GetTransactionByCategoryID(ctx context.Context, categoriesID i
 			&i.Cost,
 			&i.Date,
 			&i.CategoriesID,
+			&i.PayeeID,
+			&i.Notes,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -163,46 +382,172 @@ func (q *Queries) GetTransactionByCategoryID(ctx context.Context, categoriesID i
 	return items, nil
 }
 
-const getTransactionByID = `-- name: GetTransactionByID :one
-SELECT id, name, cost, date, categories_id
-FROM transactions
+const getAttachmentByID = `-- name: GetAttachmentByID :one
+SELECT id, transaction_id, filename, content_type, size, data, created_at
+FROM attachments
 WHERE id = ?
 `
 
-func (q *Queries) GetTransactionByID(ctx context.Context, id int64) (Transaction, error) {
-	row := q.db.QueryRowContext(ctx, getTransactionByID, id)
-	var i Transaction
+func (q *Queries) GetAttachmentByID(ctx context.Context, id int64) (Attachment, error) {
+	row := q.db.QueryRowContext(ctx, getAttachmentByID, id)
+	var i Attachment
 	err := row.Scan(
 		&i.ID,
-		&i.Name,
-		&i.Cost,
-		&i.Date,
-		&i.CategoriesID,
+		&i.TransactionID,
+		&i.Filename,
+		&i.ContentType,
+		&i.Size,
+		&i.Data,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getTransactionByName = `-- name: GetTransactionByName :many
-SELECT id, name, cost, date, categories_id
-FROM transactions
-WHERE name = ?
+const getAttachments = `-- name: GetAttachments :many
+SELECT id, transaction_id, filename, content_type, size, created_at
+FROM attachments
+WHERE transaction_id IN (/*SLICE:ids*/?)
+ORDER BY transaction_id, id
 `
 
-func (q *Queries) GetTransactionByName(ctx context.Context, name string) ([]Transaction, error) {
-	rows, err := q.db.QueryContext(ctx, getTransactionByName, name)
+type GetAttachmentsRow struct {
+	ID            int64
+	TransactionID int64
+	Filename      string
+	ContentType   string
+	Size          int64
+	CreatedAt     time.Time
+}
+
+// The data is left out, the listing only describing the files.
+func (q *Queries) GetAttachments(ctx context.Context, ids []int64) ([]GetAttachmentsRow, error) {
+	query := getAttachments
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Transaction
+	var items []GetAttachmentsRow
 	for rows.Next() {
-		var i Transaction
+		var i GetAttachmentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.Filename,
+			&i.ContentType,
+			&i.Size,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAuditEntries = `-- name: GetAuditEntries :many
+SELECT a.id, a.user_id, u.email AS user_email, a.entity, a.entity_id, a.action, a.before, a.after, a.created_at
+FROM audit_log a
+LEFT JOIN users u ON u.id = a.user_id
+ORDER BY a.id DESC
+LIMIT ?1 OFFSET ?2
+`
+
+type GetAuditEntriesParams struct {
+	PageLimit  int64
+	PageOffset int64
+}
+
+type GetAuditEntriesRow struct {
+	ID        int64
+	UserID    sql.NullInt64
+	UserEmail sql.NullString
+	Entity    string
+	EntityID  int64
+	Action    string
+	Before    sql.NullString
+	After     sql.NullString
+	CreatedAt time.Time
+}
+
+// Most recent first, with the email of the user who made the change.
+func (q *Queries) GetAuditEntries(ctx context.Context, arg GetAuditEntriesParams) ([]GetAuditEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAuditEntries, arg.PageLimit, arg.PageOffset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAuditEntriesRow
+	for rows.Next() {
+		var i GetAuditEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.UserEmail,
+			&i.Entity,
+			&i.EntityID,
+			&i.Action,
+			&i.Before,
+			&i.After,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategoriesByIDs = `-- name: GetCategoriesByIDs :many
+SELECT id, name, parent_id, deleted_at FROM categories
+WHERE id IN (/*SLICE:ids*/?) AND deleted_at IS NULL
+`
+
+func (q *Queries) GetCategoriesByIDs(ctx context.Context, ids []int64) ([]Category, error) {
+	query := getCategoriesByIDs
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Category
+	for rows.Next() {
+		var i Category
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
-			&i.Cost,
-			&i.Date,
-			&i.CategoriesID,
+			&i.ParentID,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -217,45 +562,1601 @@ func (q *Queries) GetTransactionByName(ctx context.Context, name string) ([]Tran
 	return items, nil
 }
 
-const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, password_hash FROM users WHERE email = ?
+const getCategoryAncestors = `-- name: GetCategoryAncestors :many
+WITH RECURSIVE ancestors(id, parent_id) AS (
+  SELECT c.id, c.parent_id FROM categories c WHERE c.id = ?1
+  UNION
+  SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+)
+SELECT id FROM ancestors
 `
 
-func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
-	var i User
-	err := row.Scan(&i.ID, &i.Email, &i.PasswordHash)
-	return i, err
+// The category itself comes first. UNION stops on a cycle, should one
+// exist already.
+func (q *Queries) GetCategoryAncestors(ctx context.Context, id int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getCategoryAncestors, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const insertCategory = `-- name: InsertCategory :exec
-INSERT INTO categories(name)
-VALUES (?)
+const getCategoryByID = `-- name: GetCategoryByID :one
+SELECT id, name, parent_id, deleted_at
+FROM categories
+WHERE id = ? AND deleted_at IS NULL
 `
 
-func (q *Queries) InsertCategory(ctx context.Context, name string) error {
-	_, err := q.db.ExecContext(ctx, insertCategory, name)
-	return err
+func (q *Queries) GetCategoryByID(ctx context.Context, id int64) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getCategoryByID, id)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ParentID,
+		&i.DeletedAt,
+	)
+	return i, err
 }
 
-const insertTransaction = `-- name: InsertTransaction :exec
-INSERT INTO transactions(name, cost, date, categories_id)
-VALUES (?, ?, ?, ?)
+const getCategoryReport = `-- name: GetCategoryReport :many
+SELECT c.id, c.name, c.parent_id, COUNT(a.transaction_id) AS transactions, CAST(COALESCE(SUM(a.amount), 0) AS REAL) AS total
+FROM categories c
+LEFT JOIN transaction_allocations a ON a.categories_id = c.id
+  AND a.date >= ?1
+  AND a.date < ?2
+WHERE c.deleted_at IS NULL
+GROUP BY c.id, c.name, c.parent_id
+ORDER BY c.name
 `
 
-type InsertTransactionParams struct {
+type GetCategoryReportParams struct {
+	DateFrom time.Time
+	DateTo   time.Time
+}
+
+type GetCategoryReportRow struct {
+	ID           int64
 	Name         string
-	Cost         float64
-	Date         time.Time
-	CategoriesID int64
+	ParentID     sql.NullInt64
+	Transactions int64
+	Total        float64
 }
 
-func (q *Queries) InsertTransaction(ctx context.Context, arg InsertTransactionParams) error {
-	_, err := q.db.ExecContext(ctx, insertTransaction,
-		arg.Name,
-		arg.Cost,
-		arg.Date,
-		arg.CategoriesID,
-	)
+func (q *Queries) GetCategoryReport(ctx context.Context, arg GetCategoryReportParams) ([]GetCategoryReportRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCategoryReport, arg.DateFrom, arg.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategoryReportRow
+	for rows.Next() {
+		var i GetCategoryReportRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ParentID,
+			&i.Transactions,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategorySamples = `-- name: GetCategorySamples :many
+SELECT t.name, a.amount, a.categories_id
+FROM transaction_allocations a
+JOIN transactions t ON t.id = a.transaction_id
+JOIN categories c ON c.id = a.categories_id
+WHERE c.parent_id IS NOT NULL OR c.name <> ?
+`
+
+type GetCategorySamplesRow struct {
+	Name         string
+	Amount       float64
+	CategoriesID int64
+}
+
+// Each line of a split transaction is a sample of its own category. The top
+// level category with the given name is left out.
+func (q *Queries) GetCategorySamples(ctx context.Context, name string) ([]GetCategorySamplesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCategorySamples, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategorySamplesRow
+	for rows.Next() {
+		var i GetCategorySamplesRow
+		if err := rows.Scan(&i.Name, &i.Amount, &i.CategoriesID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEntityAuditEntries = `-- name: GetEntityAuditEntries :many
+SELECT a.id, a.user_id, u.email AS user_email, a.entity, a.entity_id, a.action, a.before, a.after, a.created_at
+FROM audit_log a
+LEFT JOIN users u ON u.id = a.user_id
+WHERE a.entity = ? AND a.entity_id = ?
+ORDER BY a.id
+`
+
+type GetEntityAuditEntriesParams struct {
+	Entity   string
+	EntityID int64
+}
+
+type GetEntityAuditEntriesRow struct {
+	ID        int64
+	UserID    sql.NullInt64
+	UserEmail sql.NullString
+	Entity    string
+	EntityID  int64
+	Action    string
+	Before    sql.NullString
+	After     sql.NullString
+	CreatedAt time.Time
+}
+
+// Oldest first, so that the history reads in order.
+func (q *Queries) GetEntityAuditEntries(ctx context.Context, arg GetEntityAuditEntriesParams) ([]GetEntityAuditEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getEntityAuditEntries, arg.Entity, arg.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEntityAuditEntriesRow
+	for rows.Next() {
+		var i GetEntityAuditEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.UserEmail,
+			&i.Entity,
+			&i.EntityID,
+			&i.Action,
+			&i.Before,
+			&i.After,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPayeeAliases = `-- name: GetPayeeAliases :many
+SELECT id, payee_id, alias, normalized
+FROM payee_aliases
+ORDER BY payee_id, alias
+`
+
+func (q *Queries) GetPayeeAliases(ctx context.Context) ([]PayeeAlias, error) {
+	rows, err := q.db.QueryContext(ctx, getPayeeAliases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PayeeAlias
+	for rows.Next() {
+		var i PayeeAlias
+		if err := rows.Scan(
+			&i.ID,
+			&i.PayeeID,
+			&i.Alias,
+			&i.Normalized,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPayeeByID = `-- name: GetPayeeByID :one
+SELECT id, name
+FROM payees
+WHERE id = ?
+`
+
+func (q *Queries) GetPayeeByID(ctx context.Context, id int64) (Payee, error) {
+	row := q.db.QueryRowContext(ctx, getPayeeByID, id)
+	var i Payee
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
+const getPayeeIDByAlias = `-- name: GetPayeeIDByAlias :one
+SELECT payee_id
+FROM payee_aliases
+WHERE normalized = ?
+`
+
+func (q *Queries) GetPayeeIDByAlias(ctx context.Context, normalized string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getPayeeIDByAlias, normalized)
+	var payee_id int64
+	err := row.Scan(&payee_id)
+	return payee_id, err
+}
+
+const getPayeeReport = `-- name: GetPayeeReport :many
+SELECT p.id, p.name, COUNT(t.id) AS transactions, CAST(COALESCE(SUM(t.cost), 0) AS REAL) AS total
+FROM payees p
+LEFT JOIN transactions t ON t.payee_id = p.id
+  AND t.deleted_at IS NULL
+  AND t.date >= ?1
+  AND t.date < ?2
+GROUP BY p.id, p.name
+ORDER BY total DESC, p.name
+`
+
+type GetPayeeReportParams struct {
+	DateFrom time.Time
+	DateTo   time.Time
+}
+
+type GetPayeeReportRow struct {
+	ID           int64
+	Name         string
+	Transactions int64
+	Total        float64
+}
+
+func (q *Queries) GetPayeeReport(ctx context.Context, arg GetPayeeReportParams) ([]GetPayeeReportRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPayeeReport, arg.DateFrom, arg.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPayeeReportRow
+	for rows.Next() {
+		var i GetPayeeReportRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Transactions,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPayeeTransactionIDs = `-- name: GetPayeeTransactionIDs :many
+SELECT id
+FROM transactions
+WHERE payee_id = ?
+ORDER BY id
+`
+
+func (q *Queries) GetPayeeTransactionIDs(ctx context.Context, payeeID sql.NullInt64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getPayeeTransactionIDs, payeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPayeesByIDs = `-- name: GetPayeesByIDs :many
+SELECT id, name FROM payees
+WHERE id IN (/*SLICE:ids*/?)
+`
+
+func (q *Queries) GetPayeesByIDs(ctx context.Context, ids []int64) ([]Payee, error) {
+	query := getPayeesByIDs
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Payee
+	for rows.Next() {
+		var i Payee
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRootCategoryByName = `-- name: GetRootCategoryByName :one
+SELECT id, name, parent_id, deleted_at
+FROM categories
+WHERE parent_id IS NULL AND name = ? AND deleted_at IS NULL
+`
+
+func (q *Queries) GetRootCategoryByName(ctx context.Context, name string) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getRootCategoryByName, name)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ParentID,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getRuleByID = `-- name: GetRuleByID :one
+SELECT id, name, priority, match_field, match_type, pattern, amount_min, amount_max, weekdays, categories_id
+FROM rules
+WHERE id = ?
+`
+
+func (q *Queries) GetRuleByID(ctx context.Context, id int64) (Rule, error) {
+	row := q.db.QueryRowContext(ctx, getRuleByID, id)
+	var i Rule
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Priority,
+		&i.MatchField,
+		&i.MatchType,
+		&i.Pattern,
+		&i.AmountMin,
+		&i.AmountMax,
+		&i.Weekdays,
+		&i.CategoriesID,
+	)
+	return i, err
+}
+
+const getRuleTags = `-- name: GetRuleTags :many
+SELECT rt.rule_id, rt.tag_id, g.name
+FROM rule_tags rt
+JOIN tags g ON g.id = rt.tag_id
+ORDER BY g.name
+`
+
+type GetRuleTagsRow struct {
+	RuleID int64
+	TagID  int64
+	Name   string
+}
+
+func (q *Queries) GetRuleTags(ctx context.Context) ([]GetRuleTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRuleTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRuleTagsRow
+	for rows.Next() {
+		var i GetRuleTagsRow
+		if err := rows.Scan(&i.RuleID, &i.TagID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTagByID = `-- name: GetTagByID :one
+SELECT id, name
+FROM tags
+WHERE id = ?
+`
+
+func (q *Queries) GetTagByID(ctx context.Context, id int64) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, getTagByID, id)
+	var i Tag
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
+const getTagByName = `-- name: GetTagByName :one
+SELECT id, name
+FROM tags
+WHERE name = ?
+`
+
+func (q *Queries) GetTagByName(ctx context.Context, name string) (Tag, error) {
+	row := q.db.QueryRowContext(ctx, getTagByName, name)
+	var i Tag
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
+const getTagReport = `-- name: GetTagReport :many
+SELECT g.id, g.name, COUNT(t.id) AS transactions, CAST(COALESCE(SUM(t.cost), 0) AS REAL) AS total
+FROM tags g
+LEFT JOIN transaction_tags tt ON tt.tag_id = g.id
+LEFT JOIN transactions t ON t.id = tt.transaction_id
+  AND t.deleted_at IS NULL
+  AND t.date >= ?1
+  AND t.date < ?2
+GROUP BY g.id, g.name
+ORDER BY total DESC, g.name
+`
+
+type GetTagReportParams struct {
+	DateFrom time.Time
+	DateTo   time.Time
+}
+
+type GetTagReportRow struct {
+	ID           int64
+	Name         string
+	Transactions int64
+	Total        float64
+}
+
+func (q *Queries) GetTagReport(ctx context.Context, arg GetTagReportParams) ([]GetTagReportRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTagReport, arg.DateFrom, arg.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTagReportRow
+	for rows.Next() {
+		var i GetTagReportRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Transactions,
+			&i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTagTransactionIDs = `-- name: GetTagTransactionIDs :many
+SELECT transaction_id
+FROM transaction_tags
+WHERE tag_id = ?
+ORDER BY transaction_id
+`
+
+func (q *Queries) GetTagTransactionIDs(ctx context.Context, tagID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getTagTransactionIDs, tagID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var transaction_id int64
+		if err := rows.Scan(&transaction_id); err != nil {
+			return nil, err
+		}
+		items = append(items, transaction_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionByCategoryID = `-- name: GetTransactionByCategoryID :many
+SELECT id, name, cost, date, categories_id, payee_id, notes, deleted_at
+FROM transactions
+WHERE id IN (
+  SELECT transaction_id FROM transaction_allocations WHERE categories_id = ?
+)
+`
+
+// Split transactions are found from the category of any of their lines.
+func (q *Queries) GetTransactionByCategoryID(ctx context.Context, categoriesID int64) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, getTransactionByCategoryID, categoriesID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Cost,
+			&i.Date,
+			&i.CategoriesID,
+			&i.PayeeID,
+			&i.Notes,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionByID = `-- name: GetTransactionByID :one
+SELECT id, name, cost, date, categories_id, payee_id, notes, deleted_at
+FROM transactions
+WHERE id = ? AND deleted_at IS NULL
+`
+
+func (q *Queries) GetTransactionByID(ctx context.Context, id int64) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, getTransactionByID, id)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Cost,
+		&i.Date,
+		&i.CategoriesID,
+		&i.PayeeID,
+		&i.Notes,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getTransactionByName = `-- name: GetTransactionByName :many
+SELECT id, name, cost, date, categories_id, payee_id, notes, deleted_at
+FROM transactions
+WHERE name = ? AND deleted_at IS NULL
+`
+
+func (q *Queries) GetTransactionByName(ctx context.Context, name string) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, getTransactionByName, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Cost,
+			&i.Date,
+			&i.CategoriesID,
+			&i.PayeeID,
+			&i.Notes,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionSplits = `-- name: GetTransactionSplits :many
+SELECT id, transaction_id, categories_id, amount
+FROM transaction_splits
+WHERE transaction_id IN (/*SLICE:ids*/?)
+ORDER BY transaction_id, id
+`
+
+func (q *Queries) GetTransactionSplits(ctx context.Context, ids []int64) ([]TransactionSplit, error) {
+	query := getTransactionSplits
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TransactionSplit
+	for rows.Next() {
+		var i TransactionSplit
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.CategoriesID,
+			&i.Amount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionTags = `-- name: GetTransactionTags :many
+SELECT tt.transaction_id, g.name
+FROM transaction_tags tt
+JOIN tags g ON g.id = tt.tag_id
+WHERE tt.transaction_id IN (/*SLICE:ids*/?)
+ORDER BY g.name
+`
+
+type GetTransactionTagsRow struct {
+	TransactionID int64
+	Name          string
+}
+
+func (q *Queries) GetTransactionTags(ctx context.Context, ids []int64) ([]GetTransactionTagsRow, error) {
+	query := getTransactionTags
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTransactionTagsRow
+	for rows.Next() {
+		var i GetTransactionTagsRow
+		if err := rows.Scan(&i.TransactionID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionsPage = `-- name: GetTransactionsPage :many
+SELECT t.id, t.name, t.cost, t.date, t.categories_id, t.payee_id, t.notes, t.deleted_at
+FROM transactions t
+JOIN categories c ON c.id = t.categories_id
+WHERE t.deleted_at IS NULL
+  AND (t.name LIKE ?1 ESCAPE '\' OR c.name LIKE ?1 ESCAPE '\')
+  AND t.date >= ?2
+  AND t.date < ?3
+  AND (?4 = '' OR EXISTS (
+    SELECT 1
+    FROM transaction_tags tt
+    JOIN tags g ON g.id = tt.tag_id
+    WHERE tt.transaction_id = t.id AND g.name = ?4
+  ))
+ORDER BY
+  CASE WHEN ?5 = 'date' AND NOT ?6 THEN t.date END ASC,
+  CASE WHEN ?5 = 'date' AND ?6 THEN t.date END DESC,
+  CASE WHEN ?5 = 'cost' AND NOT ?6 THEN t.cost END ASC,
+  CASE WHEN ?5 = 'cost' AND ?6 THEN t.cost END DESC,
+  CASE WHEN ?5 = 'name' AND NOT ?6 THEN t.name END COLLATE NOCASE ASC,
+  CASE WHEN ?5 = 'name' AND ?6 THEN t.name END COLLATE NOCASE DESC,
+  CASE WHEN ?5 = 'category' AND NOT ?6 THEN c.name END COLLATE NOCASE ASC,
+  CASE WHEN ?5 = 'category' AND ?6 THEN c.name END COLLATE NOCASE DESC,
+  t.id ASC
+LIMIT ?7 OFFSET ?8
+`
+
+type GetTransactionsPageParams struct {
+	Pattern    string
+	DateFrom   time.Time
+	DateTo     time.Time
+	Tag        string
+	SortKey    string
+	Descending bool
+	PageLimit  int64
+	PageOffset int64
+}
+
+func (q *Queries) GetTransactionsPage(ctx context.Context, arg GetTransactionsPageParams) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, getTransactionsPage,
+		arg.Pattern,
+		arg.DateFrom,
+		arg.DateTo,
+		arg.Tag,
+		arg.SortKey,
+		arg.Descending,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Cost,
+			&i.Date,
+			&i.CategoriesID,
+			&i.PayeeID,
+			&i.Notes,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTransactionsWithoutPayee = `-- name: GetTransactionsWithoutPayee :many
+SELECT id, name
+FROM transactions
+WHERE payee_id IS NULL
+`
+
+type GetTransactionsWithoutPayeeRow struct {
+	ID   int64
+	Name string
+}
+
+func (q *Queries) GetTransactionsWithoutPayee(ctx context.Context) ([]GetTransactionsWithoutPayeeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTransactionsWithoutPayee)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTransactionsWithoutPayeeRow
+	for rows.Next() {
+		var i GetTransactionsWithoutPayeeRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrashedCategories = `-- name: GetTrashedCategories :many
+SELECT id, name, parent_id, deleted_at
+FROM categories
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC
+`
+
+func (q *Queries) GetTrashedCategories(ctx context.Context) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, getTrashedCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Category
+	for rows.Next() {
+		var i Category
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ParentID,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrashedCategoryByID = `-- name: GetTrashedCategoryByID :one
+SELECT id, name, parent_id, deleted_at
+FROM categories
+WHERE id = ? AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) GetTrashedCategoryByID(ctx context.Context, id int64) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getTrashedCategoryByID, id)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ParentID,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getTrashedTransactionByID = `-- name: GetTrashedTransactionByID :one
+SELECT id, name, cost, date, categories_id, payee_id, notes, deleted_at
+FROM transactions
+WHERE id = ? AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) GetTrashedTransactionByID(ctx context.Context, id int64) (Transaction, error) {
+	row := q.db.QueryRowContext(ctx, getTrashedTransactionByID, id)
+	var i Transaction
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Cost,
+		&i.Date,
+		&i.CategoriesID,
+		&i.PayeeID,
+		&i.Notes,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getTrashedTransactions = `-- name: GetTrashedTransactions :many
+SELECT id, name, cost, date, categories_id, payee_id, notes, deleted_at
+FROM transactions
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id DESC
+`
+
+func (q *Queries) GetTrashedTransactions(ctx context.Context) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, getTrashedTransactions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Cost,
+			&i.Date,
+			&i.CategoriesID,
+			&i.PayeeID,
+			&i.Notes,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUncategorizedTransactions = `-- name: GetUncategorizedTransactions :many
+SELECT t.id, t.name, t.cost, t.date, t.categories_id, p.name AS payee_name
+FROM transactions t
+LEFT JOIN payees p ON p.id = t.payee_id
+WHERE t.categories_id = ?
+  AND t.deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id)
+ORDER BY t.date, t.id
+`
+
+type GetUncategorizedTransactionsRow struct {
+	ID           int64
+	Name         string
+	Cost         float64
+	Date         time.Time
+	CategoriesID int64
+	PayeeName    sql.NullString
+}
+
+// Split transactions are left out, their lines having categories of their own.
+func (q *Queries) GetUncategorizedTransactions(ctx context.Context, categoriesID int64) ([]GetUncategorizedTransactionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUncategorizedTransactions, categoriesID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUncategorizedTransactionsRow
+	for rows.Next() {
+		var i GetUncategorizedTransactionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Cost,
+			&i.Date,
+			&i.CategoriesID,
+			&i.PayeeName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT id, email, password_hash FROM users WHERE email = ?
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(&i.ID, &i.Email, &i.PasswordHash)
+	return i, err
+}
+
+const insertAttachment = `-- name: InsertAttachment :one
+INSERT INTO attachments(transaction_id, filename, content_type, size, data, created_at)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id
+`
+
+type InsertAttachmentParams struct {
+	TransactionID int64
+	Filename      string
+	ContentType   string
+	Size          int64
+	Data          []byte
+	CreatedAt     time.Time
+}
+
+func (q *Queries) InsertAttachment(ctx context.Context, arg InsertAttachmentParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, insertAttachment,
+		arg.TransactionID,
+		arg.Filename,
+		arg.ContentType,
+		arg.Size,
+		arg.Data,
+		arg.CreatedAt,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const insertAuditEntry = `-- name: InsertAuditEntry :exec
+INSERT INTO audit_log(user_id, entity, entity_id, action, before, after, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+type InsertAuditEntryParams struct {
+	UserID    sql.NullInt64
+	Entity    string
+	EntityID  int64
+	Action    string
+	Before    sql.NullString
+	After     sql.NullString
+	CreatedAt time.Time
+}
+
+func (q *Queries) InsertAuditEntry(ctx context.Context, arg InsertAuditEntryParams) error {
+	_, err := q.db.ExecContext(ctx, insertAuditEntry,
+		arg.UserID,
+		arg.Entity,
+		arg.EntityID,
+		arg.Action,
+		arg.Before,
+		arg.After,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const insertCategory = `-- name: InsertCategory :one
+INSERT INTO categories(name, parent_id)
+VALUES (?, ?)
+RETURNING id
+`
+
+type InsertCategoryParams struct {
+	Name     string
+	ParentID sql.NullInt64
+}
+
+func (q *Queries) InsertCategory(ctx context.Context, arg InsertCategoryParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, insertCategory, arg.Name, arg.ParentID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const insertPayee = `-- name: InsertPayee :one
+INSERT INTO payees(name)
+VALUES (?)
+RETURNING id
+`
+
+func (q *Queries) InsertPayee(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, insertPayee, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const insertPayeeAlias = `-- name: InsertPayeeAlias :exec
+INSERT INTO payee_aliases(payee_id, alias, normalized)
+VALUES (?, ?, ?)
+`
+
+type InsertPayeeAliasParams struct {
+	PayeeID    int64
+	Alias      string
+	Normalized string
+}
+
+func (q *Queries) InsertPayeeAlias(ctx context.Context, arg InsertPayeeAliasParams) error {
+	_, err := q.db.ExecContext(ctx, insertPayeeAlias, arg.PayeeID, arg.Alias, arg.Normalized)
+	return err
+}
+
+const insertRule = `-- name: InsertRule :one
+INSERT INTO rules(name, priority, match_field, match_type, pattern, amount_min, amount_max, weekdays, categories_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`
+
+type InsertRuleParams struct {
+	Name         string
+	Priority     int64
+	MatchField   string
+	MatchType    string
+	Pattern      string
+	AmountMin    sql.NullFloat64
+	AmountMax    sql.NullFloat64
+	Weekdays     int64
+	CategoriesID sql.NullInt64
+}
+
+func (q *Queries) InsertRule(ctx context.Context, arg InsertRuleParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, insertRule,
+		arg.Name,
+		arg.Priority,
+		arg.MatchField,
+		arg.MatchType,
+		arg.Pattern,
+		arg.AmountMin,
+		arg.AmountMax,
+		arg.Weekdays,
+		arg.CategoriesID,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const insertTag = `-- name: InsertTag :exec
+INSERT INTO tags(name)
+VALUES (?)
+`
+
+func (q *Queries) InsertTag(ctx context.Context, name string) error {
+	_, err := q.db.ExecContext(ctx, insertTag, name)
+	return err
+}
+
+const insertTransaction = `-- name: InsertTransaction :one
+INSERT INTO transactions(name, cost, date, categories_id, payee_id, notes)
+VALUES (?, ?, ?, ?, ?, ?)
+RETURNING id
+`
+
+type InsertTransactionParams struct {
+	Name         string
+	Cost         float64
+	Date         time.Time
+	CategoriesID int64
+	PayeeID      sql.NullInt64
+	Notes        string
+}
+
+func (q *Queries) InsertTransaction(ctx context.Context, arg InsertTransactionParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, insertTransaction,
+		arg.Name,
+		arg.Cost,
+		arg.Date,
+		arg.CategoriesID,
+		arg.PayeeID,
+		arg.Notes,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const insertTransactionSplit = `-- name: InsertTransactionSplit :exec
+INSERT INTO transaction_splits(transaction_id, categories_id, amount)
+VALUES (?, ?, ?)
+`
+
+type InsertTransactionSplitParams struct {
+	TransactionID int64
+	CategoriesID  int64
+	Amount        float64
+}
+
+func (q *Queries) InsertTransactionSplit(ctx context.Context, arg InsertTransactionSplitParams) error {
+	_, err := q.db.ExecContext(ctx, insertTransactionSplit, arg.TransactionID, arg.CategoriesID, arg.Amount)
+	return err
+}
+
+const moveCategoryRules = `-- name: MoveCategoryRules :exec
+UPDATE rules
+SET categories_id = ?
+WHERE categories_id = ?
+`
+
+type MoveCategoryRulesParams struct {
+	ToID   int64
+	FromID int64
+}
+
+func (q *Queries) MoveCategoryRules(ctx context.Context, arg MoveCategoryRulesParams) error {
+	_, err := q.db.ExecContext(ctx, moveCategoryRules, arg.ToID, arg.FromID)
+	return err
+}
+
+const moveCategorySplits = `-- name: MoveCategorySplits :many
+UPDATE transaction_splits
+SET categories_id = ?
+WHERE categories_id = ?
+  AND transaction_id IN (SELECT id FROM transactions WHERE deleted_at IS NULL)
+RETURNING transaction_id
+`
+
+type MoveCategorySplitsParams struct {
+	ToID   int64
+	FromID int64
+}
+
+func (q *Queries) MoveCategorySplits(ctx context.Context, arg MoveCategorySplitsParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, moveCategorySplits, arg.ToID, arg.FromID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var transaction_id int64
+		if err := rows.Scan(&transaction_id); err != nil {
+			return nil, err
+		}
+		items = append(items, transaction_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveCategoryTransactions = `-- name: MoveCategoryTransactions :many
+UPDATE transactions
+SET categories_id = ?
+WHERE categories_id = ? AND deleted_at IS NULL
+RETURNING id
+`
+
+type MoveCategoryTransactionsParams struct {
+	ToID   int64
+	FromID int64
+}
+
+// Transactions in the trash keep their category.
+func (q *Queries) MoveCategoryTransactions(ctx context.Context, arg MoveCategoryTransactionsParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, moveCategoryTransactions, arg.ToID, arg.FromID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveSubcategories = `-- name: MoveSubcategories :many
+UPDATE categories
+SET parent_id = ?
+WHERE parent_id = ? AND deleted_at IS NULL
+RETURNING id
+`
+
+type MoveSubcategoriesParams struct {
+	ToID   int64
+	FromID int64
+}
+
+func (q *Queries) MoveSubcategories(ctx context.Context, arg MoveSubcategoriesParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, moveSubcategories, arg.ToID, arg.FromID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeCategories = `-- name: PurgeCategories :many
+DELETE
+FROM categories
+WHERE deleted_at < ?1
+  AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.categories_id = categories.id)
+  AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.categories_id = categories.id)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM categories c
+    WHERE c.parent_id = categories.id
+      AND (c.deleted_at IS NULL OR c.deleted_at >= ?1)
+  )
+RETURNING id, name, parent_id, deleted_at
+`
+
+// Categories still used by a transaction, even one in the trash, or by a
+// subcategory kept longer are left for a later purge.
+func (q *Queries) PurgeCategories(ctx context.Context, before sql.NullTime) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, purgeCategories, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Category
+	for rows.Next() {
+		var i Category
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ParentID,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeTransactions = `-- name: PurgeTransactions :many
+DELETE
+FROM transactions
+WHERE deleted_at < ?
+RETURNING id, name, cost, date, categories_id, payee_id, notes, deleted_at
+`
+
+func (q *Queries) PurgeTransactions(ctx context.Context, deletedAt sql.NullTime) ([]Transaction, error) {
+	rows, err := q.db.QueryContext(ctx, purgeTransactions, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Cost,
+			&i.Date,
+			&i.CategoriesID,
+			&i.PayeeID,
+			&i.Notes,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const renameTag = `-- name: RenameTag :exec
+UPDATE tags
+SET name = ?
+WHERE id = ?
+`
+
+type RenameTagParams struct {
+	Name string
+	ID   int64
+}
+
+func (q *Queries) RenameTag(ctx context.Context, arg RenameTagParams) error {
+	_, err := q.db.ExecContext(ctx, renameTag, arg.Name, arg.ID)
+	return err
+}
+
+const restoreCategory = `-- name: RestoreCategory :exec
+UPDATE categories
+SET deleted_at = NULL
+WHERE id = ?
+`
+
+func (q *Queries) RestoreCategory(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, restoreCategory, id)
+	return err
+}
+
+const restoreTransaction = `-- name: RestoreTransaction :exec
+UPDATE transactions
+SET deleted_at = NULL
+WHERE id = ?
+`
+
+func (q *Queries) RestoreTransaction(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, restoreTransaction, id)
+	return err
+}
+
+const setTransactionCategory = `-- name: SetTransactionCategory :exec
+UPDATE transactions
+SET categories_id = ?
+WHERE id = ?
+`
+
+type SetTransactionCategoryParams struct {
+	CategoriesID int64
+	ID           int64
+}
+
+func (q *Queries) SetTransactionCategory(ctx context.Context, arg SetTransactionCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, setTransactionCategory, arg.CategoriesID, arg.ID)
+	return err
+}
+
+const setTransactionNotes = `-- name: SetTransactionNotes :exec
+UPDATE transactions
+SET notes = ?
+WHERE id = ?
+`
+
+type SetTransactionNotesParams struct {
+	Notes string
+	ID    int64
+}
+
+func (q *Queries) SetTransactionNotes(ctx context.Context, arg SetTransactionNotesParams) error {
+	_, err := q.db.ExecContext(ctx, setTransactionNotes, arg.Notes, arg.ID)
+	return err
+}
+
+const setTransactionPayee = `-- name: SetTransactionPayee :exec
+UPDATE transactions
+SET payee_id = ?
+WHERE id = ?
+`
+
+type SetTransactionPayeeParams struct {
+	PayeeID sql.NullInt64
+	ID      int64
+}
+
+func (q *Queries) SetTransactionPayee(ctx context.Context, arg SetTransactionPayeeParams) error {
+	_, err := q.db.ExecContext(ctx, setTransactionPayee, arg.PayeeID, arg.ID)
+	return err
+}
+
+const tagTransaction = `-- name: TagTransaction :exec
+INSERT OR IGNORE INTO transaction_tags(transaction_id, tag_id)
+VALUES (?, ?)
+`
+
+type TagTransactionParams struct {
+	TransactionID int64
+	TagID         int64
+}
+
+func (q *Queries) TagTransaction(ctx context.Context, arg TagTransactionParams) error {
+	_, err := q.db.ExecContext(ctx, tagTransaction, arg.TransactionID, arg.TagID)
+	return err
+}
+
+const trashCategory = `-- name: TrashCategory :exec
+UPDATE categories
+SET deleted_at = ?
+WHERE id = ?
+`
+
+type TrashCategoryParams struct {
+	DeletedAt sql.NullTime
+	ID        int64
+}
+
+func (q *Queries) TrashCategory(ctx context.Context, arg TrashCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, trashCategory, arg.DeletedAt, arg.ID)
+	return err
+}
+
+const trashTransaction = `-- name: TrashTransaction :exec
+UPDATE transactions
+SET deleted_at = ?
+WHERE id = ?
+`
+
+type TrashTransactionParams struct {
+	DeletedAt sql.NullTime
+	ID        int64
+}
+
+func (q *Queries) TrashTransaction(ctx context.Context, arg TrashTransactionParams) error {
+	_, err := q.db.ExecContext(ctx, trashTransaction, arg.DeletedAt, arg.ID)
+	return err
+}
+
+const updateCategory = `-- name: UpdateCategory :exec
+UPDATE categories
+SET name = ?, parent_id = ?
+WHERE id = ?
+`
+
+type UpdateCategoryParams struct {
+	Name     string
+	ParentID sql.NullInt64
+	ID       int64
+}
+
+func (q *Queries) UpdateCategory(ctx context.Context, arg UpdateCategoryParams) error {
+	_, err := q.db.ExecContext(ctx, updateCategory, arg.Name, arg.ParentID, arg.ID)
 	return err
 }
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"quattrinitrack/database"
+	middleware "quattrinitrack/middlewares"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// maxAttachmentSize is the largest file, in bytes, that can be attached to a
+// transaction.
+const maxAttachmentSize = 10 << 20
+
+const attachmentTooLarge = "the file must be at most 10 MiB"
+
+// attachmentTypes are the content types accepted for attachments: receipts
+// come as PDF documents or as photos.
+var attachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/gif":       true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+}
+
+type AttachmentQuerier interface {
+	GetTransactionByID(ctx context.Context, id int64) (database.Transaction, error)
+	InsertAttachment(ctx context.Context, arg database.InsertAttachmentParams) (int64, error)
+	GetAttachmentByID(ctx context.Context, id int64) (database.Attachment, error)
+	DeleteAttachment(ctx context.Context, id int64) error
+}
+
+// Attachment handles the files kept with transactions: GET downloads one,
+// POST uploads one as the file field of a multipart form and DELETE removes
+// one.
+func Attachment(queries AttachmentQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		if req.Method == http.MethodPost {
+			transactionID, err := strconv.ParseInt(req.URL.Query().Get("transaction_id"), 10, 64)
+			if err != nil {
+				slog.WarnContext(ctx, "error in converting transaction id", "error", err)
+				invalidParam(w, ctx, "transaction_id", "must be an integer")
+				return
+			}
+			insertAttachment(w, req, ctx, queries, transactionID)
+			return
+		}
+
+		id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			slog.WarnContext(ctx, "error in converting id", "error", err)
+			invalidParam(w, ctx, "id", "must be an integer")
+			return
+		}
+
+		if req.Method == http.MethodGet {
+			getAttachment(w, ctx, queries, id)
+		}
+
+		if req.Method == http.MethodDelete {
+			deleteAttachment(w, ctx, queries, id)
+		}
+	}
+}
+
+func getAttachment(w http.ResponseWriter, ctx context.Context, queries AttachmentQuerier, id int64) {
+	attachment, err := queries.GetAttachmentByID(ctx, id)
+	if err != nil {
+		slog.WarnContext(ctx, "attachment not found", "id", id, "error", err)
+		middleware.Error(w, ctx, http.StatusNotFound, middleware.CodeNotFound, "no attachment found with the given ID")
+		return
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	// The stored type was sniffed on upload, browsers must not guess another
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(attachment.Data)
+}
+
+// insertAttachment stores the file field of a multipart form. Its content type
+// is sniffed from the data, whatever the client says it is.
+func insertAttachment(w http.ResponseWriter, req *http.Request, ctx context.Context, queries AttachmentQuerier, transactionID int64) {
+	// Room is left for the other fields and the boundaries of the form
+	req.Body = http.MaxBytesReader(w, req.Body, maxAttachmentSize+1<<20)
+	reader, err := req.MultipartReader()
+	if err != nil {
+		middleware.Error(w, ctx, http.StatusBadRequest, middleware.CodeBadRequest, "the body must be a multipart form")
+		return
+	}
+
+	var filename string
+	var data []byte
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			attachmentReadError(w, ctx, err)
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		// One byte more than allowed tells a file that is too large
+		data, err = io.ReadAll(io.LimitReader(part, maxAttachmentSize+1))
+		if err != nil {
+			attachmentReadError(w, ctx, err)
+			return
+		}
+		filename = part.FileName()
+		break
+	}
+
+	if data == nil {
+		validationError(w, ctx, []middleware.FieldError{{Field: "file", Message: "is required"}})
+		return
+	}
+	if len(data) > maxAttachmentSize {
+		middleware.Error(w, ctx, http.StatusRequestEntityTooLarge, middleware.CodeBadRequest, attachmentTooLarge)
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if !attachmentTypes[contentType] {
+		slog.WarnContext(ctx, "attachment type not accepted", "content_type", contentType)
+		middleware.Error(w, ctx, http.StatusUnsupportedMediaType, middleware.CodeBadRequest, "the file must be a PDF document or a GIF, JPEG, PNG or WebP image")
+		return
+	}
+
+	if _, err := queries.GetTransactionByID(ctx, transactionID); err != nil {
+		slog.WarnContext(ctx, "transaction not found", "id", transactionID, "error", err)
+		middleware.Error(w, ctx, http.StatusNotFound, middleware.CodeNotFound, "no transaction found with the given ID")
+		return
+	}
+
+	attachment := database.InsertAttachmentParams{
+		TransactionID: transactionID,
+		Filename:      attachmentFilename(filename),
+		ContentType:   contentType,
+		Size:          int64(len(data)),
+		Data:          data,
+		CreatedAt:     time.Now().UTC(),
+	}
+	id, err := queries.InsertAttachment(ctx, attachment)
+	if err != nil {
+		slog.ErrorContext(ctx, "error in inserting attachment into db", "transaction_id", transactionID, "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(AttachmentResource{
+		ID:          id,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		CreatedAt:   attachment.CreatedAt,
+	})
+}
+
+func attachmentReadError(w http.ResponseWriter, ctx context.Context, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		middleware.Error(w, ctx, http.StatusRequestEntityTooLarge, middleware.CodeBadRequest, attachmentTooLarge)
+		return
+	}
+	middleware.Error(w, ctx, http.StatusBadRequest, middleware.CodeBadRequest, "could not read the request body")
+}
+
+// attachmentFilename keeps the name of an uploaded file without its directory
+// and control characters, which have no place in a download header.
+func attachmentFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == ".." {
+		return "attachment"
+	}
+	return name
+}
+
+func deleteAttachment(w http.ResponseWriter, ctx context.Context, queries AttachmentQuerier, id int64) {
+	_, err := queries.GetAttachmentByID(ctx, id)
+	if err != nil {
+		slog.WarnContext(ctx, "no attachment present", "id", id)
+		middleware.Error(w, ctx, http.StatusNotFound, middleware.CodeNotFound, "no attachment found with the given ID")
+		return
+	}
+	err = queries.DeleteAttachment(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not delete attachment", "id", id, "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+}
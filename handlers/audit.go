@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"quattrinitrack/audit"
+	"quattrinitrack/database"
+	middleware "quattrinitrack/middlewares"
+	"strconv"
+)
+
+type AuditQuerier interface {
+	GetAuditEntries(ctx context.Context, arg database.GetAuditEntriesParams) ([]database.GetAuditEntriesRow, error)
+	CountAuditEntries(ctx context.Context) (int64, error)
+	GetEntityAuditEntries(ctx context.Context, arg database.GetEntityAuditEntriesParams) ([]database.GetEntityAuditEntriesRow, error)
+}
+
+// Audit is the activity feed: the changes made to the transactions and
+// categories, most recent first, paged with limit and offset.
+func Audit(queries AuditQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		params := database.GetAuditEntriesParams{PageLimit: defaultPageLimit}
+		if limit := req.URL.Query().Get("limit"); limit != "" {
+			n, err := strconv.ParseInt(limit, 10, 64)
+			if err != nil || n <= 0 || n > maxPageLimit {
+				invalidParam(w, ctx, "limit", fmt.Sprintf("must be between 1 and %d", maxPageLimit))
+				return
+			}
+			params.PageLimit = n
+		}
+		if offset := req.URL.Query().Get("offset"); offset != "" {
+			n, err := strconv.ParseInt(offset, 10, 64)
+			if err != nil || n < 0 {
+				invalidParam(w, ctx, "offset", "must be a non negative integer")
+				return
+			}
+			params.PageOffset = n
+		}
+
+		total, err := queries.CountAuditEntries(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "error counting the audit entries", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+		rows, err := queries.GetAuditEntries(ctx, params)
+		if err != nil {
+			slog.ErrorContext(ctx, "error getting the audit entries", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+		if err := json.NewEncoder(w).Encode(newAuditEntries(rows)); err != nil {
+			slog.ErrorContext(ctx, "error encoding the audit entries", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		}
+	}
+}
+
+// TransactionHistory lists the changes made to a transaction, oldest first.
+// It still answers once the transaction has been deleted or purged.
+func TransactionHistory(queries AuditQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			slog.WarnContext(ctx, "error in converting id", "error", err)
+			invalidParam(w, ctx, "id", "must be an integer")
+			return
+		}
+
+		rows, err := queries.GetEntityAuditEntries(ctx, database.GetEntityAuditEntriesParams{
+			Entity:   audit.EntityTransaction,
+			EntityID: id,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "error getting the transaction history", "id", id, "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+		// Every transaction has at least its insert entry
+		if len(rows) == 0 {
+			middleware.Error(w, ctx, http.StatusNotFound, middleware.CodeNotFound, "no history found for the given transaction ID")
+			return
+		}
+
+		entries := make([]database.GetAuditEntriesRow, 0, len(rows))
+		for _, r := range rows {
+			entries = append(entries, database.GetAuditEntriesRow(r))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(newAuditEntries(entries)); err != nil {
+			slog.ErrorContext(ctx, "error encoding the transaction history", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		}
+	}
+}
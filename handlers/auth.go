@@ -3,10 +3,12 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"quattrinitrack/config"
 	"quattrinitrack/database"
+	"quattrinitrack/metrics"
+	middleware "quattrinitrack/middlewares"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -31,13 +33,26 @@ func Register(queries UserStore) http.HandlerFunc {
 		var reqAuth AuthRequest
 		err := json.NewDecoder(req.Body).Decode(&reqAuth)
 		if err != nil {
-			http.Error(w, "Status Bad Request", http.StatusBadRequest)
+			middleware.Error(w, req.Context(), http.StatusBadRequest, middleware.CodeInvalidJSON, "invalid JSON")
+			return
+		}
+
+		var details []middleware.FieldError
+		if reqAuth.Email == "" {
+			details = append(details, middleware.FieldError{Field: "email", Message: "is required"})
+		}
+		if reqAuth.Password == "" {
+			details = append(details, middleware.FieldError{Field: "password", Message: "is required"})
+		}
+		if len(details) > 0 {
+			validationError(w, req.Context(), details)
 			return
 		}
 
 		hash, err := bcrypt.GenerateFromPassword([]byte(reqAuth.Password), bcrypt.DefaultCost)
 		if err != nil {
-			log.Printf("error in encrypting password %v", err)
+			slog.ErrorContext(req.Context(), "error in encrypting password", "error", err)
+			middleware.Error(w, req.Context(), http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 			return
 		}
 
@@ -46,12 +61,12 @@ func Register(queries UserStore) http.HandlerFunc {
 			PasswordHash: string(hash),
 		})
 		if err != nil {
-			log.Printf("error in creating user %v", err)
-			http.Error(w, "Email already used", http.StatusConflict)
+			slog.WarnContext(req.Context(), "error in creating user", "email", reqAuth.Email, "error", err)
+			middleware.Error(w, req.Context(), http.StatusConflict, middleware.CodeConflict, "email already used")
 			return
 		}
 
-		json.NewEncoder(w).Encode(user)
+		json.NewEncoder(w).Encode(UserResource{ID: user.ID, Email: user.Email})
 	}
 }
 
@@ -61,19 +76,21 @@ func Login(queries UserStore) http.HandlerFunc {
 		var reqAuth AuthRequest
 		err := json.NewDecoder(req.Body).Decode(&reqAuth)
 		if err != nil {
-			http.Error(w, "Bad request", http.StatusBadRequest)
+			middleware.Error(w, req.Context(), http.StatusBadRequest, middleware.CodeInvalidJSON, "invalid JSON")
 			return
 		}
 
 		user, err := queries.GetUserByEmail(req.Context(), reqAuth.Email)
 		if err != nil {
-			http.Error(w, "Invalid email/password", http.StatusUnauthorized)
+			metrics.LoginFailed()
+			middleware.Error(w, req.Context(), http.StatusUnauthorized, middleware.CodeUnauthorized, "invalid email or password")
 			return
 		}
 
 		err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(reqAuth.Password))
 		if err != nil {
-			http.Error(w, "Invalid email/password", http.StatusUnauthorized)
+			metrics.LoginFailed()
+			middleware.Error(w, req.Context(), http.StatusUnauthorized, middleware.CodeUnauthorized, "invalid email or password")
 			return
 		}
 
@@ -83,6 +100,7 @@ func Login(queries UserStore) http.HandlerFunc {
 		})
 
 		tokenString, _ := token.SignedString(config.JWTSecret)
+		metrics.LoginSucceeded()
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{"token": tokenString})
 	}
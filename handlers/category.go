@@ -2,20 +2,63 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"quattrinitrack/audit"
 	"quattrinitrack/database"
+	middleware "quattrinitrack/middlewares"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type CategoryQuerier interface {
 	GetAllCategories(ctx context.Context) ([]database.Category, error)
 	GetCategoryByID(ctx context.Context, id int64) (database.Category, error)
-	DeleteCategory(ctx context.Context, id int64) error
-	InsertCategory(ctx context.Context, name string) error
+	GetCategoryReport(ctx context.Context, arg database.GetCategoryReportParams) ([]database.GetCategoryReportRow, error)
+	GetCategorySamples(ctx context.Context, name string) ([]database.GetCategorySamplesRow, error)
+	InsertAuditEntry(ctx context.Context, arg database.InsertAuditEntryParams) error
+	InCategoryTx(ctx context.Context, fn func(CategoryTxQuerier) error) error
 }
 
+// CategoryTxQuerier is what changing a category along with its audit entry
+// needs, and moving the transactions of a deleted one, run in a single
+// database transaction.
+type CategoryTxQuerier interface {
+	GetAllCategories(ctx context.Context) ([]database.Category, error)
+	GetCategoryByID(ctx context.Context, id int64) (database.Category, error)
+	CountCategoryUses(ctx context.Context, id int64) (database.CountCategoryUsesRow, error)
+	GetCategoryAncestors(ctx context.Context, id int64) ([]int64, error)
+	GetTrashedCategoryByID(ctx context.Context, id int64) (database.Category, error)
+	InsertCategory(ctx context.Context, arg database.InsertCategoryParams) (int64, error)
+	UpdateCategory(ctx context.Context, arg database.UpdateCategoryParams) error
+	RestoreCategory(ctx context.Context, id int64) error
+	MoveCategoryTransactions(ctx context.Context, arg database.MoveCategoryTransactionsParams) ([]int64, error)
+	MoveCategorySplits(ctx context.Context, arg database.MoveCategorySplitsParams) ([]int64, error)
+	MoveSubcategories(ctx context.Context, arg database.MoveSubcategoriesParams) ([]int64, error)
+	MoveCategoryRules(ctx context.Context, arg database.MoveCategoryRulesParams) error
+	TrashCategory(ctx context.Context, arg database.TrashCategoryParams) error
+	InsertAuditEntry(ctx context.Context, arg database.InsertAuditEntryParams) error
+}
+
+// categoryDelete tells where the transactions of a deleted category go. A
+// zero targetID deletes the category only when it is not in use.
+type categoryDelete struct {
+	targetID int64
+	// merge moves the subcategories and rules too, not only the transactions
+	merge bool
+	param string
+}
+
+// Category handles the categories of transactions. A category may be nested
+// under another one, so that "Food" can hold "Groceries" and "Restaurants";
+// names only have to be unique among siblings.
 func Category(queries CategoryQuerier) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
@@ -27,12 +70,22 @@ func Category(queries CategoryQuerier) http.HandlerFunc {
 			case id != "":
 				idNum, err := strconv.ParseInt(id, 10, 64)
 				if err != nil {
-					log.Printf("error in converting id")
+					slog.WarnContext(ctx, "error in converting id", "error", err)
+					invalidParam(w, ctx, "id", "must be an integer")
 					return
 				}
 				getCategoryByID(w, ctx, queries, idNum)
 			default:
-				getAllCategories(w, ctx, queries)
+				tree := false
+				if value := req.URL.Query().Get("tree"); value != "" {
+					var err error
+					tree, err = strconv.ParseBool(value)
+					if err != nil {
+						invalidParam(w, ctx, "tree", "must be true or false")
+						return
+					}
+				}
+				getAllCategories(w, ctx, queries, tree)
 			}
 
 		}
@@ -41,29 +94,75 @@ func Category(queries CategoryQuerier) http.HandlerFunc {
 			insertCategory(w, req, ctx, queries)
 		}
 
+		if req.Method == http.MethodPut {
+			id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
+			if err != nil {
+				slog.WarnContext(ctx, "error in converting id", "error", err)
+				invalidParam(w, ctx, "id", "must be an integer")
+				return
+			}
+			updateCategory(w, req, ctx, queries, id)
+		}
+
 		if req.Method == http.MethodDelete {
 			id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
 			if err != nil {
-				log.Printf("error in converting id")
+				slog.WarnContext(ctx, "error in converting id", "error", err)
+				invalidParam(w, ctx, "id", "must be an integer")
+				return
+			}
+			options, ok := parseCategoryDelete(w, ctx, req.URL.Query())
+			if !ok {
 				return
 			}
-			deleteCategory(w, ctx, queries, id)
+			deleteCategory(w, ctx, queries, id, options)
+		}
+	}
+}
+
+// CategoryReport sums the transactions of every category, optionally between
+// the from and to dates. Parents include the totals of their subcategories.
+func CategoryReport(queries CategoryQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		from, to, ok := parseReportDates(w, req, ctx)
+		if !ok {
+			return
+		}
+
+		rows, err := queries.GetCategoryReport(ctx, database.GetCategoryReportParams{DateFrom: from, DateTo: to})
+		if err != nil {
+			slog.ErrorContext(ctx, "error getting the category report", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(newCategoryReport(rows))
+		if err != nil {
+			slog.ErrorContext(ctx, "error encoding the category report", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 		}
 	}
 }
 
-func getAllCategories(w http.ResponseWriter, ctx context.Context, queries CategoryQuerier) {
+func getAllCategories(w http.ResponseWriter, ctx context.Context, queries CategoryQuerier, tree bool) {
 	categories, err := queries.GetAllCategories(ctx)
 	if err != nil {
-		log.Printf("error getting categories %v", err)
-		http.Error(w, "Internal Sever Error", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "error getting categories", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 		return
 	}
+	var response any = newCategories(categories)
+	if tree {
+		response = newCategoryTree(categories)
+	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(categories)
+	err = json.NewEncoder(w).Encode(response)
 	if err != nil {
-		log.Printf("error encoding categories %v", err)
-		http.Error(w, "Internal Sever Error", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "error encoding categories", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 		return
 	}
 }
@@ -71,35 +170,73 @@ func getAllCategories(w http.ResponseWriter, ctx context.Context, queries Catego
 func getCategoryByID(w http.ResponseWriter, ctx context.Context, queries CategoryQuerier, id int64) {
 	category, err := queries.GetCategoryByID(ctx, id)
 	if err != nil {
-		log.Printf("category not found with id: %d, error: %v", id, err)
-		http.Error(w, "no category found with the given ID", http.StatusNotFound)
+		slog.WarnContext(ctx, "category not found", "id", id, "error", err)
+		middleware.Error(w, ctx, http.StatusNotFound, middleware.CodeNotFound, "no category found with the given ID")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(category)
+	err = json.NewEncoder(w).Encode(newCategory(category))
 	if err != nil {
-		log.Printf("error encoding category %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "error encoding category", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 	}
 }
 
-func insertCategory(w http.ResponseWriter, req *http.Request, ctx context.Context, queries CategoryQuerier) {
-	var category database.Category
-	err := json.NewDecoder(req.Body).Decode(&category)
-	if err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
-		return
+// decodeCategory reads the body of a request creating or updating a
+// category, and responds with an error when it is not valid.
+func decodeCategory(w http.ResponseWriter, req *http.Request, ctx context.Context, queries CategoryQuerier) (NewCategory, bool) {
+	var category NewCategory
+	if err := json.NewDecoder(req.Body).Decode(&category); err != nil {
+		middleware.Error(w, ctx, http.StatusBadRequest, middleware.CodeInvalidJSON, "invalid JSON")
+		return category, false
 	}
 
+	category.Name = strings.TrimSpace(category.Name)
 	if category.Name == "" {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		validationError(w, ctx, []middleware.FieldError{{Field: "name", Message: "is required"}})
+		return category, false
+	}
+
+	if category.ParentID != 0 {
+		_, err := queries.GetCategoryByID(ctx, category.ParentID)
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.WarnContext(ctx, "parent category not found", "parent_id", category.ParentID)
+			validationError(w, ctx, []middleware.FieldError{{Field: "parent_id", Message: "must be an existing category"}})
+			return category, false
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "error getting the parent category", "parent_id", category.ParentID, "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return category, false
+		}
+	}
+	return category, true
+}
+
+func insertCategory(w http.ResponseWriter, req *http.Request, ctx context.Context, queries CategoryQuerier) {
+	category, ok := decodeCategory(w, req, ctx, queries)
+	if !ok {
 		return
 	}
 
-	err = queries.InsertCategory(ctx, category.Name)
+	err := queries.InCategoryTx(ctx, func(tx CategoryTxQuerier) error {
+		inserted := database.Category{
+			Name:     category.Name,
+			ParentID: sql.NullInt64{Int64: category.ParentID, Valid: category.ParentID != 0},
+		}
+		id, err := tx.InsertCategory(ctx, database.InsertCategoryParams{Name: inserted.Name, ParentID: inserted.ParentID})
+		if isUniqueViolation(err) {
+			slog.WarnContext(ctx, "category already exists", "name", category.Name, "error", err)
+			return &httpError{http.StatusConflict, middleware.CodeConflict, "category already exists"}
+		}
+		if err != nil {
+			return fmt.Errorf("inserting category: %w", err)
+		}
+		inserted.ID = id
+		return audit.Record(ctx, tx, audit.EntityCategory, id, audit.Insert, nil, audit.NewCategory(inserted))
+	})
 	if err != nil {
-		log.Printf("error with inserting category in db %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		txError(w, ctx, err, "could not insert the category", "name", category.Name)
 		return
 	}
 
@@ -109,17 +246,269 @@ func insertCategory(w http.ResponseWriter, req *http.Request, ctx context.Contex
 	json.NewEncoder(w).Encode(response)
 }
 
-func deleteCategory(w http.ResponseWriter, ctx context.Context, queries CategoryQuerier, id int64) {
-	_, err := queries.GetCategoryByID(ctx, id)
+// updateCategory renames a category and moves it under another parent. It
+// cannot be moved under itself or one of its subcategories.
+func updateCategory(w http.ResponseWriter, req *http.Request, ctx context.Context, queries CategoryQuerier, id int64) {
+	category, ok := decodeCategory(w, req, ctx, queries)
+	if !ok {
+		return
+	}
+
+	err := queries.InCategoryTx(ctx, func(tx CategoryTxQuerier) error {
+		current, err := tx.GetCategoryByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.WarnContext(ctx, "category not found", "id", id)
+			return &httpError{http.StatusNotFound, middleware.CodeNotFound, "no category found with the given ID"}
+		}
+		if err != nil {
+			return fmt.Errorf("getting category: %w", err)
+		}
+
+		if category.ParentID != 0 {
+			ancestors, err := tx.GetCategoryAncestors(ctx, category.ParentID)
+			if err != nil {
+				return fmt.Errorf("getting the ancestors of category %d: %w", category.ParentID, err)
+			}
+			if slices.Contains(ancestors, id) {
+				slog.WarnContext(ctx, "category cycle refused", "id", id, "parent_id", category.ParentID)
+				return &httpError{http.StatusConflict, middleware.CodeConflict,
+					"a category cannot be moved under itself or one of its subcategories"}
+			}
+		}
+
+		updated := current
+		updated.Name = category.Name
+		updated.ParentID = sql.NullInt64{Int64: category.ParentID, Valid: category.ParentID != 0}
+		err = tx.UpdateCategory(ctx, database.UpdateCategoryParams{
+			Name:     updated.Name,
+			ParentID: updated.ParentID,
+			ID:       id,
+		})
+		if isUniqueViolation(err) {
+			slog.WarnContext(ctx, "category already exists", "id", id, "name", category.Name, "error", err)
+			return &httpError{http.StatusConflict, middleware.CodeConflict, "category already exists"}
+		}
+		if err != nil {
+			return fmt.Errorf("updating category: %w", err)
+		}
+		return audit.RecordUpdate(ctx, tx, audit.EntityCategory, id, audit.NewCategory(current), audit.NewCategory(updated))
+	})
+	if err != nil {
+		txError(w, ctx, err, "could not update the category", "id", id)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]string{"message": "Category updated successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// parseCategoryDelete reads the move_to and merge_into query parameters,
+// only one of which may be given.
+func parseCategoryDelete(w http.ResponseWriter, ctx context.Context, query url.Values) (categoryDelete, bool) {
+	moveTo, mergeInto := query.Get("move_to"), query.Get("merge_into")
+	if moveTo != "" && mergeInto != "" {
+		invalidParam(w, ctx, "merge_into", "cannot be used with move_to")
+		return categoryDelete{}, false
+	}
+
+	options := categoryDelete{param: "move_to"}
+	value := moveTo
+	if mergeInto != "" {
+		options = categoryDelete{param: "merge_into", merge: true}
+		value = mergeInto
+	}
+	if value == "" {
+		return options, true
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
 	if err != nil {
-		log.Printf("no category with id %d present", id)
-		http.Error(w, "Status Bad Request", http.StatusBadRequest)
+		invalidParam(w, ctx, options.param, "must be an integer")
+		return options, false
+	}
+	options.targetID = id
+	return options, true
+}
+
+// deleteCategory moves a category to the trash. A category in use is only
+// deleted when its transactions are moved to another one, or when it is
+// merged into another one, which also takes its subcategories and rules.
+// The checks, the moves and the delete happen in one database transaction.
+func deleteCategory(w http.ResponseWriter, ctx context.Context, queries CategoryQuerier, id int64, options categoryDelete) {
+	if options.targetID == id {
+		invalidParam(w, ctx, options.param, "must be another category")
 		return
 	}
-	err = queries.DeleteCategory(ctx, id)
+
+	moved := make(map[int64]bool)
+	err := queries.InCategoryTx(ctx, func(tx CategoryTxQuerier) error {
+		category, err := tx.GetCategoryByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.WarnContext(ctx, "no category present", "id", id)
+			return &httpError{http.StatusNotFound, middleware.CodeNotFound, "no category found with the given ID"}
+		}
+		if err != nil {
+			return fmt.Errorf("getting the category: %w", err)
+		}
+		uses, err := tx.CountCategoryUses(ctx, id)
+		if err != nil {
+			return fmt.Errorf("counting the category uses: %w", err)
+		}
+
+		if options.targetID != 0 {
+			return moveCategory(ctx, tx, category, uses, options, moved)
+		}
+		return trashCategory(ctx, tx, id, category, uses)
+	})
 	if err != nil {
-		log.Printf("could not delete category with id %d", id)
-		http.Error(w, "Status Bad Request", http.StatusBadRequest)
+		txError(w, ctx, err, "could not delete the category", "id", id, "target", options.targetID, "merge", options.merge)
+		return
+	}
+	if options.targetID == 0 {
 		return
 	}
+
+	slog.InfoContext(ctx, "category deleted", "id", id, "target", options.targetID, "merge", options.merge, "transactions", len(moved))
+	message := fmt.Sprintf("Category deleted successfully, %d transactions moved", len(moved))
+	if options.merge {
+		message = fmt.Sprintf("Category merged successfully, %d transactions moved", len(moved))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// trashCategory trashes a category that is not in use.
+func trashCategory(ctx context.Context, tx CategoryTxQuerier, id int64, category database.Category, uses database.CountCategoryUsesRow) error {
+	// A category in the trash must not hold live transactions or subcategories
+	if uses.Transactions > 0 {
+		slog.WarnContext(ctx, "category in use", "id", id, "transactions", uses.Transactions)
+		return &detailedError{httpError{http.StatusConflict, middleware.CodeConflict, fmt.Sprintf(
+			"the category is used by %d transactions, move them with move_to or merge the category with merge_into", uses.Transactions)},
+			[]middleware.FieldError{{Field: "transactions", Message: strconv.FormatInt(uses.Transactions, 10)}}}
+	}
+	if uses.Subcategories > 0 {
+		slog.WarnContext(ctx, "category has subcategories", "id", id, "subcategories", uses.Subcategories)
+		return subcategoriesError(uses.Subcategories)
+	}
+	trash := database.TrashCategoryParams{
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:        id,
+	}
+	if err := tx.TrashCategory(ctx, trash); err != nil {
+		slog.ErrorContext(ctx, "could not delete category", "id", id, "error", err)
+		return &httpError{http.StatusBadRequest, middleware.CodeBadRequest, "could not delete the category"}
+	}
+	return audit.Record(ctx, tx, audit.EntityCategory, id, audit.Delete, audit.NewCategory(category), nil)
+}
+
+// subcategoriesError refuses to delete a category with subcategories without
+// merging it, with their number in the details.
+func subcategoriesError(subcategories int64) error {
+	return &detailedError{httpError{http.StatusConflict, middleware.CodeConflict, fmt.Sprintf(
+		"the category has %d subcategories, merge the category with merge_into or delete them first", subcategories)},
+		[]middleware.FieldError{{Field: "subcategories", Message: strconv.FormatInt(subcategories, 10)}}}
+}
+
+// moveCategory moves the transactions of a category to the target one, and
+// with a merge its subcategories and rules too, then trashes the category.
+// The transactions moved are added to moved.
+func moveCategory(ctx context.Context, tx CategoryTxQuerier, category database.Category, uses database.CountCategoryUsesRow, options categoryDelete, moved map[int64]bool) error {
+	id, targetID := category.ID, options.targetID
+	if _, err := tx.GetCategoryByID(ctx, targetID); err != nil {
+		slog.WarnContext(ctx, "target category not found", "id", targetID, "error", err)
+		return &paramError{options.param, "must be an existing category"}
+	}
+
+	if !options.merge && uses.Subcategories > 0 {
+		slog.WarnContext(ctx, "category has subcategories", "id", id, "subcategories", uses.Subcategories)
+		return subcategoriesError(uses.Subcategories)
+	}
+	if options.merge {
+		if err := checkCategoryMerge(ctx, tx, id, targetID); err != nil {
+			return err
+		}
+	}
+
+	move := database.MoveCategoryTransactionsParams{ToID: targetID, FromID: id}
+	transactions, err := tx.MoveCategoryTransactions(ctx, move)
+	if err != nil {
+		return err
+	}
+	for _, t := range transactions {
+		moved[t] = true
+		err := audit.RecordUpdate(ctx, tx, audit.EntityTransaction, t,
+			map[string]any{"categories_id": id}, map[string]any{"categories_id": targetID})
+		if err != nil {
+			return err
+		}
+	}
+	// Split lines are not part of the audited transaction
+	splits, err := tx.MoveCategorySplits(ctx, database.MoveCategorySplitsParams(move))
+	if err != nil {
+		return err
+	}
+	for _, t := range splits {
+		moved[t] = true
+	}
+
+	if options.merge {
+		subcategories, err := tx.MoveSubcategories(ctx, database.MoveSubcategoriesParams(move))
+		if err != nil {
+			return err
+		}
+		for _, c := range subcategories {
+			err := audit.RecordUpdate(ctx, tx, audit.EntityCategory, c,
+				map[string]any{"parent_id": id}, map[string]any{"parent_id": targetID})
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.MoveCategoryRules(ctx, database.MoveCategoryRulesParams(move)); err != nil {
+			return err
+		}
+	}
+
+	err = tx.TrashCategory(ctx, database.TrashCategoryParams{
+		DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:        id,
+	})
+	if err != nil {
+		return err
+	}
+	return audit.Record(ctx, tx, audit.EntityCategory, id, audit.Delete, audit.NewCategory(category), nil)
+}
+
+// checkCategoryMerge returns a conflict when the category cannot be merged
+// into the target: the target is one of its subcategories, or already has a
+// subcategory named like one of those it would take.
+func checkCategoryMerge(ctx context.Context, tx CategoryTxQuerier, id, targetID int64) error {
+	ancestors, err := tx.GetCategoryAncestors(ctx, targetID)
+	if err != nil {
+		return fmt.Errorf("getting the ancestors of category %d: %w", targetID, err)
+	}
+	if slices.Contains(ancestors, id) {
+		slog.WarnContext(ctx, "category merge into a subcategory refused", "id", id, "target", targetID)
+		return &httpError{http.StatusConflict, middleware.CodeConflict,
+			"a category cannot be merged into one of its subcategories"}
+	}
+
+	categories, err := tx.GetAllCategories(ctx)
+	if err != nil {
+		return fmt.Errorf("getting the categories: %w", err)
+	}
+	targetNames := make(map[string]bool)
+	for _, c := range categories {
+		if c.ParentID.Valid && c.ParentID.Int64 == targetID {
+			targetNames[c.Name] = true
+		}
+	}
+	for _, c := range categories {
+		if c.ParentID.Valid && c.ParentID.Int64 == id && targetNames[c.Name] {
+			slog.WarnContext(ctx, "subcategory name taken in the merge target", "id", id, "target", targetID, "name", c.Name)
+			return &httpError{http.StatusConflict, middleware.CodeConflict,
+				fmt.Sprintf("the target category already has a subcategory named %q", c.Name)}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	middleware "quattrinitrack/middlewares"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// validationError rejects a request body whose fields failed validation,
+// listing every problem at once.
+func validationError(w http.ResponseWriter, ctx context.Context, details []middleware.FieldError) {
+	problems := make([]string, 0, len(details))
+	for _, d := range details {
+		problems = append(problems, d.Field+" "+d.Message)
+	}
+	message := "invalid JSON: " + strings.Join(problems, "; ")
+	middleware.Error(w, ctx, http.StatusBadRequest, middleware.CodeValidation, message, details...)
+}
+
+// invalidParam rejects a query parameter that could not be parsed.
+func invalidParam(w http.ResponseWriter, ctx context.Context, name, message string) {
+	middleware.Error(w, ctx, http.StatusBadRequest, middleware.CodeBadRequest, "invalid "+name,
+		middleware.FieldError{Field: name, Message: message})
+}
+
+// isUniqueViolation reports whether err is a unique constraint failing, such
+// as a name already taken, rather than the database failing.
+func isUniqueViolation(err error) bool {
+	var se *sqlite.Error
+	return errors.As(err, &se) && se.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// httpError is an error answered with its own status and code. Returned from
+// a database transaction, it rolls the transaction back.
+type httpError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *httpError) Error() string {
+	return e.message
+}
+
+// detailedError is an httpError answered with details too, for clients to
+// read without parsing the message.
+type detailedError struct {
+	httpError
+	details []middleware.FieldError
+}
+
+// fieldErrors are the fields of a request body found invalid inside a
+// database transaction, answered like validationError.
+type fieldErrors []middleware.FieldError
+
+func (e fieldErrors) Error() string {
+	return "invalid fields"
+}
+
+// txError responds to the error a database transaction failed with: an
+// httpError or a detailedError with its status, a paramError as an invalid parameter, fieldErrors
+// as a validation error, anything else with an internal error, logged with msg
+// and args.
+func txError(w http.ResponseWriter, ctx context.Context, err error, msg string, args ...any) {
+	var de *detailedError
+	if errors.As(err, &de) {
+		middleware.Error(w, ctx, de.status, de.code, de.message, de.details...)
+		return
+	}
+	var he *httpError
+	if errors.As(err, &he) {
+		middleware.Error(w, ctx, he.status, he.code, he.message)
+		return
+	}
+	var fe fieldErrors
+	if errors.As(err, &fe) {
+		validationError(w, ctx, fe)
+		return
+	}
+	var pe *paramError
+	if errors.As(err, &pe) {
+		invalidParam(w, ctx, pe.param, pe.message)
+		return
+	}
+	slog.ErrorContext(ctx, msg, append(args, "error", err)...)
+	middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+}
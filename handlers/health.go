@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"quattrinitrack/database"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Version is the release of the build, set with
+// -ldflags "-X quattrinitrack/handlers.Version=v1.2.0".
+var Version = "dev"
+
+const readinessTimeout = 2 * time.Second
+
+// ReadinessDB is the part of *sql.DB the readiness check needs.
+type ReadinessDB interface {
+	database.DBTX
+	PingContext(ctx context.Context) error
+}
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+type HealthResponse struct {
+	Status string    `json:"status"`
+	Build  BuildInfo `json:"build"`
+}
+
+type ReadinessResponse struct {
+	Status              string    `json:"status"`
+	Database            string    `json:"database"`
+	SchemaVersion       int       `json:"schema_version"`
+	LatestSchemaVersion int       `json:"latest_schema_version"`
+	ForeignKeys         bool      `json:"foreign_keys"`
+	Build               BuildInfo `json:"build"`
+}
+
+func buildInfo() BuildInfo {
+	build := BuildInfo{Version: Version, GoVersion: runtime.Version()}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Commit = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+	return build
+}
+
+// Health reports that the process is up, without touching the database.
+func Health() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(HealthResponse{Status: "ok", Build: buildInfo()})
+	}
+}
+
+// Ready reports whether the server can serve requests: the database answers,
+// its schema is up to date and foreign keys are enforced.
+func Ready(db ReadinessDB) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), readinessTimeout)
+		defer cancel()
+
+		response := ReadinessResponse{Status: "ready", Database: "ok", Build: buildInfo()}
+		ready := true
+
+		latest, err := database.LatestVersion()
+		if err != nil {
+			slog.ErrorContext(ctx, "error loading migrations", "error", err)
+			ready = false
+		}
+		response.LatestSchemaVersion = latest
+
+		if err := db.PingContext(ctx); err != nil {
+			slog.ErrorContext(ctx, "database ping failed", "error", err)
+			response.Database = err.Error()
+			ready = false
+		} else {
+			response.SchemaVersion, err = database.SchemaVersion(ctx, db)
+			if err != nil {
+				slog.ErrorContext(ctx, "error reading schema version", "error", err)
+				ready = false
+			}
+
+			var foreignKeys int
+			if err := db.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+				slog.ErrorContext(ctx, "error reading foreign keys pragma", "error", err)
+				ready = false
+			}
+			response.ForeignKeys = foreignKeys == 1
+		}
+
+		if response.SchemaVersion != latest || !response.ForeignKeys {
+			ready = false
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if !ready {
+			response.Status = "not ready"
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(response)
+	}
+}
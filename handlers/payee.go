@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"quattrinitrack/audit"
+	"quattrinitrack/database"
+	middleware "quattrinitrack/middlewares"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type PayeeQuerier interface {
+	GetAllPayees(ctx context.Context) ([]database.Payee, error)
+	GetPayeeByID(ctx context.Context, id int64) (database.Payee, error)
+	GetPayeeAliases(ctx context.Context) ([]database.PayeeAlias, error)
+	GetPayeeIDByAlias(ctx context.Context, normalized string) (int64, error)
+	DeletePayeeAlias(ctx context.Context, id int64) error
+	GetPayeeReport(ctx context.Context, arg database.GetPayeeReportParams) ([]database.GetPayeeReportRow, error)
+	InPayeeTx(ctx context.Context, fn func(PayeeTxQuerier) error) error
+}
+
+// PayeeTxQuerier is what adding a payee or an alias needs, with the linking
+// of the transactions it matches and their audit entries, and what deleting a
+// payee needs, run in a single database transaction.
+type PayeeTxQuerier interface {
+	GetPayeeByID(ctx context.Context, id int64) (database.Payee, error)
+	GetPayeeTransactionIDs(ctx context.Context, payeeID sql.NullInt64) ([]int64, error)
+	InsertPayee(ctx context.Context, name string) (int64, error)
+	DeletePayee(ctx context.Context, id int64) error
+	InsertPayeeAlias(ctx context.Context, arg database.InsertPayeeAliasParams) error
+	GetPayeeAliases(ctx context.Context) ([]database.PayeeAlias, error)
+	GetTransactionsWithoutPayee(ctx context.Context) ([]database.GetTransactionsWithoutPayeeRow, error)
+	SetTransactionPayee(ctx context.Context, arg database.SetTransactionPayeeParams) error
+	InsertAuditEntry(ctx context.Context, arg database.InsertAuditEntryParams) error
+}
+
+// payeeSuffixes are dropped from the end of merchant names when normalizing
+// them: legal forms, regions and the like, so that "AMAZON EU SARL" reads as
+// "amazon".
+var payeeSuffixes = map[string]bool{
+	"ag": true, "bv": true, "co": true, "com": true, "eu": true, "gmbh": true, "inc": true,
+	"llc": true, "ltd": true, "plc": true, "sa": true, "sarl": true, "spa": true, "srl": true,
+}
+
+// normalizePayee reduces a merchant name to the form aliases are matched on:
+// lower case words without punctuation, trailing store numbers and payeeSuffixes.
+func normalizePayee(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for len(words) > 1 {
+		last := words[len(words)-1]
+		if !payeeSuffixes[last] && strings.TrimFunc(last, unicode.IsDigit) != "" {
+			break
+		}
+		words = words[:len(words)-1]
+	}
+	return strings.Join(words, " ")
+}
+
+// Payee handles the payees transactions are linked to. A payee gathers the
+// names a merchant appears under, so that its purchases group together.
+func Payee(queries PayeeQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		if req.Method == http.MethodGet {
+			id := req.URL.Query().Get("id")
+
+			switch {
+			case id != "":
+				idNum, err := strconv.ParseInt(id, 10, 64)
+				if err != nil {
+					slog.WarnContext(ctx, "error in converting id", "error", err)
+					invalidParam(w, ctx, "id", "must be an integer")
+					return
+				}
+				getPayeeByID(w, ctx, queries, idNum)
+			default:
+				getAllPayees(w, ctx, queries)
+			}
+		}
+
+		if req.Method == http.MethodPost {
+			insertPayee(w, req, ctx, queries)
+		}
+
+		if req.Method == http.MethodDelete {
+			id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
+			if err != nil {
+				slog.WarnContext(ctx, "error in converting id", "error", err)
+				invalidParam(w, ctx, "id", "must be an integer")
+				return
+			}
+			deletePayee(w, ctx, queries, id)
+		}
+	}
+}
+
+// PayeeAlias adds and removes the names a payee appears under.
+func PayeeAlias(queries PayeeQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		if req.Method == http.MethodPost {
+			insertPayeeAlias(w, req, ctx, queries)
+		}
+
+		if req.Method == http.MethodDelete {
+			id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
+			if err != nil {
+				slog.WarnContext(ctx, "error in converting id", "error", err)
+				invalidParam(w, ctx, "id", "must be an integer")
+				return
+			}
+			err = queries.DeletePayeeAlias(ctx, id)
+			if err != nil {
+				slog.ErrorContext(ctx, "could not delete payee alias", "id", id, "error", err)
+				middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+				return
+			}
+		}
+	}
+}
+
+// PayeeReport sums the transactions of every payee, optionally between the
+// from and to dates.
+func PayeeReport(queries PayeeQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		from, to, ok := parseReportDates(w, req, ctx)
+		if !ok {
+			return
+		}
+
+		report, err := queries.GetPayeeReport(ctx, database.GetPayeeReportParams{DateFrom: from, DateTo: to})
+		if err != nil {
+			slog.ErrorContext(ctx, "error getting the payee report", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(newPayeeReport(report))
+		if err != nil {
+			slog.ErrorContext(ctx, "error encoding the payee report", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		}
+	}
+}
+
+func getAllPayees(w http.ResponseWriter, ctx context.Context, queries PayeeQuerier) {
+	payees, err := queries.GetAllPayees(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting payees", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+	aliases, err := queries.GetPayeeAliases(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting payee aliases", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newPayees(payees, aliases))
+	if err != nil {
+		slog.ErrorContext(ctx, "error encoding payees", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+	}
+}
+
+func getPayeeByID(w http.ResponseWriter, ctx context.Context, queries PayeeQuerier, id int64) {
+	payee, err := queries.GetPayeeByID(ctx, id)
+	if err != nil {
+		slog.WarnContext(ctx, "payee not found", "id", id, "error", err)
+		middleware.Error(w, ctx, http.StatusNotFound, middleware.CodeNotFound, "no payee found with the given ID")
+		return
+	}
+	aliases, err := queries.GetPayeeAliases(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting payee aliases", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newPayees([]database.Payee{payee}, aliases)[0])
+	if err != nil {
+		slog.ErrorContext(ctx, "error encoding payee", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+	}
+}
+
+// aliasTaken reports whether a name already stands for a payee.
+func aliasTaken(ctx context.Context, queries PayeeQuerier, alias string) (bool, error) {
+	_, err := queries.GetPayeeIDByAlias(ctx, normalizePayee(alias))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func insertPayee(w http.ResponseWriter, req *http.Request, ctx context.Context, queries PayeeQuerier) {
+	var payee NewPayee
+	if err := json.NewDecoder(req.Body).Decode(&payee); err != nil {
+		middleware.Error(w, ctx, http.StatusBadRequest, middleware.CodeInvalidJSON, "invalid JSON")
+		return
+	}
+
+	payee.Name = strings.TrimSpace(payee.Name)
+	if normalizePayee(payee.Name) == "" {
+		validationError(w, ctx, []middleware.FieldError{{Field: "name", Message: "is required"}})
+		return
+	}
+
+	// The name is an alias too
+	var aliases []string
+	seen := make(map[string]bool)
+	for _, alias := range append([]string{payee.Name}, payee.Aliases...) {
+		alias = strings.TrimSpace(alias)
+		if normalizePayee(alias) == "" || seen[normalizePayee(alias)] {
+			continue
+		}
+		seen[normalizePayee(alias)] = true
+
+		taken, err := aliasTaken(ctx, queries, alias)
+		if err != nil {
+			slog.ErrorContext(ctx, "error looking up payee alias", "alias", alias, "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+		if taken {
+			slog.WarnContext(ctx, "payee alias already used", "alias", alias)
+			middleware.Error(w, ctx, http.StatusConflict, middleware.CodeConflict, "alias already used by a payee: "+alias)
+			return
+		}
+		aliases = append(aliases, alias)
+	}
+
+	err := queries.InPayeeTx(ctx, func(tx PayeeTxQuerier) error {
+		id, err := tx.InsertPayee(ctx, payee.Name)
+		if err != nil {
+			slog.WarnContext(ctx, "error with inserting payee in db", "name", payee.Name, "error", err)
+			return &httpError{http.StatusConflict, middleware.CodeConflict, "payee already exists"}
+		}
+		for _, alias := range aliases {
+			err := tx.InsertPayeeAlias(ctx, database.InsertPayeeAliasParams{PayeeID: id, Alias: alias, Normalized: normalizePayee(alias)})
+			if err != nil {
+				return fmt.Errorf("inserting payee alias %q: %w", alias, err)
+			}
+		}
+		if err := linkPayees(ctx, tx); err != nil {
+			return fmt.Errorf("linking transactions to payees: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		txError(w, ctx, err, "could not insert the payee", "name", payee.Name)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]string{"message": "Payee created successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func insertPayeeAlias(w http.ResponseWriter, req *http.Request, ctx context.Context, queries PayeeQuerier) {
+	var alias NewPayeeAlias
+	if err := json.NewDecoder(req.Body).Decode(&alias); err != nil {
+		middleware.Error(w, ctx, http.StatusBadRequest, middleware.CodeInvalidJSON, "invalid JSON")
+		return
+	}
+
+	alias.Alias = strings.TrimSpace(alias.Alias)
+	if normalizePayee(alias.Alias) == "" {
+		validationError(w, ctx, []middleware.FieldError{{Field: "alias", Message: "is required"}})
+		return
+	}
+	if _, err := queries.GetPayeeByID(ctx, alias.PayeeID); err != nil {
+		slog.WarnContext(ctx, "payee not found", "id", alias.PayeeID, "error", err)
+		validationError(w, ctx, []middleware.FieldError{{Field: "payee_id", Message: "must be an existing payee"}})
+		return
+	}
+
+	err := queries.InPayeeTx(ctx, func(tx PayeeTxQuerier) error {
+		err := tx.InsertPayeeAlias(ctx, database.InsertPayeeAliasParams{
+			PayeeID:    alias.PayeeID,
+			Alias:      alias.Alias,
+			Normalized: normalizePayee(alias.Alias),
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "error with inserting payee alias in db", "alias", alias.Alias, "error", err)
+			return &httpError{http.StatusConflict, middleware.CodeConflict, "alias already used by a payee: " + alias.Alias}
+		}
+		if err := linkPayees(ctx, tx); err != nil {
+			return fmt.Errorf("linking transactions to payees: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		txError(w, ctx, err, "could not add the payee alias", "alias", alias.Alias)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]string{"message": "Alias added successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+// linkPayees links the transactions without a payee whose name matches an
+// alias, so that earlier purchases join the payee they belong to.
+func linkPayees(ctx context.Context, queries PayeeTxQuerier) error {
+	aliases, err := queries.GetPayeeAliases(ctx)
+	if err != nil {
+		return err
+	}
+	payees := make(map[string]int64, len(aliases))
+	for _, a := range aliases {
+		payees[a.Normalized] = a.PayeeID
+	}
+
+	transactions, err := queries.GetTransactionsWithoutPayee(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range transactions {
+		id, ok := payees[normalizePayee(t.Name)]
+		if !ok {
+			continue
+		}
+		err := queries.SetTransactionPayee(ctx, database.SetTransactionPayeeParams{
+			PayeeID: sql.NullInt64{Int64: id, Valid: true},
+			ID:      t.ID,
+		})
+		if err != nil {
+			return err
+		}
+		err = audit.RecordUpdate(ctx, queries, audit.EntityTransaction, t.ID,
+			map[string]any{"payee_id": nil}, map[string]any{"payee_id": id})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deletePayee(w http.ResponseWriter, ctx context.Context, queries PayeeQuerier, id int64) {
+	err := queries.InPayeeTx(ctx, func(tx PayeeTxQuerier) error {
+		_, err := tx.GetPayeeByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.WarnContext(ctx, "no payee present", "id", id)
+			return &httpError{http.StatusNotFound, middleware.CodeNotFound, "no payee found with the given ID"}
+		}
+		if err != nil {
+			return fmt.Errorf("getting payee: %w", err)
+		}
+
+		// Its transactions are kept, without a payee, cleared here rather
+		// than by the foreign key so that the audit log records it
+		ids, err := tx.GetPayeeTransactionIDs(ctx, sql.NullInt64{Int64: id, Valid: true})
+		if err != nil {
+			return fmt.Errorf("getting the transactions of the payee: %w", err)
+		}
+		for _, transactionID := range ids {
+			err := tx.SetTransactionPayee(ctx, database.SetTransactionPayeeParams{ID: transactionID})
+			if err != nil {
+				return fmt.Errorf("clearing the payee of transaction %d: %w", transactionID, err)
+			}
+			err = audit.RecordUpdate(ctx, tx, audit.EntityTransaction, transactionID,
+				map[string]any{"payee_id": id}, map[string]any{"payee_id": nil})
+			if err != nil {
+				return fmt.Errorf("recording the payee of transaction %d: %w", transactionID, err)
+			}
+		}
+		if err := tx.DeletePayee(ctx, id); err != nil {
+			return fmt.Errorf("deleting payee: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		txError(w, ctx, err, "could not delete the payee", "id", id)
+	}
+}
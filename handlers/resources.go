@@ -0,0 +1,688 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+	"quattrinitrack/config"
+	"quattrinitrack/database"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Resources are the JSON shapes of the API. Handlers never encode the sqlc
+// models directly, so that schema changes do not leak into responses.
+
+// TransactionResource is a transaction as returned by the API.
+type TransactionResource struct {
+	ID           int64                `json:"id"`
+	Name         string               `json:"name"`
+	Cost         float64              `json:"cost"`
+	Date         time.Time            `json:"date"`
+	CategoriesID int64                `json:"categories_id"`
+	CategoryName string               `json:"category_name"`
+	Tags         []string             `json:"tags"`
+	Splits       []SplitResource      `json:"splits"`
+	PayeeID      *int64               `json:"payee_id"`
+	PayeeName    string               `json:"payee_name"`
+	Notes        string               `json:"notes"`
+	Attachments  []AttachmentResource `json:"attachments"`
+}
+
+// AttachmentResource describes a file kept with a transaction. The file
+// itself is downloaded from its own endpoint.
+type AttachmentResource struct {
+	ID          int64     `json:"id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SplitResource is the part of a split transaction going to one category.
+type SplitResource struct {
+	CategoriesID int64   `json:"categories_id"`
+	CategoryName string  `json:"category_name"`
+	Amount       float64 `json:"amount"`
+}
+
+// CategoryResource is a category as returned by the API. ParentID is null
+// for a top level category.
+type CategoryResource struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	ParentID *int64 `json:"parent_id"`
+}
+
+// CategoryNode is a category with its subcategories.
+type CategoryNode struct {
+	CategoryResource
+	Children []CategoryNode `json:"children"`
+}
+
+// CategoryReportResource sums the transactions of a category and of its
+// subcategories. The own totals leave the subcategories out.
+type CategoryReportResource struct {
+	CategoryResource
+	Transactions    int64                    `json:"transactions"`
+	Total           float64                  `json:"total"`
+	OwnTransactions int64                    `json:"own_transactions"`
+	OwnTotal        float64                  `json:"own_total"`
+	Children        []CategoryReportResource `json:"children"`
+}
+
+// CategorySuggestionResource is a category suggested for a transaction, with
+// the probability, between 0 and 1, that it is the right one.
+type CategorySuggestionResource struct {
+	CategoriesID int64   `json:"categories_id"`
+	CategoryName string  `json:"category_name"`
+	Confidence   float64 `json:"confidence"`
+}
+
+// TrashResource lists the transactions and categories in the trash.
+type TrashResource struct {
+	Transactions []TrashedTransactionResource `json:"transactions"`
+	Categories   []TrashedCategoryResource    `json:"categories"`
+}
+
+// TrashedTransactionResource is a transaction in the trash. PurgeAt is null
+// when the trash is kept until restored.
+type TrashedTransactionResource struct {
+	TransactionResource
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at"`
+}
+
+// TrashedCategoryResource is a category in the trash. PurgeAt is null when
+// the trash is kept until restored.
+type TrashedCategoryResource struct {
+	CategoryResource
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at"`
+}
+
+// AuditEntryResource is an entry of the audit log. Before and After hold the
+// state of the entity, or only its changed fields for an update, and are null
+// when there is none. UserID is null for the changes made by the server.
+type AuditEntryResource struct {
+	ID        int64           `json:"id"`
+	UserID    *int64          `json:"user_id"`
+	UserEmail string          `json:"user_email"`
+	Entity    string          `json:"entity"`
+	EntityID  int64           `json:"entity_id"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// UserResource is the account returned on registration.
+type UserResource struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+}
+
+// NewTransaction is the body of a request creating a transaction.
+type NewTransaction struct {
+	Name         string     `json:"name"`
+	Cost         float64    `json:"cost"`
+	Date         time.Time  `json:"date"`
+	CategoriesID int64      `json:"categories_id"`
+	Tags         []string   `json:"tags"`
+	Splits       []NewSplit `json:"splits"`
+	Payee        string     `json:"payee"`
+	Notes        string     `json:"notes"`
+}
+
+// NewNotes is the body of a request replacing the notes of a transaction.
+type NewNotes struct {
+	Notes string `json:"notes"`
+}
+
+// NewSplit is a line of a transaction split across categories.
+type NewSplit struct {
+	CategoriesID int64   `json:"categories_id"`
+	Amount       float64 `json:"amount"`
+}
+
+// NewCategory is the body of a request creating or updating a category. A
+// zero ParentID makes it a top level category.
+type NewCategory struct {
+	Name     string `json:"name"`
+	ParentID int64  `json:"parent_id"`
+}
+
+// newTransactions converts transactions to resources, looking up the names of
+// their categories, their tags, their splits, their payees and their
+// attachments. Only those of the given transactions are queried. It never
+// returns nil, so that an empty list is encoded as [].
+func newTransactions(ctx context.Context, queries TransactionQuerier, ts []database.Transaction) ([]TransactionResource, error) {
+	transactions := make([]TransactionResource, 0, len(ts))
+	if len(ts) == 0 {
+		return transactions, nil
+	}
+
+	ids := make([]int64, 0, len(ts))
+	var categoryIDs, payeeIDs []int64
+	for _, t := range ts {
+		ids = append(ids, t.ID)
+		categoryIDs = appendID(categoryIDs, t.CategoriesID)
+		if t.PayeeID.Valid {
+			payeeIDs = appendID(payeeIDs, t.PayeeID.Int64)
+		}
+	}
+
+	transactionTags, err := queries.GetTransactionTags(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[int64][]string)
+	for _, t := range transactionTags {
+		tags[t.TransactionID] = append(tags[t.TransactionID], t.Name)
+	}
+
+	transactionSplits, err := queries.GetTransactionSplits(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range transactionSplits {
+		categoryIDs = appendID(categoryIDs, s.CategoriesID)
+	}
+
+	categories, err := queries.GetCategoriesByIDs(ctx, categoryIDs)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int64]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+
+	splits := make(map[int64][]SplitResource)
+	for _, s := range transactionSplits {
+		splits[s.TransactionID] = append(splits[s.TransactionID], SplitResource{
+			CategoriesID: s.CategoriesID,
+			CategoryName: names[s.CategoriesID],
+			Amount:       s.Amount,
+		})
+	}
+
+	payeeNames := make(map[int64]string)
+	if len(payeeIDs) > 0 {
+		payees, err := queries.GetPayeesByIDs(ctx, payeeIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range payees {
+			payeeNames[p.ID] = p.Name
+		}
+	}
+
+	transactionAttachments, err := queries.GetAttachments(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	attachments := make(map[int64][]AttachmentResource)
+	for _, a := range transactionAttachments {
+		attachments[a.TransactionID] = append(attachments[a.TransactionID], AttachmentResource{
+			ID:          a.ID,
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Size:        a.Size,
+			CreatedAt:   a.CreatedAt.UTC(),
+		})
+	}
+
+	for _, t := range ts {
+		transactions = append(transactions, TransactionResource{
+			ID:           t.ID,
+			Name:         t.Name,
+			Cost:         t.Cost,
+			Date:         t.Date.UTC(),
+			CategoriesID: t.CategoriesID,
+			CategoryName: names[t.CategoriesID],
+			Tags:         tagNames(tags[t.ID]),
+			Splits:       splitLines(splits[t.ID]),
+			PayeeID:      nullableID(t.PayeeID),
+			PayeeName:    payeeNames[t.PayeeID.Int64],
+			Notes:        t.Notes,
+			Attachments:  attachmentList(attachments[t.ID]),
+		})
+	}
+	return transactions, nil
+}
+
+// appendID adds id to ids unless it is already there.
+func appendID(ids []int64, id int64) []int64 {
+	if slices.Contains(ids, id) {
+		return ids
+	}
+	return append(ids, id)
+}
+
+func newTransaction(ctx context.Context, queries TransactionQuerier, t database.Transaction) (TransactionResource, error) {
+	transactions, err := newTransactions(ctx, queries, []database.Transaction{t})
+	if err != nil {
+		return TransactionResource{}, err
+	}
+	return transactions[0], nil
+}
+
+// splitLines never returns nil, so that a transaction that is not split has [].
+func splitLines(splits []SplitResource) []SplitResource {
+	if splits == nil {
+		return []SplitResource{}
+	}
+	return splits
+}
+
+// attachmentList never returns nil, so that a transaction without attachments
+// has [].
+func attachmentList(attachments []AttachmentResource) []AttachmentResource {
+	if attachments == nil {
+		return []AttachmentResource{}
+	}
+	return attachments
+}
+
+// tagNames never returns nil, so that a transaction without tags has [].
+func tagNames(names []string) []string {
+	if names == nil {
+		return []string{}
+	}
+	return names
+}
+
+func newCategory(c database.Category) CategoryResource {
+	return CategoryResource{ID: c.ID, Name: c.Name, ParentID: nullableID(c.ParentID)}
+}
+
+func nullableID(id sql.NullInt64) *int64 {
+	if !id.Valid {
+		return nil
+	}
+	return &id.Int64
+}
+
+func newCategories(cs []database.Category) []CategoryResource {
+	categories := make([]CategoryResource, 0, len(cs))
+	for _, c := range cs {
+		categories = append(categories, newCategory(c))
+	}
+	return categories
+}
+
+// newTrash converts the trashed transactions and categories to resources. The
+// transactions of a trashed category are given its name, which the live
+// categories no longer have.
+func newTrash(ctx context.Context, queries TransactionQuerier, ts []database.Transaction, cs []database.Category) (TrashResource, error) {
+	resources, err := newTransactions(ctx, queries, ts)
+	if err != nil {
+		return TrashResource{}, err
+	}
+	trashedNames := make(map[int64]string, len(cs))
+	for _, c := range cs {
+		trashedNames[c.ID] = c.Name
+	}
+
+	trash := TrashResource{
+		Transactions: make([]TrashedTransactionResource, 0, len(ts)),
+		Categories:   make([]TrashedCategoryResource, 0, len(cs)),
+	}
+	for i, t := range resources {
+		if t.CategoryName == "" {
+			t.CategoryName = trashedNames[t.CategoriesID]
+		}
+		for j, split := range t.Splits {
+			if split.CategoryName == "" {
+				t.Splits[j].CategoryName = trashedNames[split.CategoriesID]
+			}
+		}
+		deletedAt := ts[i].DeletedAt.Time.UTC()
+		trash.Transactions = append(trash.Transactions, TrashedTransactionResource{
+			TransactionResource: t,
+			DeletedAt:           deletedAt,
+			PurgeAt:             purgeTime(deletedAt),
+		})
+	}
+	for _, c := range cs {
+		deletedAt := c.DeletedAt.Time.UTC()
+		trash.Categories = append(trash.Categories, TrashedCategoryResource{
+			CategoryResource: newCategory(c),
+			DeletedAt:        deletedAt,
+			PurgeAt:          purgeTime(deletedAt),
+		})
+	}
+	return trash, nil
+}
+
+// newAuditEntries converts the audit log entries to resources. It never
+// returns nil.
+func newAuditEntries(rows []database.GetAuditEntriesRow) []AuditEntryResource {
+	entries := make([]AuditEntryResource, 0, len(rows))
+	for _, r := range rows {
+		entry := AuditEntryResource{
+			ID:        r.ID,
+			UserEmail: r.UserEmail.String,
+			Entity:    r.Entity,
+			EntityID:  r.EntityID,
+			Action:    r.Action,
+			Before:    rawJSON(r.Before),
+			After:     rawJSON(r.After),
+			CreatedAt: r.CreatedAt.UTC(),
+		}
+		if r.UserID.Valid {
+			entry.UserID = &r.UserID.Int64
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// rawJSON passes a stored JSON document through, encoding NULL as null.
+func rawJSON(s sql.NullString) json.RawMessage {
+	if !s.Valid {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(s.String)
+}
+
+// purgeTime is when an item deleted at the given time leaves the trash for
+// good, or nil when the trash is kept until restored.
+func purgeTime(deletedAt time.Time) *time.Time {
+	if config.TrashRetention == 0 {
+		return nil
+	}
+	purgeAt := deletedAt.Add(config.TrashRetention)
+	return &purgeAt
+}
+
+// newCategoryTree nests the categories under their parents, sorted by name.
+// It never returns nil, at any level.
+func newCategoryTree(cs []database.Category) []CategoryNode {
+	sorted := slices.Clone(cs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i].Name) < strings.ToLower(sorted[j].Name)
+	})
+	// Top level categories are under the zero ID
+	children := make(map[int64][]database.Category)
+	for _, c := range sorted {
+		children[c.ParentID.Int64] = append(children[c.ParentID.Int64], c)
+	}
+
+	var build func(parent int64) []CategoryNode
+	build = func(parent int64) []CategoryNode {
+		nodes := make([]CategoryNode, 0, len(children[parent]))
+		for _, c := range children[parent] {
+			nodes = append(nodes, CategoryNode{CategoryResource: newCategory(c), Children: build(c.ID)})
+		}
+		return nodes
+	}
+	return build(0)
+}
+
+// newCategoryReport nests the totals of the categories under their parents,
+// adding up those of the subcategories.
+func newCategoryReport(rows []database.GetCategoryReportRow) []CategoryReportResource {
+	categories := make([]database.Category, 0, len(rows))
+	own := make(map[int64]database.GetCategoryReportRow, len(rows))
+	for _, r := range rows {
+		categories = append(categories, database.Category{ID: r.ID, Name: r.Name, ParentID: r.ParentID})
+		own[r.ID] = r
+	}
+
+	var build func(nodes []CategoryNode) []CategoryReportResource
+	build = func(nodes []CategoryNode) []CategoryReportResource {
+		report := make([]CategoryReportResource, 0, len(nodes))
+		for _, n := range nodes {
+			r := CategoryReportResource{
+				CategoryResource: n.CategoryResource,
+				Transactions:     own[n.ID].Transactions,
+				Total:            own[n.ID].Total,
+				OwnTransactions:  own[n.ID].Transactions,
+				OwnTotal:         own[n.ID].Total,
+				Children:         build(n.Children),
+			}
+			for _, child := range r.Children {
+				r.Transactions += child.Transactions
+				r.Total += child.Total
+			}
+			report = append(report, r)
+		}
+		return report
+	}
+	return build(newCategoryTree(categories))
+}
+
+func newCategorySuggestions(scores []categoryScore, cs []database.Category) []CategorySuggestionResource {
+	names := make(map[int64]string, len(cs))
+	for _, c := range cs {
+		names[c.ID] = c.Name
+	}
+
+	suggestions := make([]CategorySuggestionResource, 0, len(scores))
+	for _, s := range scores {
+		suggestions = append(suggestions, CategorySuggestionResource{
+			CategoriesID: s.id,
+			CategoryName: names[s.id],
+			Confidence:   math.Round(s.confidence*1000) / 1000,
+		})
+	}
+	return suggestions
+}
+
+// TagResource is a tag as returned by the API.
+type TagResource struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewTag is the body of a request creating or renaming a tag.
+type NewTag struct {
+	Name string `json:"name"`
+}
+
+// TagReportResource sums the transactions carrying a tag.
+type TagReportResource struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	Transactions int64   `json:"transactions"`
+	Total        float64 `json:"total"`
+}
+
+func newTags(ts []database.Tag) []TagResource {
+	tags := make([]TagResource, 0, len(ts))
+	for _, t := range ts {
+		tags = append(tags, TagResource{ID: t.ID, Name: t.Name})
+	}
+	return tags
+}
+
+func newTagReport(rows []database.GetTagReportRow) []TagReportResource {
+	report := make([]TagReportResource, 0, len(rows))
+	for _, r := range rows {
+		report = append(report, TagReportResource{
+			ID:           r.ID,
+			Name:         r.Name,
+			Transactions: r.Transactions,
+			Total:        r.Total,
+		})
+	}
+	return report
+}
+
+// PayeeResource is a payee as returned by the API, with the names it appears
+// under.
+type PayeeResource struct {
+	ID      int64                `json:"id"`
+	Name    string               `json:"name"`
+	Aliases []PayeeAliasResource `json:"aliases"`
+}
+
+// PayeeAliasResource is a name a payee appears under.
+type PayeeAliasResource struct {
+	ID    int64  `json:"id"`
+	Alias string `json:"alias"`
+}
+
+// NewPayee is the body of a request creating a payee.
+type NewPayee struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+// NewPayeeAlias is the body of a request adding an alias to a payee.
+type NewPayeeAlias struct {
+	PayeeID int64  `json:"payee_id"`
+	Alias   string `json:"alias"`
+}
+
+// PayeeReportResource sums the transactions of a payee.
+type PayeeReportResource struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	Transactions int64   `json:"transactions"`
+	Total        float64 `json:"total"`
+}
+
+// newPayees converts payees to resources with their aliases. It never returns
+// nil, at any level.
+func newPayees(ps []database.Payee, as []database.PayeeAlias) []PayeeResource {
+	aliases := make(map[int64][]PayeeAliasResource)
+	for _, a := range as {
+		aliases[a.PayeeID] = append(aliases[a.PayeeID], PayeeAliasResource{ID: a.ID, Alias: a.Alias})
+	}
+
+	payees := make([]PayeeResource, 0, len(ps))
+	for _, p := range ps {
+		payee := PayeeResource{ID: p.ID, Name: p.Name, Aliases: aliases[p.ID]}
+		if payee.Aliases == nil {
+			payee.Aliases = []PayeeAliasResource{}
+		}
+		payees = append(payees, payee)
+	}
+	return payees
+}
+
+func newPayeeReport(rows []database.GetPayeeReportRow) []PayeeReportResource {
+	report := make([]PayeeReportResource, 0, len(rows))
+	for _, r := range rows {
+		report = append(report, PayeeReportResource{
+			ID:           r.ID,
+			Name:         r.Name,
+			Transactions: r.Transactions,
+			Total:        r.Total,
+		})
+	}
+	return report
+}
+
+// RuleResource is a rule as returned by the API. Bounds that are not set are
+// null, and an empty list of weekdays matches every day.
+type RuleResource struct {
+	ID           int64    `json:"id"`
+	Name         string   `json:"name"`
+	Priority     int64    `json:"priority"`
+	MatchField   string   `json:"match_field"`
+	MatchType    string   `json:"match_type"`
+	Pattern      string   `json:"pattern"`
+	AmountMin    *float64 `json:"amount_min"`
+	AmountMax    *float64 `json:"amount_max"`
+	Weekdays     []string `json:"weekdays"`
+	CategoriesID *int64   `json:"categories_id"`
+	Tags         []string `json:"tags"`
+}
+
+// NewRule is the body of a request creating a rule. A zero amount_min,
+// amount_max or categories_id is not set.
+type NewRule struct {
+	Name         string   `json:"name"`
+	Priority     int64    `json:"priority"`
+	MatchField   string   `json:"match_field"`
+	MatchType    string   `json:"match_type"`
+	Pattern      string   `json:"pattern"`
+	AmountMin    float64  `json:"amount_min"`
+	AmountMax    float64  `json:"amount_max"`
+	Weekdays     []string `json:"weekdays"`
+	CategoriesID int64    `json:"categories_id"`
+	Tags         []string `json:"tags"`
+}
+
+// RuleChangeResource is what the rules do to an uncategorized transaction:
+// the category of the rule RuleID, null when the rules only add tags, and the
+// tags added by every matching rule.
+type RuleChangeResource struct {
+	TransactionID int64    `json:"transaction_id"`
+	Name          string   `json:"name"`
+	RuleID        int64    `json:"rule_id"`
+	RuleName      string   `json:"rule_name"`
+	CategoriesID  *int64   `json:"categories_id"`
+	CategoryName  string   `json:"category_name,omitempty"`
+	Tags          []string `json:"tags"`
+}
+
+func nullableAmount(amount sql.NullFloat64) *float64 {
+	if !amount.Valid {
+		return nil
+	}
+	return &amount.Float64
+}
+
+// newRules converts rules to resources with their tags. It never returns nil,
+// at any level.
+func newRules(rs []database.Rule, ts []database.GetRuleTagsRow) []RuleResource {
+	tags := make(map[int64][]string)
+	for _, t := range ts {
+		tags[t.RuleID] = append(tags[t.RuleID], t.Name)
+	}
+
+	rules := make([]RuleResource, 0, len(rs))
+	for _, r := range rs {
+		rule := RuleResource{
+			ID:           r.ID,
+			Name:         r.Name,
+			Priority:     r.Priority,
+			MatchField:   r.MatchField,
+			MatchType:    r.MatchType,
+			Pattern:      r.Pattern,
+			AmountMin:    nullableAmount(r.AmountMin),
+			AmountMax:    nullableAmount(r.AmountMax),
+			Weekdays:     weekdayNames(r.Weekdays),
+			CategoriesID: nullableID(r.CategoriesID),
+			Tags:         tags[r.ID],
+		}
+		if rule.Tags == nil {
+			rule.Tags = []string{}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func newRuleChanges(matches []ruleMatch, cs []database.Category) []RuleChangeResource {
+	names := make(map[int64]string, len(cs))
+	for _, c := range cs {
+		names[c.ID] = c.Name
+	}
+
+	changes := make([]RuleChangeResource, 0, len(matches))
+	for _, m := range matches {
+		change := RuleChangeResource{
+			TransactionID: m.transaction.ID,
+			Name:          m.transaction.Name,
+			RuleID:        m.rule.ID,
+			RuleName:      m.rule.Name,
+			CategoriesID:  nullableID(m.categoriesID),
+			CategoryName:  names[m.categoriesID.Int64],
+			Tags:          []string{},
+		}
+		for _, t := range m.tags {
+			change.Tags = append(change.Tags, t.Name)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
@@ -0,0 +1,586 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"quattrinitrack/audit"
+	"quattrinitrack/database"
+	middleware "quattrinitrack/middlewares"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// uncategorizedCategory is the top level category of the transactions created
+// without a category that no rule could place. It is created when first needed.
+const uncategorizedCategory = "Uncategorized"
+
+type RuleQuerier interface {
+	GetAllRules(ctx context.Context) ([]database.Rule, error)
+	GetRuleByID(ctx context.Context, id int64) (database.Rule, error)
+	DeleteRule(ctx context.Context, id int64) error
+	GetRuleTags(ctx context.Context) ([]database.GetRuleTagsRow, error)
+	GetAllCategories(ctx context.Context) ([]database.Category, error)
+	GetCategoryByID(ctx context.Context, id int64) (database.Category, error)
+	GetRootCategoryByName(ctx context.Context, name string) (database.Category, error)
+	GetUncategorizedTransactions(ctx context.Context, categoriesID int64) ([]database.GetUncategorizedTransactionsRow, error)
+	GetTransactionTags(ctx context.Context, ids []int64) ([]database.GetTransactionTagsRow, error)
+	SetTransactionCategory(ctx context.Context, arg database.SetTransactionCategoryParams) error
+	TagTransaction(ctx context.Context, arg database.TagTransactionParams) error
+	InsertAuditEntry(ctx context.Context, arg database.InsertAuditEntryParams) error
+	InRuleTx(ctx context.Context, fn func(RuleTxQuerier) error) error
+}
+
+// RuleTxQuerier is what creating a rule with its tags, and running the rules
+// over the uncategorized transactions, need, run in a single database
+// transaction.
+type RuleTxQuerier interface {
+	ruleMatcher
+	InsertRule(ctx context.Context, arg database.InsertRuleParams) (int64, error)
+	AddRuleTag(ctx context.Context, arg database.AddRuleTagParams) error
+	EnsureTag(ctx context.Context, name string) error
+	GetTagByName(ctx context.Context, name string) (database.Tag, error)
+	SetTransactionCategory(ctx context.Context, arg database.SetTransactionCategoryParams) error
+	TagTransaction(ctx context.Context, arg database.TagTransactionParams) error
+	InsertAuditEntry(ctx context.Context, arg database.InsertAuditEntryParams) error
+}
+
+// ruleSource is what loading the rules needs, shared by the rule and
+// transaction handlers.
+type ruleSource interface {
+	GetAllRules(ctx context.Context) ([]database.Rule, error)
+	GetRuleTags(ctx context.Context) ([]database.GetRuleTagsRow, error)
+}
+
+// ruleMatcher is what matching the rules over the uncategorized transactions
+// needs, shared by the preview and the run of the rules.
+type ruleMatcher interface {
+	ruleSource
+	GetRootCategoryByName(ctx context.Context, name string) (database.Category, error)
+	GetUncategorizedTransactions(ctx context.Context, categoriesID int64) ([]database.GetUncategorizedTransactionsRow, error)
+	GetTransactionTags(ctx context.Context, ids []int64) ([]database.GetTransactionTagsRow, error)
+}
+
+// rule is a rule ready to be matched, with its pattern compiled and its tags.
+type rule struct {
+	database.Rule
+	pattern *regexp.Regexp
+	tags    []database.GetRuleTagsRow
+}
+
+// ruleSubject is what rules look at in a transaction.
+type ruleSubject struct {
+	name  string
+	payee string
+	cost  float64
+	date  time.Time
+}
+
+// ruleResult is what the rules matching a transaction give it: the category
+// of the first of them having one, and the tags of all of them. rule is the
+// rule giving the category, or the first matching one when none does.
+type ruleResult struct {
+	rule         rule
+	categoriesID sql.NullInt64
+	tags         []database.GetRuleTagsRow
+}
+
+// ruleMatch is an uncategorized transaction and what the rules matching it
+// change, with tags only the ones it does not have yet.
+type ruleMatch struct {
+	transaction database.GetUncategorizedTransactionsRow
+	ruleResult
+	tagged []string
+}
+
+// loadRules returns the rules in the order they are tried: by priority, then
+// by creation.
+func loadRules(ctx context.Context, queries ruleSource) ([]rule, error) {
+	rs, err := queries.GetAllRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := queries.GetRuleTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[int64][]database.GetRuleTagsRow)
+	for _, t := range ts {
+		tags[t.RuleID] = append(tags[t.RuleID], t)
+	}
+
+	rules := make([]rule, 0, len(rs))
+	for _, r := range rs {
+		compiled := rule{Rule: r, tags: tags[r.ID]}
+		if r.MatchType == "regex" {
+			compiled.pattern, err = compileRulePattern(r.Pattern)
+			if err != nil {
+				return nil, err
+			}
+		}
+		rules = append(rules, compiled)
+	}
+	return rules, nil
+}
+
+// compileRulePattern compiles the regular expression of a rule, which ignores
+// case like the substring match does.
+func compileRulePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// matches reports whether every condition of the rule holds for s.
+func (r rule) matches(s ruleSubject) bool {
+	text := s.name
+	if r.MatchField == "payee" {
+		text = s.payee
+	}
+	switch {
+	case r.Pattern == "":
+	case r.pattern != nil:
+		if !r.pattern.MatchString(text) {
+			return false
+		}
+	case !strings.Contains(strings.ToLower(text), strings.ToLower(r.Pattern)):
+		return false
+	}
+
+	if r.AmountMin.Valid && s.cost < r.AmountMin.Float64 {
+		return false
+	}
+	if r.AmountMax.Valid && s.cost > r.AmountMax.Float64 {
+		return false
+	}
+	return r.Weekdays == 0 || r.Weekdays&(1<<s.date.UTC().Weekday()) != 0
+}
+
+// matchRules returns what the rules matching s give it, reporting false when
+// none matches. A rule only adding tags does not keep a later one from giving
+// its category.
+func matchRules(rules []rule, s ruleSubject) (ruleResult, bool) {
+	var result ruleResult
+	matched := false
+	for _, r := range rules {
+		if !r.matches(s) {
+			continue
+		}
+		if !matched || !result.categoriesID.Valid && r.CategoriesID.Valid {
+			result.rule = r
+			result.categoriesID = r.CategoriesID
+		}
+		matched = true
+		for _, t := range r.tags {
+			if !slices.ContainsFunc(result.tags, func(u database.GetRuleTagsRow) bool { return u.TagID == t.TagID }) {
+				result.tags = append(result.tags, t)
+			}
+		}
+	}
+	return result, matched
+}
+
+// weekdayMask turns day names, such as "monday", into the mask stored with a
+// rule. It reports false when a name is not a day of the week.
+func weekdayMask(names []string) (int64, bool) {
+	var mask int64
+	for _, name := range names {
+		found := false
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.EqualFold(strings.TrimSpace(name), d.String()) {
+				mask |= 1 << d
+				found = true
+			}
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return mask, true
+}
+
+// weekdayNames lists the days of a mask, Monday first. It never returns nil.
+func weekdayNames(mask int64) []string {
+	names := []string{}
+	for i := 1; i <= 7; i++ {
+		d := time.Weekday(i % 7)
+		if mask&(1<<d) != 0 {
+			names = append(names, strings.ToLower(d.String()))
+		}
+	}
+	return names
+}
+
+// Rule handles the rules assigning a category and tags to new transactions.
+func Rule(queries RuleQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		if req.Method == http.MethodGet {
+			id := req.URL.Query().Get("id")
+
+			switch {
+			case id != "":
+				idNum, err := strconv.ParseInt(id, 10, 64)
+				if err != nil {
+					slog.WarnContext(ctx, "error in converting id", "error", err)
+					invalidParam(w, ctx, "id", "must be an integer")
+					return
+				}
+				getRuleByID(w, ctx, queries, idNum)
+			default:
+				getAllRules(w, ctx, queries)
+			}
+		}
+
+		if req.Method == http.MethodPost {
+			insertRule(w, req, ctx, queries)
+		}
+
+		if req.Method == http.MethodDelete {
+			id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
+			if err != nil {
+				slog.WarnContext(ctx, "error in converting id", "error", err)
+				invalidParam(w, ctx, "id", "must be an integer")
+				return
+			}
+			deleteRule(w, ctx, queries, id)
+		}
+	}
+}
+
+// RulePreview lists what running the rules over the uncategorized
+// transactions would change, without changing anything.
+func RulePreview(queries RuleQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		matches, err := matchUncategorized(ctx, queries)
+		if err != nil {
+			slog.ErrorContext(ctx, "error matching rules", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+		writeRuleChanges(w, ctx, queries, matches)
+	}
+}
+
+// RuleApply runs the rules over the uncategorized transactions, and lists the
+// changes made like RulePreview. Every change is made, or none is.
+func RuleApply(queries RuleQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		var matches []ruleMatch
+		err := queries.InRuleTx(ctx, func(tx RuleTxQuerier) error {
+			var err error
+			matches, err = matchUncategorized(ctx, tx)
+			if err != nil {
+				return fmt.Errorf("matching the rules: %w", err)
+			}
+			for _, m := range matches {
+				if err := applyRule(ctx, tx, m); err != nil {
+					return fmt.Errorf("applying rule %d to transaction %d: %w", m.rule.ID, m.transaction.ID, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "could not apply the rules", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+		slog.InfoContext(ctx, "rules applied", "transactions", len(matches))
+		writeRuleChanges(w, ctx, queries, matches)
+	}
+}
+
+// matchUncategorized pairs the uncategorized transactions with what the rules
+// matching them change, leaving out those the rules leave as they are.
+func matchUncategorized(ctx context.Context, queries ruleMatcher) ([]ruleMatch, error) {
+	category, err := queries.GetRootCategoryByName(ctx, uncategorizedCategory)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := queries.GetUncategorizedTransactions(ctx, category.ID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := loadRules(ctx, queries)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []ruleMatch
+	var ids []int64
+	for _, t := range transactions {
+		result, ok := matchRules(rules, ruleSubject{name: t.Name, payee: t.PayeeName.String, cost: t.Cost, date: t.Date})
+		if ok {
+			matches = append(matches, ruleMatch{transaction: t, ruleResult: result})
+			ids = append(ids, t.ID)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	rows, err := queries.GetTransactionTags(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	tagged := make(map[int64][]string)
+	for _, r := range rows {
+		tagged[r.TransactionID] = append(tagged[r.TransactionID], r.Name)
+	}
+	changes := matches[:0]
+	for _, m := range matches {
+		m.tagged = tagged[m.transaction.ID]
+		if m.categoriesID.Int64 == m.transaction.CategoriesID {
+			m.categoriesID = sql.NullInt64{}
+		}
+		m.tags = slices.DeleteFunc(slices.Clone(m.tags), func(t database.GetRuleTagsRow) bool {
+			return slices.Contains(m.tagged, t.Name)
+		})
+		if m.categoriesID.Valid || len(m.tags) > 0 {
+			changes = append(changes, m)
+		}
+	}
+	return changes, nil
+}
+
+// applyRule gives the transaction the category and tags of the match, and
+// records both changes in the audit log.
+func applyRule(ctx context.Context, queries RuleTxQuerier, m ruleMatch) error {
+	if m.categoriesID.Valid {
+		err := queries.SetTransactionCategory(ctx, database.SetTransactionCategoryParams{
+			CategoriesID: m.categoriesID.Int64,
+			ID:           m.transaction.ID,
+		})
+		if err != nil {
+			return err
+		}
+		err = audit.RecordUpdate(ctx, queries, audit.EntityTransaction, m.transaction.ID,
+			map[string]any{"categories_id": m.transaction.CategoriesID},
+			map[string]any{"categories_id": m.categoriesID.Int64})
+		if err != nil {
+			return err
+		}
+	}
+	if len(m.tags) == 0 {
+		return nil
+	}
+
+	before := append([]string{}, m.tagged...)
+	after := slices.Clone(before)
+	for _, t := range m.tags {
+		err := queries.TagTransaction(ctx, database.TagTransactionParams{TransactionID: m.transaction.ID, TagID: t.TagID})
+		if err != nil {
+			return err
+		}
+		after = append(after, t.Name)
+	}
+	slices.Sort(after)
+	return audit.RecordUpdate(ctx, queries, audit.EntityTransaction, m.transaction.ID,
+		map[string]any{"tags": before}, map[string]any{"tags": after})
+}
+
+func writeRuleChanges(w http.ResponseWriter, ctx context.Context, queries RuleQuerier, matches []ruleMatch) {
+	categories, err := queries.GetAllCategories(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting categories", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newRuleChanges(matches, categories))
+	if err != nil {
+		slog.ErrorContext(ctx, "error encoding rule changes", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+	}
+}
+
+func getAllRules(w http.ResponseWriter, ctx context.Context, queries RuleQuerier) {
+	rules, err := queries.GetAllRules(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting rules", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+	tags, err := queries.GetRuleTags(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting rule tags", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newRules(rules, tags))
+	if err != nil {
+		slog.ErrorContext(ctx, "error encoding rules", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+	}
+}
+
+func getRuleByID(w http.ResponseWriter, ctx context.Context, queries RuleQuerier, id int64) {
+	rule, err := queries.GetRuleByID(ctx, id)
+	if err != nil {
+		slog.WarnContext(ctx, "rule not found", "id", id, "error", err)
+		middleware.Error(w, ctx, http.StatusNotFound, middleware.CodeNotFound, "no rule found with the given ID")
+		return
+	}
+	tags, err := queries.GetRuleTags(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting rule tags", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newRules([]database.Rule{rule}, tags)[0])
+	if err != nil {
+		slog.ErrorContext(ctx, "error encoding rule", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+	}
+}
+
+// validateRule checks a new rule and fills in its defaults, returning the
+// weekdays mask to store.
+func validateRule(ctx context.Context, queries RuleQuerier, rule *NewRule) ([]middleware.FieldError, int64, error) {
+	var details []middleware.FieldError
+
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		details = append(details, middleware.FieldError{Field: "name", Message: "is required"})
+	}
+
+	if rule.MatchField == "" {
+		rule.MatchField = "name"
+	}
+	if rule.MatchField != "name" && rule.MatchField != "payee" {
+		details = append(details, middleware.FieldError{Field: "match_field", Message: "must be name or payee"})
+	}
+	if rule.MatchType == "" {
+		rule.MatchType = "contains"
+	}
+	switch rule.MatchType {
+	case "contains":
+	case "regex":
+		if _, err := compileRulePattern(rule.Pattern); err != nil {
+			details = append(details, middleware.FieldError{Field: "pattern", Message: "must be a valid regular expression"})
+		}
+	default:
+		details = append(details, middleware.FieldError{Field: "match_type", Message: "must be contains or regex"})
+	}
+
+	if rule.AmountMin < 0 {
+		details = append(details, middleware.FieldError{Field: "amount_min", Message: "must not be negative"})
+	}
+	if rule.AmountMax < 0 {
+		details = append(details, middleware.FieldError{Field: "amount_max", Message: "must not be negative"})
+	}
+	if rule.AmountMin > 0 && rule.AmountMax > 0 && rule.AmountMin > rule.AmountMax {
+		details = append(details, middleware.FieldError{Field: "amount_max", Message: "must not be less than amount_min"})
+	}
+
+	weekdays, ok := weekdayMask(rule.Weekdays)
+	if !ok {
+		details = append(details, middleware.FieldError{Field: "weekdays", Message: "must be days of the week, such as monday"})
+	}
+
+	switch {
+	case rule.CategoriesID != 0:
+		_, err := queries.GetCategoryByID(ctx, rule.CategoriesID)
+		if errors.Is(err, sql.ErrNoRows) {
+			details = append(details, middleware.FieldError{Field: "categories_id", Message: "must be an existing category"})
+		} else if err != nil {
+			return nil, 0, err
+		}
+	case len(rule.Tags) == 0:
+		details = append(details, middleware.FieldError{Field: "categories_id", Message: "is required unless tags are given"})
+	}
+	return details, weekdays, nil
+}
+
+func insertRule(w http.ResponseWriter, req *http.Request, ctx context.Context, queries RuleQuerier) {
+	var rule NewRule
+	if err := json.NewDecoder(req.Body).Decode(&rule); err != nil {
+		middleware.Error(w, ctx, http.StatusBadRequest, middleware.CodeInvalidJSON, "invalid JSON")
+		return
+	}
+
+	details, weekdays, err := validateRule(ctx, queries, &rule)
+	if err != nil {
+		slog.ErrorContext(ctx, "error in validating rule", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+	if len(details) > 0 {
+		validationError(w, ctx, details)
+		return
+	}
+
+	err = queries.InRuleTx(ctx, func(tx RuleTxQuerier) error {
+		id, err := tx.InsertRule(ctx, database.InsertRuleParams{
+			Name:         rule.Name,
+			Priority:     rule.Priority,
+			MatchField:   rule.MatchField,
+			MatchType:    rule.MatchType,
+			Pattern:      rule.Pattern,
+			AmountMin:    sql.NullFloat64{Float64: rule.AmountMin, Valid: rule.AmountMin != 0},
+			AmountMax:    sql.NullFloat64{Float64: rule.AmountMax, Valid: rule.AmountMax != 0},
+			Weekdays:     weekdays,
+			CategoriesID: sql.NullInt64{Int64: rule.CategoriesID, Valid: rule.CategoriesID != 0},
+		})
+		if err != nil {
+			return fmt.Errorf("inserting rule: %w", err)
+		}
+
+		for _, name := range rule.Tags {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if err := tx.EnsureTag(ctx, name); err != nil {
+				return fmt.Errorf("inserting tag %q: %w", name, err)
+			}
+			tag, err := tx.GetTagByName(ctx, name)
+			if err == nil {
+				err = tx.AddRuleTag(ctx, database.AddRuleTagParams{RuleID: id, TagID: tag.ID})
+			}
+			if err != nil {
+				return fmt.Errorf("adding tag %q to rule %d: %w", name, id, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		txError(w, ctx, err, "could not insert the rule", "name", rule.Name)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]string{"message": "Rule created successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func deleteRule(w http.ResponseWriter, ctx context.Context, queries RuleQuerier, id int64) {
+	_, err := queries.GetRuleByID(ctx, id)
+	if err != nil {
+		slog.WarnContext(ctx, "no rule present", "id", id)
+		middleware.Error(w, ctx, http.StatusNotFound, middleware.CodeNotFound, "no rule found with the given ID")
+		return
+	}
+	err = queries.DeleteRule(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "could not delete rule", "id", id, "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"quattrinitrack/database"
+	middleware "quattrinitrack/middlewares"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultSuggestionLimit = 3
+	maxSuggestionLimit     = 10
+)
+
+// categoryModel is a naive Bayes classifier of transactions into categories,
+// trained on the words of their names and the size of their amounts.
+type categoryModel struct {
+	samples    int
+	categories map[int64]int
+	counts     map[int64]map[string]int
+	totals     map[int64]int
+	vocabulary map[string]bool
+}
+
+// categoryScore is the probability the model gives a category.
+type categoryScore struct {
+	id         int64
+	confidence float64
+}
+
+// suggestionFeatures returns the words of a transaction name, without numbers
+// such as store numbers, and the power of two bucket of its cost, if known.
+func suggestionFeatures(name string, cost float64) []string {
+	var features []string
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if strings.TrimFunc(word, unicode.IsDigit) != "" {
+			features = append(features, word)
+		}
+	}
+	if cost > 0 {
+		features = append(features, fmt.Sprintf("amount:%d", int(math.Floor(math.Log2(cost)))))
+	}
+	return features
+}
+
+func trainCategoryModel(samples []database.GetCategorySamplesRow) categoryModel {
+	m := categoryModel{
+		categories: make(map[int64]int),
+		counts:     make(map[int64]map[string]int),
+		totals:     make(map[int64]int),
+		vocabulary: make(map[string]bool),
+	}
+	for _, s := range samples {
+		m.samples++
+		m.categories[s.CategoriesID]++
+		if m.counts[s.CategoriesID] == nil {
+			m.counts[s.CategoriesID] = make(map[string]int)
+		}
+		for _, f := range suggestionFeatures(s.Name, s.Amount) {
+			m.counts[s.CategoriesID][f]++
+			m.totals[s.CategoriesID]++
+			m.vocabulary[f] = true
+		}
+	}
+	return m
+}
+
+// suggest scores every category seen in training, most likely first. Word
+// counts are smoothed, so unseen words do not rule a category out.
+func (m categoryModel) suggest(features []string) []categoryScore {
+	if m.samples == 0 {
+		return nil
+	}
+
+	logs := make(map[int64]float64, len(m.categories))
+	best := math.Inf(-1)
+	for id, n := range m.categories {
+		score := math.Log(float64(n) / float64(m.samples))
+		for _, f := range features {
+			score += math.Log(float64(m.counts[id][f]+1) / float64(m.totals[id]+len(m.vocabulary)))
+		}
+		logs[id] = score
+		best = math.Max(best, score)
+	}
+
+	// The log scores turned back into probabilities adding up to 1
+	var sum float64
+	scores := make([]categoryScore, 0, len(logs))
+	for id, score := range logs {
+		p := math.Exp(score - best)
+		sum += p
+		scores = append(scores, categoryScore{id: id, confidence: p})
+	}
+	for i := range scores {
+		scores[i].confidence /= sum
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].confidence != scores[j].confidence {
+			return scores[i].confidence > scores[j].confidence
+		}
+		return scores[i].id < scores[j].id
+	})
+	return scores
+}
+
+// CategorySuggest suggests categories for a transaction from its name and,
+// optionally, its cost. The model is trained on the categorized transactions
+// at every request, so it follows the history without any state to keep.
+func CategorySuggest(queries CategoryQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		query := req.URL.Query()
+
+		name := strings.TrimSpace(query.Get("name"))
+		if name == "" {
+			invalidParam(w, ctx, "name", "is required")
+			return
+		}
+		var cost float64
+		if value := query.Get("cost"); value != "" {
+			c, err := strconv.ParseFloat(value, 64)
+			if err != nil || c <= 0 {
+				invalidParam(w, ctx, "cost", "must be a number greater than 0")
+				return
+			}
+			cost = c
+		}
+		limit := defaultSuggestionLimit
+		if value := query.Get("limit"); value != "" {
+			l, err := strconv.Atoi(value)
+			if err != nil || l < 1 || l > maxSuggestionLimit {
+				invalidParam(w, ctx, "limit", fmt.Sprintf("must be an integer between 1 and %d", maxSuggestionLimit))
+				return
+			}
+			limit = l
+		}
+
+		samples, err := queries.GetCategorySamples(ctx, uncategorizedCategory)
+		if err != nil {
+			slog.ErrorContext(ctx, "error getting category samples", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+		categories, err := queries.GetAllCategories(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "error getting categories", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+
+		scores := trainCategoryModel(samples).suggest(suggestionFeatures(name, cost))
+		if len(scores) > limit {
+			scores = scores[:limit]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(newCategorySuggestions(scores, categories))
+		if err != nil {
+			slog.ErrorContext(ctx, "error encoding category suggestions", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"quattrinitrack/audit"
+	"quattrinitrack/database"
+	middleware "quattrinitrack/middlewares"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type TagQuerier interface {
+	GetAllTags(ctx context.Context) ([]database.Tag, error)
+	GetTagByID(ctx context.Context, id int64) (database.Tag, error)
+	InsertTag(ctx context.Context, name string) error
+	GetTagReport(ctx context.Context, arg database.GetTagReportParams) ([]database.GetTagReportRow, error)
+	InTagTx(ctx context.Context, fn func(TagTxQuerier) error) error
+}
+
+// TagTxQuerier is what renaming or deleting a tag needs, with the audit
+// entries of the transactions carrying it, run in a single database
+// transaction.
+type TagTxQuerier interface {
+	GetTagByID(ctx context.Context, id int64) (database.Tag, error)
+	GetTagTransactionIDs(ctx context.Context, tagID int64) ([]int64, error)
+	GetTransactionTags(ctx context.Context, ids []int64) ([]database.GetTransactionTagsRow, error)
+	RenameTag(ctx context.Context, arg database.RenameTagParams) error
+	DeleteTag(ctx context.Context, id int64) error
+	InsertAuditEntry(ctx context.Context, arg database.InsertAuditEntryParams) error
+}
+
+// Tag handles the tags put on transactions. Unlike categories, a transaction
+// may carry any number of them.
+func Tag(queries TagQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		if req.Method == http.MethodGet {
+			id := req.URL.Query().Get("id")
+
+			switch {
+			case id != "":
+				idNum, err := strconv.ParseInt(id, 10, 64)
+				if err != nil {
+					slog.WarnContext(ctx, "error in converting id", "error", err)
+					invalidParam(w, ctx, "id", "must be an integer")
+					return
+				}
+				getTagByID(w, ctx, queries, idNum)
+			default:
+				getAllTags(w, ctx, queries)
+			}
+		}
+
+		if req.Method == http.MethodPost {
+			insertTag(w, req, ctx, queries)
+		}
+
+		if req.Method == http.MethodPut {
+			id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
+			if err != nil {
+				slog.WarnContext(ctx, "error in converting id", "error", err)
+				invalidParam(w, ctx, "id", "must be an integer")
+				return
+			}
+			renameTag(w, req, ctx, queries, id)
+		}
+
+		if req.Method == http.MethodDelete {
+			id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
+			if err != nil {
+				slog.WarnContext(ctx, "error in converting id", "error", err)
+				invalidParam(w, ctx, "id", "must be an integer")
+				return
+			}
+			deleteTag(w, ctx, queries, id)
+		}
+	}
+}
+
+// TagReport sums the transactions of every tag, optionally between the from
+// and to dates.
+func TagReport(queries TagQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		from, to, ok := parseReportDates(w, req, ctx)
+		if !ok {
+			return
+		}
+
+		report, err := queries.GetTagReport(ctx, database.GetTagReportParams{DateFrom: from, DateTo: to})
+		if err != nil {
+			slog.ErrorContext(ctx, "error getting the tag report", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(newTagReport(report))
+		if err != nil {
+			slog.ErrorContext(ctx, "error encoding the tag report", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		}
+	}
+}
+
+func getAllTags(w http.ResponseWriter, ctx context.Context, queries TagQuerier) {
+	tags, err := queries.GetAllTags(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting tags", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(newTags(tags))
+	if err != nil {
+		slog.ErrorContext(ctx, "error encoding tags", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+	}
+}
+
+func getTagByID(w http.ResponseWriter, ctx context.Context, queries TagQuerier, id int64) {
+	tag, err := queries.GetTagByID(ctx, id)
+	if err != nil {
+		slog.WarnContext(ctx, "tag not found", "id", id, "error", err)
+		middleware.Error(w, ctx, http.StatusNotFound, middleware.CodeNotFound, "no tag found with the given ID")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(TagResource{ID: tag.ID, Name: tag.Name})
+	if err != nil {
+		slog.ErrorContext(ctx, "error encoding tag", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+	}
+}
+
+// decodeTag reads the body of a request creating or renaming a tag, and
+// responds with an error when it is not valid.
+func decodeTag(w http.ResponseWriter, req *http.Request, ctx context.Context) (string, bool) {
+	var tag NewTag
+	if err := json.NewDecoder(req.Body).Decode(&tag); err != nil {
+		middleware.Error(w, ctx, http.StatusBadRequest, middleware.CodeInvalidJSON, "invalid JSON")
+		return "", false
+	}
+
+	name := strings.TrimSpace(tag.Name)
+	if name == "" {
+		validationError(w, ctx, []middleware.FieldError{{Field: "name", Message: "is required"}})
+		return "", false
+	}
+	return name, true
+}
+
+func insertTag(w http.ResponseWriter, req *http.Request, ctx context.Context, queries TagQuerier) {
+	name, ok := decodeTag(w, req, ctx)
+	if !ok {
+		return
+	}
+
+	err := queries.InsertTag(ctx, name)
+	if err != nil {
+		slog.WarnContext(ctx, "error with inserting tag in db", "name", name, "error", err)
+		middleware.Error(w, ctx, http.StatusConflict, middleware.CodeConflict, "tag already exists")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := map[string]string{"message": "Tag created successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func renameTag(w http.ResponseWriter, req *http.Request, ctx context.Context, queries TagQuerier, id int64) {
+	name, ok := decodeTag(w, req, ctx)
+	if !ok {
+		return
+	}
+
+	err := queries.InTagTx(ctx, func(tx TagTxQuerier) error {
+		tag, err := getTag(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		ids, tags, err := tagHolders(ctx, tx, id)
+		if err != nil {
+			return fmt.Errorf("getting the transactions of the tag: %w", err)
+		}
+		err = tx.RenameTag(ctx, database.RenameTagParams{Name: name, ID: id})
+		if err != nil {
+			slog.WarnContext(ctx, "could not rename tag", "id", id, "name", name, "error", err)
+			return &httpError{http.StatusConflict, middleware.CodeConflict, "tag already exists"}
+		}
+		return recordRetag(ctx, tx, ids, tags, tag.Name, name)
+	})
+	if err != nil {
+		txError(w, ctx, err, "could not rename the tag", "id", id, "name", name)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]string{"message": "Tag renamed successfully"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func deleteTag(w http.ResponseWriter, ctx context.Context, queries TagQuerier, id int64) {
+	err := queries.InTagTx(ctx, func(tx TagTxQuerier) error {
+		tag, err := getTag(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		ids, tags, err := tagHolders(ctx, tx, id)
+		if err != nil {
+			return fmt.Errorf("getting the transactions of the tag: %w", err)
+		}
+		// The tag is also removed from its transactions
+		if err := tx.DeleteTag(ctx, id); err != nil {
+			return fmt.Errorf("deleting tag: %w", err)
+		}
+		return recordRetag(ctx, tx, ids, tags, tag.Name, "")
+	})
+	if err != nil {
+		txError(w, ctx, err, "could not delete the tag", "id", id)
+	}
+}
+
+// getTag returns the tag, or a not found error when there is none.
+func getTag(ctx context.Context, queries TagTxQuerier, id int64) (database.Tag, error) {
+	tag, err := queries.GetTagByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		slog.WarnContext(ctx, "no tag present", "id", id)
+		return tag, &httpError{http.StatusNotFound, middleware.CodeNotFound, "no tag found with the given ID"}
+	}
+	if err != nil {
+		return tag, fmt.Errorf("getting tag: %w", err)
+	}
+	return tag, nil
+}
+
+// tagHolders returns the transactions carrying the tag, and all the tags of
+// each of them.
+func tagHolders(ctx context.Context, queries TagTxQuerier, id int64) ([]int64, map[int64][]string, error) {
+	ids, err := queries.GetTagTransactionIDs(ctx, id)
+	if err != nil || len(ids) == 0 {
+		return nil, nil, err
+	}
+	rows, err := queries.GetTransactionTags(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags := make(map[int64][]string, len(ids))
+	for _, r := range rows {
+		tags[r.TransactionID] = append(tags[r.TransactionID], r.Name)
+	}
+	return ids, tags, nil
+}
+
+// recordRetag records in the audit log the tags of the transactions once the
+// tag named old is renamed to name, or removed when name is empty.
+func recordRetag(ctx context.Context, queries TagTxQuerier, ids []int64, tags map[int64][]string, old, name string) error {
+	for _, id := range ids {
+		before := tags[id]
+		after := slices.DeleteFunc(slices.Clone(before), func(t string) bool { return t == old })
+		if name != "" {
+			after = append(after, name)
+			slices.Sort(after)
+		}
+		err := audit.RecordUpdate(ctx, queries, audit.EntityTransaction, id,
+			map[string]any{"tags": before}, map[string]any{"tags": after})
+		if err != nil {
+			return fmt.Errorf("recording the tags of transaction %d: %w", id, err)
+		}
+	}
+	return nil
+}
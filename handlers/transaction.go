@@ -3,11 +3,38 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
+	"quattrinitrack/audit"
 	"quattrinitrack/database"
+	middleware "quattrinitrack/middlewares"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+
+	// maxNotesLength is the number of characters the notes of a transaction
+	// can have.
+	maxNotesLength = 10000
+)
+
+var (
+	// Bounds used when a page request has no from/to filter
+	minTransactionDate = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	maxTransactionDate = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	transactionSortKeys = map[string]bool{"date": true, "cost": true, "name": true, "category": true}
 )
 
 type TransactionQuerier interface {
@@ -15,8 +42,42 @@ type TransactionQuerier interface {
 	GetTransactionByID(ctx context.Context, id int64) (database.Transaction, error)
 	GetTransactionByName(ctx context.Context, name string) ([]database.Transaction, error)
 	GetTransactionByCategoryID(ctx context.Context, categoryID int64) ([]database.Transaction, error) // Fixed typo: cetegoryID -> categoryID
-	DeleteTransaction(ctx context.Context, id int64) error
-	InsertTransaction(ctx context.Context, params database.InsertTransactionParams) error
+	GetTransactionsPage(ctx context.Context, arg database.GetTransactionsPageParams) ([]database.Transaction, error)
+	CountTransactions(ctx context.Context, arg database.CountTransactionsParams) (int64, error)
+	GetAllCategories(ctx context.Context) ([]database.Category, error)
+	GetCategoriesByIDs(ctx context.Context, ids []int64) ([]database.Category, error)
+	GetTransactionTags(ctx context.Context, ids []int64) ([]database.GetTransactionTagsRow, error)
+	GetTransactionSplits(ctx context.Context, ids []int64) ([]database.TransactionSplit, error)
+	GetPayeesByIDs(ctx context.Context, ids []int64) ([]database.Payee, error)
+	GetAttachments(ctx context.Context, ids []int64) ([]database.GetAttachmentsRow, error)
+	InTransactionTx(ctx context.Context, fn func(TransactionTxQuerier) error) error
+}
+
+// TransactionTxQuerier is what changing a transaction along with its audit
+// entry needs, and inserting one with its payee, tags and split lines, run in
+// a single database transaction.
+type TransactionTxQuerier interface {
+	GetTransactionByID(ctx context.Context, id int64) (database.Transaction, error)
+	GetTrashedTransactionByID(ctx context.Context, id int64) (database.Transaction, error)
+	CountTrashedTransactionCategories(ctx context.Context, id int64) (int64, error)
+	TrashTransaction(ctx context.Context, arg database.TrashTransactionParams) error
+	RestoreTransaction(ctx context.Context, id int64) error
+	SetTransactionNotes(ctx context.Context, arg database.SetTransactionNotesParams) error
+	InsertTransaction(ctx context.Context, params database.InsertTransactionParams) (int64, error)
+	InsertTransactionSplit(ctx context.Context, arg database.InsertTransactionSplitParams) error
+	GetPayeeIDByAlias(ctx context.Context, normalized string) (int64, error)
+	InsertPayee(ctx context.Context, name string) (int64, error)
+	InsertPayeeAlias(ctx context.Context, arg database.InsertPayeeAliasParams) error
+	GetPayeeByID(ctx context.Context, id int64) (database.Payee, error)
+	EnsureTag(ctx context.Context, name string) error
+	GetTagByName(ctx context.Context, name string) (database.Tag, error)
+	TagTransaction(ctx context.Context, arg database.TagTransactionParams) error
+	GetAllRules(ctx context.Context) ([]database.Rule, error)
+	GetRuleTags(ctx context.Context) ([]database.GetRuleTagsRow, error)
+	GetCategoryByID(ctx context.Context, id int64) (database.Category, error)
+	EnsureRootCategory(ctx context.Context, name string) error
+	GetRootCategoryByName(ctx context.Context, name string) (database.Category, error)
+	InsertAuditEntry(ctx context.Context, arg database.InsertAuditEntryParams) error
 }
 
 func Transaction(queries TransactionQuerier) http.HandlerFunc {
@@ -26,12 +87,25 @@ func Transaction(queries TransactionQuerier) http.HandlerFunc {
 		if req.Method == http.MethodGet {
 			id := req.URL.Query().Get("id")
 			name := req.URL.Query().Get("name")
-			categoryID := req.URL.Query().Get("categoriesid")
+			categoryID := req.URL.Query().Get("categories_id")
+			if categoryID == "" {
+				// Deprecated spelling
+				categoryID = req.URL.Query().Get("categoriesid")
+			}
 			switch {
+			case isPageRequest(req.URL.Query()):
+				params, err := parsePageParams(req.URL.Query())
+				if err != nil {
+					slog.WarnContext(ctx, "invalid transaction page request", "error", err)
+					invalidParam(w, ctx, err.param, err.message)
+					return
+				}
+				getTransactionsPage(w, ctx, queries, params)
 			case id != "":
 				id, err := strconv.ParseInt(id, 10, 64)
 				if err != nil {
-					log.Printf("error in converting id")
+					slog.WarnContext(ctx, "error in converting id", "error", err)
+					invalidParam(w, ctx, "id", "must be an integer")
 					return
 				}
 				getTransactionByID(w, ctx, queries, id)
@@ -40,7 +114,8 @@ func Transaction(queries TransactionQuerier) http.HandlerFunc {
 			case categoryID != "":
 				categoryID, err := strconv.ParseInt(categoryID, 10, 64)
 				if err != nil {
-					log.Printf("error in converting category id")
+					slog.WarnContext(ctx, "error in converting category id", "error", err)
+					invalidParam(w, ctx, "categories_id", "must be an integer")
 					return
 				}
 				getTransactionByCategory(w, ctx, queries, categoryID)
@@ -57,7 +132,8 @@ func Transaction(queries TransactionQuerier) http.HandlerFunc {
 			id := req.URL.Query().Get("id")
 			idNum, err := strconv.ParseInt(id, 10, 64)
 			if err != nil {
-				log.Printf("error in converting id")
+				slog.WarnContext(ctx, "error in converting id", "error", err)
+				invalidParam(w, ctx, "id", "must be an integer")
 				return
 			}
 			deleteTransaction(w, ctx, queries, idNum)
@@ -68,31 +144,31 @@ func Transaction(queries TransactionQuerier) http.HandlerFunc {
 func getAllTransactions(w http.ResponseWriter, ctx context.Context, queries TransactionQuerier) {
 	transactions, err := queries.GetAllTransactions(ctx)
 	if err != nil {
-		log.Printf("error getting the transaction %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(transactions)
-	if err != nil {
-		log.Printf("error encoding transactions %v", err)
-		http.Error(w, "Internal sever error", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "error getting the transactions", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 		return
 	}
+	writeTransactions(w, ctx, queries, transactions)
 }
 
 func getTransactionByID(w http.ResponseWriter, ctx context.Context, queries TransactionQuerier, id int64) {
 	transaction, err := queries.GetTransactionByID(ctx, id)
 	if err != nil {
-		log.Printf("transaction not found with id: %d, error: %v", id, err)
-		http.Error(w, "No transaction found with the given ID", http.StatusNotFound)
+		slog.WarnContext(ctx, "transaction not found", "id", id, "error", err)
+		middleware.Error(w, ctx, http.StatusNotFound, middleware.CodeNotFound, "no transaction found with the given ID")
+		return
+	}
+	resource, err := newTransaction(ctx, queries, transaction)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting the category of the transaction", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(transaction)
+	err = json.NewEncoder(w).Encode(resource)
 	if err != nil {
-		log.Printf("error encoding transaction %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "error encoding transaction", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 		return
 	}
 }
@@ -100,64 +176,300 @@ func getTransactionByID(w http.ResponseWriter, ctx context.Context, queries Tran
 func getTransactionByName(w http.ResponseWriter, ctx context.Context, queries TransactionQuerier, name string) {
 	transactions, err := queries.GetTransactionByName(ctx, name)
 	if err != nil {
-		log.Printf("error getting transactions by name %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "error getting transactions by name", "name", name, "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 		return
 	}
 
 	if len(transactions) == 0 {
-		http.Error(w, "No transactions found with that name", http.StatusNotFound)
+		middleware.Error(w, ctx, http.StatusNotFound, middleware.CodeNotFound, "no transactions found with that name")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(transactions)
+	writeTransactions(w, ctx, queries, transactions)
+}
+
+func getTransactionByCategory(w http.ResponseWriter, ctx context.Context, queries TransactionQuerier, categoryID int64) {
+	transactions, err := queries.GetTransactionByCategoryID(ctx, categoryID)
 	if err != nil {
-		log.Printf("error encoding transactions %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "error getting transactions by category", "categories_id", categoryID, "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 		return
 	}
+
+	writeTransactions(w, ctx, queries, transactions)
 }
 
-func getTransactionByCategory(w http.ResponseWriter, ctx context.Context, queries TransactionQuerier, categoryID int64) {
-	transactions, err := queries.GetTransactionByCategoryID(ctx, categoryID)
+// writeTransactions responds with the transactions as resources.
+func writeTransactions(w http.ResponseWriter, ctx context.Context, queries TransactionQuerier, transactions []database.Transaction) {
+	resources, err := newTransactions(ctx, queries, transactions)
 	if err != nil {
-		log.Printf("error getting transactions with categoryID %d, %v", categoryID, err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "error getting the categories of the transactions", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	err = json.NewEncoder(w).Encode(transactions)
+	err = json.NewEncoder(w).Encode(resources)
 	if err != nil {
-		log.Printf("error encoding transactions %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.ErrorContext(ctx, "error encoding transactions", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+	}
+}
+
+// isPageRequest reports whether the listing asks for paging, sorting,
+// searching or filtering by tag.
+func isPageRequest(query url.Values) bool {
+	for _, param := range []string{"limit", "offset", "sort", "order", "q", "from", "to", "tag"} {
+		if query.Has(param) {
+			return true
+		}
+	}
+	return false
+}
+
+// paramError reports an invalid query parameter.
+type paramError struct {
+	param   string
+	message string
+}
+
+func (e *paramError) Error() string {
+	return e.param + " " + e.message
+}
+
+// parsePageParams reads the limit, offset, sort, order, q, from, to and tag query parameters.
+func parsePageParams(query url.Values) (database.GetTransactionsPageParams, *paramError) {
+	params := database.GetTransactionsPageParams{
+		Pattern:   fuzzyPattern(query.Get("q")),
+		DateFrom:  minTransactionDate,
+		DateTo:    maxTransactionDate,
+		Tag:       strings.TrimSpace(query.Get("tag")),
+		SortKey:   "date",
+		PageLimit: defaultPageLimit,
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || n <= 0 || n > maxPageLimit {
+			return params, &paramError{"limit", fmt.Sprintf("must be between 1 and %d", maxPageLimit)}
+		}
+		params.PageLimit = n
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		n, err := strconv.ParseInt(offset, 10, 64)
+		if err != nil || n < 0 {
+			return params, &paramError{"offset", "must be a non negative integer"}
+		}
+		params.PageOffset = n
+	}
+
+	if sortKey := query.Get("sort"); sortKey != "" {
+		if !transactionSortKeys[sortKey] {
+			return params, &paramError{"sort", "must be one of date, cost, name, category"}
+		}
+		params.SortKey = sortKey
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		params.Descending = true
+	default:
+		return params, &paramError{"order", "must be asc or desc"}
+	}
+
+	if from := query.Get("from"); from != "" {
+		t, err := parseQueryDate(from)
+		if err != nil {
+			return params, &paramError{"from", "must be a YYYY-MM-DD date or an RFC 3339 timestamp"}
+		}
+		params.DateFrom = t
+	}
+
+	if to := query.Get("to"); to != "" {
+		t, err := parseQueryDate(to)
+		if err != nil {
+			return params, &paramError{"to", "must be a YYYY-MM-DD date or an RFC 3339 timestamp"}
+		}
+		params.DateTo = t
+	}
+
+	return params, nil
+}
+
+// parseQueryDate accepts either an RFC 3339 timestamp or a YYYY-MM-DD date.
+func parseQueryDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// parseReportDates reads the from and to dates bounding a report, defaulting
+// to every transaction, and responds with an error when they are not valid.
+func parseReportDates(w http.ResponseWriter, req *http.Request, ctx context.Context) (time.Time, time.Time, bool) {
+	from, to := minTransactionDate, maxTransactionDate
+	if value := req.URL.Query().Get("from"); value != "" {
+		t, err := parseQueryDate(value)
+		if err != nil {
+			invalidParam(w, ctx, "from", "must be a YYYY-MM-DD date or an RFC 3339 timestamp")
+			return from, to, false
+		}
+		from = t
+	}
+	if value := req.URL.Query().Get("to"); value != "" {
+		t, err := parseQueryDate(value)
+		if err != nil {
+			invalidParam(w, ctx, "to", "must be a YYYY-MM-DD date or an RFC 3339 timestamp")
+			return from, to, false
+		}
+		to = t
+	}
+	return from, to, true
+}
+
+// fuzzyPattern turns a search text into a LIKE pattern matching names that
+// contain its characters in order, so "cfe" matches "Coffee".
+func fuzzyPattern(search string) string {
+	var pattern strings.Builder
+	pattern.WriteString("%")
+	for _, r := range strings.TrimSpace(search) {
+		if r == '%' || r == '_' || r == '\\' {
+			pattern.WriteRune('\\')
+		}
+		pattern.WriteRune(r)
+		pattern.WriteString("%")
+	}
+	return pattern.String()
+}
+
+func getTransactionsPage(w http.ResponseWriter, ctx context.Context, queries TransactionQuerier, params database.GetTransactionsPageParams) {
+	total, err := queries.CountTransactions(ctx, database.CountTransactionsParams{
+		Pattern:  params.Pattern,
+		DateFrom: params.DateFrom,
+		DateTo:   params.DateTo,
+		Tag:      params.Tag,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "error counting transactions", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		return
+	}
+
+	transactions, err := queries.GetTransactionsPage(ctx, params)
+	if err != nil {
+		slog.ErrorContext(ctx, "error getting transactions page", "error", err)
+		middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
 		return
 	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	writeTransactions(w, ctx, queries, transactions)
 }
 
 func insertTransaction(w http.ResponseWriter, req *http.Request, ctx context.Context, queries TransactionQuerier) {
-	var transaction database.Transaction
+	var transaction NewTransaction
 	err := json.NewDecoder(req.Body).Decode(&transaction)
 	if err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		middleware.Error(w, ctx, http.StatusBadRequest, middleware.CodeInvalidJSON, "invalid JSON")
 		return
 	}
 
-	if transaction.Name == "" || transaction.Cost <= 0 || transaction.Date.IsZero() {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+	var details []middleware.FieldError
+	if transaction.Name == "" {
+		details = append(details, middleware.FieldError{Field: "name", Message: "is required"})
+	}
+	if transaction.Cost <= 0 {
+		details = append(details, middleware.FieldError{Field: "cost", Message: "must be greater than 0"})
+	}
+	if transaction.Date.IsZero() {
+		details = append(details, middleware.FieldError{Field: "date", Message: "is required"})
+	}
+	if detail, ok := validateNotes(transaction.Notes); !ok {
+		details = append(details, detail)
+	}
+	if len(transaction.Splits) > 0 {
+		splitDetails, err := validateSplits(ctx, queries, transaction)
+		if err != nil {
+			slog.ErrorContext(ctx, "error in validating splits", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+		details = append(details, splitDetails...)
+		// A split transaction is listed under the category of its first line
+		transaction.CategoriesID = transaction.Splits[0].CategoriesID
+	}
+	if len(details) > 0 {
+		validationError(w, ctx, details)
 		return
 	}
 
-	err = queries.InsertTransaction(ctx, database.InsertTransactionParams{
-		Name:         transaction.Name,
-		Cost:         transaction.Cost,
-		Date:         transaction.Date,
-		CategoriesID: transaction.CategoriesID,
+	// The payee, the uncategorized category, the row, its audit entry, tags
+	// and split lines are all written, or none of them
+	var id int64
+	err = queries.InTransactionTx(ctx, func(tx TransactionTxQuerier) error {
+		// Looked up here, so that it cannot be trashed before the insert
+		if transaction.CategoriesID != 0 && len(transaction.Splits) == 0 {
+			_, err := tx.GetCategoryByID(ctx, transaction.CategoriesID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return fieldErrors{{Field: "categories_id", Message: "must be an existing category"}}
+			}
+			if err != nil {
+				return fmt.Errorf("getting the category: %w", err)
+			}
+		}
+
+		payeeID, err := resolvePayee(ctx, tx, transaction)
+		if err != nil {
+			return fmt.Errorf("resolving the payee %q: %w", transaction.Payee, err)
+		}
+		if err := applyRules(ctx, tx, &transaction, payeeID); err != nil {
+			return fmt.Errorf("applying the rules: %w", err)
+		}
+
+		inserted := database.Transaction{
+			Name:         transaction.Name,
+			Cost:         transaction.Cost,
+			Date:         transaction.Date.UTC(),
+			CategoriesID: transaction.CategoriesID,
+			PayeeID:      payeeID,
+			Notes:        transaction.Notes,
+		}
+		id, err = tx.InsertTransaction(ctx, database.InsertTransactionParams{
+			Name:         inserted.Name,
+			Cost:         inserted.Cost,
+			Date:         inserted.Date,
+			CategoriesID: inserted.CategoriesID,
+			PayeeID:      inserted.PayeeID,
+			Notes:        inserted.Notes,
+		})
+		if err != nil {
+			return fmt.Errorf("inserting the transaction: %w", err)
+		}
+		inserted.ID = id
+
+		if err := audit.Record(ctx, tx, audit.EntityTransaction, id, audit.Insert, nil, audit.NewTransaction(inserted)); err != nil {
+			return fmt.Errorf("recording the transaction insert: %w", err)
+		}
+		if err := tagTransaction(ctx, tx, id, transaction.Tags); err != nil {
+			return fmt.Errorf("tagging the transaction: %w", err)
+		}
+		for _, split := range transaction.Splits {
+			err := tx.InsertTransactionSplit(ctx, database.InsertTransactionSplitParams{
+				TransactionID: id,
+				CategoriesID:  split.CategoriesID,
+				Amount:        split.Amount,
+			})
+			if err != nil {
+				return fmt.Errorf("inserting a transaction split: %w", err)
+			}
+		}
+		return nil
 	})
 	if err != nil {
-		log.Printf("error in inserting transaction into db %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		txError(w, ctx, err, "could not insert the transaction", "name", transaction.Name)
 		return
 	}
 
@@ -167,17 +479,224 @@ func insertTransaction(w http.ResponseWriter, req *http.Request, ctx context.Con
 	json.NewEncoder(w).Encode(response)
 }
 
-func deleteTransaction(w http.ResponseWriter, ctx context.Context, queries TransactionQuerier, id int64) {
-	_, err := queries.GetTransactionByID(ctx, id)
+// TransactionNotes replaces the notes of a transaction. Empty notes clear
+// them.
+func TransactionNotes(queries TransactionQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			slog.WarnContext(ctx, "error in converting id", "error", err)
+			invalidParam(w, ctx, "id", "must be an integer")
+			return
+		}
+
+		var notes NewNotes
+		if err := json.NewDecoder(req.Body).Decode(&notes); err != nil {
+			middleware.Error(w, ctx, http.StatusBadRequest, middleware.CodeInvalidJSON, "invalid JSON")
+			return
+		}
+		if detail, ok := validateNotes(notes.Notes); !ok {
+			validationError(w, ctx, []middleware.FieldError{detail})
+			return
+		}
+
+		err = queries.InTransactionTx(ctx, func(tx TransactionTxQuerier) error {
+			transaction, err := tx.GetTransactionByID(ctx, id)
+			if err != nil {
+				slog.WarnContext(ctx, "transaction not found", "id", id, "error", err)
+				return &httpError{http.StatusNotFound, middleware.CodeNotFound, "no transaction found with the given ID"}
+			}
+			err = tx.SetTransactionNotes(ctx, database.SetTransactionNotesParams{Notes: notes.Notes, ID: id})
+			if err != nil {
+				return fmt.Errorf("setting the notes: %w", err)
+			}
+
+			before := audit.NewTransaction(transaction)
+			transaction.Notes = notes.Notes
+			return audit.RecordUpdate(ctx, tx, audit.EntityTransaction, id, before, audit.NewTransaction(transaction))
+		})
+		if err != nil {
+			txError(w, ctx, err, "could not update the notes of the transaction", "id", id)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := map[string]string{"message": "Notes updated successfully"}
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func validateNotes(notes string) (middleware.FieldError, bool) {
+	if utf8.RuneCountInString(notes) > maxNotesLength {
+		return middleware.FieldError{Field: "notes", Message: fmt.Sprintf("must be at most %d characters", maxNotesLength)}, false
+	}
+	return middleware.FieldError{}, true
+}
+
+// resolvePayee finds the payee of a new transaction from its payee field,
+// creating the payee when it does not exist yet. Without one, the name of the
+// transaction is matched against the payee aliases, but no payee is created.
+func resolvePayee(ctx context.Context, queries TransactionTxQuerier, transaction NewTransaction) (sql.NullInt64, error) {
+	name := strings.TrimSpace(transaction.Payee)
+	explicit := normalizePayee(name) != ""
+	if !explicit {
+		name = transaction.Name
+	}
+	if normalizePayee(name) == "" {
+		return sql.NullInt64{}, nil
+	}
+
+	id, err := queries.GetPayeeIDByAlias(ctx, normalizePayee(name))
+	switch {
+	case err == nil:
+		return sql.NullInt64{Int64: id, Valid: true}, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return sql.NullInt64{}, err
+	case !explicit:
+		return sql.NullInt64{}, nil
+	}
+
+	id, err = queries.InsertPayee(ctx, name)
 	if err != nil {
-		log.Printf("no transaction with id %v present", id)
-		http.Error(w, "Status Bad Request", http.StatusBadRequest)
-		return
+		return sql.NullInt64{}, err
 	}
-	err = queries.DeleteTransaction(ctx, id)
+	err = queries.InsertPayeeAlias(ctx, database.InsertPayeeAliasParams{PayeeID: id, Alias: name, Normalized: normalizePayee(name)})
 	if err != nil {
-		log.Printf("can not delete transaction with id %d", id)
-		http.Error(w, "Status Bad Request", http.StatusBadRequest)
-		return
+		return sql.NullInt64{}, err
+	}
+	return sql.NullInt64{Int64: id, Valid: true}, nil
+}
+
+// applyRules runs the rules over a new transaction. Every matching rule adds
+// its tags, and the first one having a category gives it to a transaction sent
+// without one; when no rule does, the transaction goes to the uncategorized
+// category.
+func applyRules(ctx context.Context, queries TransactionTxQuerier, transaction *NewTransaction, payeeID sql.NullInt64) error {
+	rules, err := loadRules(ctx, queries)
+	if err != nil {
+		return err
+	}
+
+	subject := ruleSubject{name: transaction.Name, cost: transaction.Cost, date: transaction.Date}
+	if payeeID.Valid && len(rules) > 0 {
+		payee, err := queries.GetPayeeByID(ctx, payeeID.Int64)
+		if err != nil {
+			return err
+		}
+		subject.payee = payee.Name
+	}
+	if result, ok := matchRules(rules, subject); ok {
+		slog.InfoContext(ctx, "rule matched the transaction", "rule", result.rule.ID, "name", transaction.Name)
+		for _, t := range result.tags {
+			transaction.Tags = append(transaction.Tags, t.Name)
+		}
+		if transaction.CategoriesID == 0 && result.categoriesID.Valid {
+			transaction.CategoriesID = result.categoriesID.Int64
+		}
+	}
+	if transaction.CategoriesID != 0 {
+		return nil
+	}
+
+	// Looked up first, as an ignored insert still uses up an ID
+	category, err := queries.GetRootCategoryByName(ctx, uncategorizedCategory)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := queries.EnsureRootCategory(ctx, uncategorizedCategory); err != nil {
+			return err
+		}
+		category, err = queries.GetRootCategoryByName(ctx, uncategorizedCategory)
+		if err != nil {
+			return err
+		}
+		err = audit.Record(ctx, queries, audit.EntityCategory, category.ID, audit.Insert, nil, audit.NewCategory(category))
+	}
+	if err != nil {
+		return err
+	}
+	transaction.CategoriesID = category.ID
+	return nil
+}
+
+// validateSplits checks the lines of a split transaction: there are at least
+// two, each with an existing category and a positive amount, and they add up
+// to the cost to the cent.
+func validateSplits(ctx context.Context, queries TransactionQuerier, transaction NewTransaction) ([]middleware.FieldError, error) {
+	if len(transaction.Splits) < 2 {
+		return []middleware.FieldError{{Field: "splits", Message: "must have at least 2 lines"}}, nil
+	}
+
+	categories, err := queries.GetAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[int64]bool, len(categories))
+	for _, c := range categories {
+		exists[c.ID] = true
+	}
+
+	var details []middleware.FieldError
+	var cents int64
+	for i, split := range transaction.Splits {
+		if !exists[split.CategoriesID] {
+			details = append(details, middleware.FieldError{Field: fmt.Sprintf("splits[%d].categories_id", i), Message: "must be an existing category"})
+		}
+		if split.Amount <= 0 {
+			details = append(details, middleware.FieldError{Field: fmt.Sprintf("splits[%d].amount", i), Message: "must be greater than 0"})
+		}
+		cents += int64(math.Round(split.Amount * 100))
+	}
+	if len(details) == 0 && cents != int64(math.Round(transaction.Cost*100)) {
+		details = append(details, middleware.FieldError{Field: "splits", Message: "must add up to the cost"})
+	}
+	return details, nil
+}
+
+// tagTransaction links a transaction to the named tags, creating the tags
+// that do not exist yet.
+func tagTransaction(ctx context.Context, queries TransactionTxQuerier, id int64, tags []string) error {
+	for _, name := range tags {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if err := queries.EnsureTag(ctx, name); err != nil {
+			return err
+		}
+		tag, err := queries.GetTagByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		err = queries.TagTransaction(ctx, database.TagTransactionParams{TransactionID: id, TagID: tag.ID})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteTransaction(w http.ResponseWriter, ctx context.Context, queries TransactionQuerier, id int64) {
+	err := queries.InTransactionTx(ctx, func(tx TransactionTxQuerier) error {
+		transaction, err := tx.GetTransactionByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			slog.WarnContext(ctx, "no transaction present", "id", id)
+			return &httpError{http.StatusNotFound, middleware.CodeNotFound, "no transaction found with the given ID"}
+		}
+		if err != nil {
+			return fmt.Errorf("getting transaction: %w", err)
+		}
+		// Kept in the trash, until it is restored or purged
+		trash := database.TrashTransactionParams{
+			DeletedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+			ID:        id,
+		}
+		if err := tx.TrashTransaction(ctx, trash); err != nil {
+			return fmt.Errorf("trashing transaction: %w", err)
+		}
+		return audit.Record(ctx, tx, audit.EntityTransaction, id, audit.Delete, audit.NewTransaction(transaction), nil)
+	})
+	if err != nil {
+		txError(w, ctx, err, "could not delete the transaction", "id", id)
 	}
 }
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"quattrinitrack/audit"
+	"quattrinitrack/database"
+	middleware "quattrinitrack/middlewares"
+	"strconv"
+)
+
+type TrashQuerier interface {
+	TransactionQuerier
+	GetTrashedTransactions(ctx context.Context) ([]database.Transaction, error)
+	GetTrashedCategories(ctx context.Context) ([]database.Category, error)
+	InCategoryTx(ctx context.Context, fn func(CategoryTxQuerier) error) error
+}
+
+// Trash lists the deleted transactions and categories, most recently deleted
+// first, with the time each one will be purged.
+func Trash(queries TrashQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		transactions, err := queries.GetTrashedTransactions(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "error getting the trashed transactions", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+		categories, err := queries.GetTrashedCategories(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "error getting the trashed categories", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+
+		trash, err := newTrash(ctx, queries, transactions, categories)
+		if err != nil {
+			slog.ErrorContext(ctx, "error converting the trash", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(trash); err != nil {
+			slog.ErrorContext(ctx, "error encoding the trash", "error", err)
+			middleware.Error(w, ctx, http.StatusInternalServerError, middleware.CodeInternal, "internal server error")
+		}
+	}
+}
+
+// TransactionRestore takes a transaction out of the trash. Its categories must
+// be restored first.
+func TransactionRestore(queries TrashQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			slog.WarnContext(ctx, "error in converting id", "error", err)
+			invalidParam(w, ctx, "id", "must be an integer")
+			return
+		}
+
+		err = queries.InTransactionTx(ctx, func(tx TransactionTxQuerier) error {
+			transaction, err := tx.GetTrashedTransactionByID(ctx, id)
+			if err != nil {
+				slog.WarnContext(ctx, "transaction not in the trash", "id", id, "error", err)
+				return &httpError{http.StatusNotFound, middleware.CodeNotFound, "no transaction in the trash with the given ID"}
+			}
+
+			trashed, err := tx.CountTrashedTransactionCategories(ctx, id)
+			if err != nil {
+				return fmt.Errorf("counting the trashed categories of the transaction: %w", err)
+			}
+			if trashed > 0 {
+				slog.WarnContext(ctx, "transaction category in the trash", "id", id, "categories", trashed)
+				return &httpError{http.StatusConflict, middleware.CodeConflict,
+					"the category of the transaction is in the trash, restore it first"}
+			}
+
+			if err := tx.RestoreTransaction(ctx, id); err != nil {
+				return fmt.Errorf("restoring the transaction: %w", err)
+			}
+			transaction.DeletedAt = sql.NullTime{}
+			return audit.Record(ctx, tx, audit.EntityTransaction, id, audit.Restore, nil, audit.NewTransaction(transaction))
+		})
+		if err != nil {
+			txError(w, ctx, err, "could not restore the transaction", "id", id)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := map[string]string{"message": "Transaction restored successfully"}
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// CategoryRestore takes a category out of the trash. Its parent must be
+// restored first, and no other category under it may have taken its name.
+func CategoryRestore(queries TrashQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		id, err := strconv.ParseInt(req.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			slog.WarnContext(ctx, "error in converting id", "error", err)
+			invalidParam(w, ctx, "id", "must be an integer")
+			return
+		}
+
+		err = queries.InCategoryTx(ctx, func(tx CategoryTxQuerier) error {
+			category, err := tx.GetTrashedCategoryByID(ctx, id)
+			if err != nil {
+				slog.WarnContext(ctx, "category not in the trash", "id", id, "error", err)
+				return &httpError{http.StatusNotFound, middleware.CodeNotFound, "no category in the trash with the given ID"}
+			}
+
+			if category.ParentID.Valid {
+				if _, err := tx.GetCategoryByID(ctx, category.ParentID.Int64); err != nil {
+					slog.WarnContext(ctx, "parent category in the trash", "id", id, "parent_id", category.ParentID.Int64, "error", err)
+					return &httpError{http.StatusConflict, middleware.CodeConflict,
+						"the parent category is in the trash, restore it first"}
+				}
+			}
+
+			if err := tx.RestoreCategory(ctx, id); err != nil {
+				slog.WarnContext(ctx, "could not restore category", "id", id, "name", category.Name, "error", err)
+				return &httpError{http.StatusConflict, middleware.CodeConflict, "category already exists"}
+			}
+			category.DeletedAt = sql.NullTime{}
+			return audit.Record(ctx, tx, audit.EntityCategory, id, audit.Restore, nil, audit.NewCategory(category))
+		})
+		if err != nil {
+			txError(w, ctx, err, "could not restore the category", "id", id)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		response := map[string]string{"message": "Category restored successfully"}
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"quattrinitrack/database"
+	"quattrinitrack/metrics"
+)
+
+// TxQueries are the queries of the handlers that also need to run some of
+// them in a single database transaction.
+type TxQueries struct {
+	*database.Queries
+	DB *sql.DB
+}
+
+// InCategoryTx runs fn in a database transaction, committed when fn returns
+// nil and rolled back otherwise.
+func (q TxQueries) InCategoryTx(ctx context.Context, fn func(CategoryTxQuerier) error) error {
+	return q.inTx(ctx, func(tx *database.Queries) error { return fn(tx) })
+}
+
+// InTransactionTx runs fn in a database transaction, like InCategoryTx.
+func (q TxQueries) InTransactionTx(ctx context.Context, fn func(TransactionTxQuerier) error) error {
+	return q.inTx(ctx, func(tx *database.Queries) error { return fn(tx) })
+}
+
+// InRuleTx runs fn in a database transaction, like InCategoryTx.
+func (q TxQueries) InRuleTx(ctx context.Context, fn func(RuleTxQuerier) error) error {
+	return q.inTx(ctx, func(tx *database.Queries) error { return fn(tx) })
+}
+
+// InTagTx runs fn in a database transaction, like InCategoryTx.
+func (q TxQueries) InTagTx(ctx context.Context, fn func(TagTxQuerier) error) error {
+	return q.inTx(ctx, func(tx *database.Queries) error { return fn(tx) })
+}
+
+// InPayeeTx runs fn in a database transaction, like InCategoryTx.
+func (q TxQueries) InPayeeTx(ctx context.Context, fn func(PayeeTxQuerier) error) error {
+	return q.inTx(ctx, func(tx *database.Queries) error { return fn(tx) })
+}
+
+func (q TxQueries) inTx(ctx context.Context, fn func(*database.Queries) error) error {
+	tx, err := q.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(database.New(metrics.InstrumentDB(tx))); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,220 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+var fileSink *RotatingFile
+
+// EnableFileSink writes every log record, as JSON lines, to the file at path
+// on top of capturing it.
+func EnableFileSink(path string, opts RotateOptions) error {
+	file, err := OpenRotatingFile(path, opts)
+	if err != nil {
+		return err
+	}
+	fileSink = file
+
+	fileHandler := slog.NewJSONHandler(file, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		// Durations are more readable as text than as nanoseconds
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Value.Kind() == slog.KindDuration {
+				return slog.String(a.Key, a.Value.Duration().String())
+			}
+			return a
+		},
+	})
+
+	slog.SetDefault(slog.New(multiHandler{
+		NewCaptureHandler(capture, slog.LevelDebug),
+		requestHandler{fileHandler},
+	}))
+	return nil
+}
+
+// CloseFileSink flushes and closes the log file, if any.
+func CloseFileSink() error {
+	if fileSink == nil {
+		return nil
+	}
+	err := fileSink.Close()
+	fileSink = nil
+	return err
+}
+
+// multiHandler passes every record to all of its handlers.
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+// requestHandler adds the attributes of the request in the context to the
+// records of a standard handler.
+type requestHandler struct {
+	slog.Handler
+}
+
+func (h requestHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(requestAttrs(ctx)...)
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h requestHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestHandler) WithGroup(name string) slog.Handler {
+	return requestHandler{h.Handler.WithGroup(name)}
+}
+
+// ReadLogFiles calls fn with every entry of the log at path, including the
+// rotated files, from oldest to newest. It stops early when fn returns false.
+func ReadLogFiles(path string, fn func(LogEntry) bool) error {
+	files, err := BackupFiles(path)
+	if err != nil {
+		return err
+	}
+	files = append(files, path)
+
+	for _, name := range files {
+		more, err := readLogFile(name, fn)
+		if errors.Is(err, fs.ErrNotExist) {
+			// Pruned or rotated while reading
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+	return nil
+}
+
+func readLogFile(name string, fn func(LogEntry) bool) (bool, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	var in io.Reader = file
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", name, err)
+		}
+		defer gz.Close()
+		in = gz
+	}
+
+	more, err := ReadLogs(in, fn)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", name, err)
+	}
+	return more, nil
+}
+
+// ReadLogs parses the JSON lines written by the file sink. Lines that are not
+// log records, such as a line cut short by a crash, are skipped.
+func ReadLogs(in io.Reader, fn func(LogEntry) bool) (bool, error) {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		entry, ok := ParseLogLine(scanner.Bytes())
+		if !ok {
+			continue
+		}
+		if !fn(entry) {
+			return false, nil
+		}
+	}
+	return true, scanner.Err()
+}
+
+// ParseLogLine decodes a single JSON line of the log file.
+func ParseLogLine(line []byte) (LogEntry, bool) {
+	var fields map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return LogEntry{}, false
+	}
+
+	var entry LogEntry
+	timestamp, _ := fields[slog.TimeKey].(string)
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return LogEntry{}, false
+	}
+	entry.Timestamp = t.Local()
+
+	level, _ := fields[slog.LevelKey].(string)
+	if err := entry.Level.UnmarshalText([]byte(level)); err != nil {
+		return LogEntry{}, false
+	}
+	entry.Message, _ = fields[slog.MessageKey].(string)
+
+	delete(fields, slog.TimeKey)
+	delete(fields, slog.LevelKey)
+	delete(fields, slog.MessageKey)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		entry.Attrs = append(entry.Attrs, slog.Any(k, fields[k]))
+	}
+
+	return entry, true
+}
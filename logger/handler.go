@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// CaptureHandler is a slog.Handler that stores every record in a LogCapture
+// and, unless suppressed, also prints it to the original output.
+type CaptureHandler struct {
+	capture *LogCapture
+	level   slog.Leveler
+	attrs   []slog.Attr
+	group   string
+	mu      *sync.Mutex
+}
+
+// NewCaptureHandler returns a handler storing records of at least the given level.
+func NewCaptureHandler(capture *LogCapture, level slog.Leveler) *CaptureHandler {
+	return &CaptureHandler{
+		capture: capture,
+		level:   level,
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (h *CaptureHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *CaptureHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, len(h.attrs)+r.NumAttrs()+2)
+	attrs = append(attrs, requestAttrs(ctx)...)
+	attrs = append(attrs, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendAttr(attrs, h.group, a)
+		return true
+	})
+
+	entry := LogEntry{
+		Timestamp: r.Time,
+		Level:     r.Level,
+		Message:   strings.TrimSpace(r.Message),
+		Attrs:     attrs,
+	}
+	h.capture.addLog(entry)
+
+	if h.capture.suppressed() {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := fmt.Fprintln(h.capture.originalOut, entry.String())
+	return err
+}
+
+func (h *CaptureHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	h2.attrs = append(h2.attrs, h.attrs...)
+	for _, a := range attrs {
+		h2.attrs = appendAttr(h2.attrs, h.group, a)
+	}
+	return &h2
+}
+
+func (h *CaptureHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group = qualify(h.group, name)
+	return &h2
+}
+
+// appendAttr flattens groups into dotted keys so entries stay a flat list.
+func appendAttr(attrs []slog.Attr, group string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		prefix := group
+		if a.Key != "" {
+			prefix = qualify(group, a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = appendAttr(attrs, prefix, ga)
+		}
+		return attrs
+	}
+	a.Key = qualify(group, a.Key)
+	return append(attrs, a)
+}
+
+func qualify(group, key string) string {
+	if group == "" {
+		return key
+	}
+	return group + "." + key
+}
+
+// String formats the entry on a single line, as printed to the console.
+func (e LogEntry) String() string {
+	var s strings.Builder
+	s.WriteString(e.Timestamp.Format("2006/01/02 15:04:05"))
+	s.WriteString(" ")
+	s.WriteString(e.Level.String())
+	s.WriteString(" ")
+	s.WriteString(e.Message)
+	for _, a := range e.Attrs {
+		s.WriteString(" ")
+		s.WriteString(a.String())
+	}
+	return s.String()
+}
+
+// Attr returns the value of the attribute with the given key.
+func (e LogEntry) Attr(key string) (slog.Value, bool) {
+	for _, a := range e.Attrs {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return slog.Value{}, false
+}
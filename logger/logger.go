@@ -4,9 +4,8 @@ package logger
 import (
 	"io"
 	"log"
+	"log/slog"
 	"os"
-	"regexp"
-	"strings"
 	"sync"
 	"time"
 )
@@ -17,18 +16,18 @@ type LogCapture struct {
 	suppressLogs bool
 	maxLogs      int
 	originalOut  io.Writer
+	// version changes whenever logs are added or cleared
+	version uint64
 }
 
 type LogEntry struct {
 	Timestamp time.Time
-	Level     string
+	Level     slog.Level
 	Message   string
+	Attrs     []slog.Attr
 }
 
-var (
-	capture *LogCapture
-	once    sync.Once
-)
+var capture *LogCapture
 
 func init() {
 	capture = &LogCapture{
@@ -39,72 +38,17 @@ func init() {
 	}
 }
 
-// CustomWriter intercepts log output and stores it
-type CustomWriter struct {
-	originalWriter io.Writer
-}
-
-func (cw *CustomWriter) Write(p []byte) (n int, err error) {
-	logMessage := string(p)
-
-	// Parse the log message to extract level and clean message
-	level, message := parseLogMessage(logMessage)
-
-	// Always store the log entry
-	capture.addLog(level, message)
-
-	// Only write to original output if not suppressed
-	if !capture.suppressLogs {
-		return cw.originalWriter.Write(p)
-	}
-	// Return the length as if we wrote it (to satisfy io.Writer interface)
-	return len(p), nil
-}
-
-// parseLogMessage attempts to parse the log message and extract level and clean message
-func parseLogMessage(logMessage string) (string, string) {
-	// Remove timestamp prefix that Go's standard logger adds
-	// Pattern: "2006/01/02 15:04:05 message"
-	timestampPattern := regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} `)
-	cleanMessage := timestampPattern.ReplaceAllString(logMessage, "")
-
-	// Trim whitespace and newlines
-	cleanMessage = strings.TrimSpace(cleanMessage)
-
-	// Determine log level based on content
-	level := "INFO"
-	lowerMessage := strings.ToLower(cleanMessage)
-
-	if strings.Contains(lowerMessage, "error") ||
-		strings.Contains(lowerMessage, "failed") ||
-		strings.Contains(lowerMessage, "panic") {
-		level = "ERROR"
-	} else if strings.Contains(lowerMessage, "warning") ||
-		strings.Contains(lowerMessage, "warn") {
-		level = "WARN"
-	} else if strings.Contains(lowerMessage, "debug") {
-		level = "DEBUG"
-	}
-
-	return level, cleanMessage
-}
-
-func (l *LogCapture) addLog(level, message string) {
+func (l *LogCapture) addLog(entry LogEntry) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	// Skip empty messages
-	if message == "" {
+	if entry.Message == "" {
 		return
 	}
 
-	entry := LogEntry{
-		Timestamp: time.Now(),
-		Level:     level,
-		Message:   message,
-	}
-
 	l.logs = append(l.logs, entry)
+	l.version++
 
 	// Keep only the last maxLogs entries
 	if len(l.logs) > l.maxLogs {
@@ -112,12 +56,16 @@ func (l *LogCapture) addLog(level, message string) {
 	}
 }
 
-// SetupLogCapture replaces the standard log output with our custom writer
+func (l *LogCapture) suppressed() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.suppressLogs
+}
+
+// SetupLogCapture makes a CaptureHandler the default slog handler. Output of
+// the standard log package is routed through it at INFO level.
 func SetupLogCapture() {
-	customWriter := &CustomWriter{originalWriter: capture.originalOut}
-	log.SetOutput(customWriter)
-	// Remove timestamp from Go's standard logger since we add our own
-	log.SetFlags(0)
+	slog.SetDefault(slog.New(NewCaptureHandler(capture, slog.LevelDebug)))
 }
 
 // SetSuppress controls whether logs are displayed in console
@@ -141,11 +89,20 @@ func ClearLogs() {
 	capture.mu.Lock()
 	defer capture.mu.Unlock()
 	capture.logs = make([]LogEntry, 0)
+	capture.version++
+}
+
+// Version returns a counter that changes whenever the captured logs change, so
+// callers can skip rendering an unchanged log.
+func Version() uint64 {
+	capture.mu.RLock()
+	defer capture.mu.RUnlock()
+	return capture.version
 }
 
 // RestoreOriginalOutput restores the original log output (for cleanup)
 func RestoreOriginalOutput() {
-	log.SetOutput(capture.originalOut)
+	slog.SetDefault(slog.New(slog.NewTextHandler(capture.originalOut, nil)))
 	log.SetFlags(log.LstdFlags) // Restore default flags
 }
 
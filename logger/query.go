@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// LogQuery selects captured entries. The zero value matches every entry of
+// at least the Info level, the zero slog.Level; set MinLevel to
+// slog.LevelDebug to match them all.
+type LogQuery struct {
+	// MinLevel is the lowest level included
+	MinLevel slog.Level
+	// Since excludes entries older than it, when set
+	Since time.Time
+	// Text must appear, ignoring case, in the message or an attribute
+	Text string
+}
+
+// Matches reports whether the entry is selected by the query.
+func (q LogQuery) Matches(entry LogEntry) bool {
+	if entry.Level < q.MinLevel {
+		return false
+	}
+	if !q.Since.IsZero() && entry.Timestamp.Before(q.Since) {
+		return false
+	}
+	if q.Text == "" {
+		return true
+	}
+
+	text := strings.ToLower(q.Text)
+	if strings.Contains(strings.ToLower(entry.Message), text) {
+		return true
+	}
+	for _, a := range entry.Attrs {
+		if strings.Contains(strings.ToLower(a.String()), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryLogs returns the captured entries matching q, copying only those
+// rather than the whole log.
+func QueryLogs(q LogQuery) []LogEntry {
+	capture.mu.RLock()
+	defer capture.mu.RUnlock()
+
+	var logs []LogEntry
+	for _, entry := range capture.logs {
+		if q.Matches(entry) {
+			logs = append(logs, entry)
+		}
+	}
+	return logs
+}
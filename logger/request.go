@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+type requestInfoKey struct{}
+
+// RequestInfo describes the HTTP request a log line was written for. The
+// middlewares fill it in as the request goes through them.
+type RequestInfo struct {
+	RequestID string
+	UserID    int64
+	Route     string
+}
+
+// WithRequestInfo returns a context carrying info, so that every log line
+// written with it is tagged with the request attributes.
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext returns the request info stored in ctx, or nil.
+func RequestInfoFromContext(ctx context.Context) *RequestInfo {
+	if ctx == nil {
+		return nil
+	}
+	info, _ := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info
+}
+
+func requestAttrs(ctx context.Context) []slog.Attr {
+	info := RequestInfoFromContext(ctx)
+	if info == nil {
+		return nil
+	}
+
+	var attrs []slog.Attr
+	if info.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", info.RequestID))
+	}
+	if info.UserID != 0 {
+		attrs = append(attrs, slog.Int64("user_id", info.UserID))
+	}
+	return attrs
+}
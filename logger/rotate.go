@@ -0,0 +1,295 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeLayout is the timestamp added to the name of rotated files. It
+// sorts in chronological order.
+const backupTimeLayout = "20060102T150405.000"
+
+// RotateOptions controls when a RotatingFile is rotated and how many of the
+// old files are kept. Zero values disable the matching limit.
+type RotateOptions struct {
+	MaxSize     int64
+	RotateEvery time.Duration
+	MaxAge      time.Duration
+	MaxBackups  int
+	Compress    bool
+}
+
+// RotatingFile is an io.Writer appending to a file that is moved aside once
+// it grows too big or too old. Rotated files are named after the active one
+// with a timestamp, e.g. app-20240102T150405.000.log, and optionally gzipped.
+type RotatingFile struct {
+	mu     sync.Mutex
+	path   string
+	opts   RotateOptions
+	file   *os.File
+	size   int64
+	opened time.Time
+
+	// cleanup runs compression and pruning in the background
+	cleanup sync.WaitGroup
+}
+
+// OpenRotatingFile opens the log file at path for appending, creating it and
+// its directory when needed.
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, opts: opts}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	r.file = file
+	r.size = info.Size()
+	// A file left by a previous run keeps its age, so restarting does not
+	// postpone the rotation
+	r.opened = time.Now()
+	if r.size > 0 {
+		r.opened = info.ModTime()
+	}
+	return nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if r.size > 0 && r.shouldRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) shouldRotate(next int64) bool {
+	if r.opts.MaxSize > 0 && r.size+next > r.opts.MaxSize {
+		return true
+	}
+	return r.opts.RotateEvery > 0 && time.Since(r.opened) >= r.opts.RotateEvery
+}
+
+// rotate moves the active file aside and starts a new one.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	ext := filepath.Ext(r.path)
+	base := strings.TrimSuffix(r.path, ext) + "-" + time.Now().Format(backupTimeLayout)
+	backup := base + ext
+	// Never overwrite a file rotated within the same millisecond
+	for i := 1; exists(backup) || exists(backup+".gz"); i++ {
+		backup = fmt.Sprintf("%s.%d%s", base, i, ext)
+	}
+	if err := os.Rename(r.path, backup); err != nil {
+		return err
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+
+	r.cleanup.Add(1)
+	go func() {
+		defer r.cleanup.Done()
+		if r.opts.Compress {
+			// A failed compression leaves the plain file in place, which is
+			// still readable
+			_ = compressFile(backup)
+		}
+		r.prune()
+	}()
+	return nil
+}
+
+// Close closes the active file and waits for pending compressions.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	r.mu.Unlock()
+
+	r.cleanup.Wait()
+	return err
+}
+
+// prune removes the rotated files exceeding MaxAge or MaxBackups.
+func (r *RotatingFile) prune() {
+	backups, err := BackupFiles(r.path)
+	if err != nil {
+		return
+	}
+
+	// Newest first, so the ones to keep come before the cut
+	slices.Reverse(backups)
+	for i, backup := range backups {
+		expired := r.opts.MaxBackups > 0 && i >= r.opts.MaxBackups
+		if !expired && r.opts.MaxAge > 0 {
+			info, err := os.Stat(backup)
+			expired = err == nil && time.Since(info.ModTime()) > r.opts.MaxAge
+		}
+		if expired {
+			os.Remove(backup)
+		}
+	}
+}
+
+// BackupFiles lists the rotated files of the log at path, oldest first.
+// Only the names made by rotate are listed, so app-debug.log is not taken
+// for a rotated file of app.log.
+func BackupFiles(path string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(filepath.Base(path), ext) + "-"
+	type backup struct {
+		name    string
+		rotated time.Time
+		seq     int
+	}
+	var backups []backup
+	for _, entry := range entries {
+		rotated, seq, ok := parseBackupName(entry.Name(), prefix, ext)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		name := filepath.Join(filepath.Dir(path), entry.Name())
+		// A compression still running leaves both the plain and the gzipped
+		// file around; skip the partial one
+		if strings.HasSuffix(name, ".gz") && exists(strings.TrimSuffix(name, ".gz")) {
+			continue
+		}
+		backups = append(backups, backup{name: name, rotated: rotated, seq: seq})
+	}
+
+	// Files rotated within the same millisecond are told apart by their
+	// sequence number, the first one having none
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].rotated.Equal(backups[j].rotated) {
+			return backups[i].rotated.Before(backups[j].rotated)
+		}
+		return backups[i].seq < backups[j].seq
+	})
+	names := make([]string, 0, len(backups))
+	for _, b := range backups {
+		names = append(names, b.name)
+	}
+	return names, nil
+}
+
+// parseBackupName reads the time a file was rotated at, and its sequence
+// number, from a name such as app-20240102T150405.000.1.log.gz. It reports
+// false for any other name.
+func parseBackupName(name, prefix, ext string) (time.Time, int, bool) {
+	rest, ok := strings.CutPrefix(name, prefix)
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	rest = strings.TrimSuffix(rest, ".gz")
+	if rest, ok = strings.CutSuffix(rest, ext); !ok || len(rest) < len(backupTimeLayout) {
+		return time.Time{}, 0, false
+	}
+
+	rotated, err := time.Parse(backupTimeLayout, rest[:len(backupTimeLayout)])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	suffix := rest[len(backupTimeLayout):]
+	if suffix == "" {
+		return rotated, 0, true
+	}
+	digits, ok := strings.CutPrefix(suffix, ".")
+	if !ok || strings.Trim(digits, "0123456789") != "" {
+		return time.Time{}, 0, false
+	}
+	seq, err := strconv.Atoi(digits)
+	if err != nil || seq < 1 {
+		return time.Time{}, 0, false
+	}
+	return rotated, seq, true
+}
+
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
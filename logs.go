@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"quattrinitrack/logger"
+	"strings"
+	"time"
+)
+
+const logsUsage = `usage:
+  quattrinitrack logs tail [-n lines] [-f]
+  quattrinitrack logs search [-level level] [-since duration] [text...]`
+
+// followInterval is how often logs tail -f checks the file for new lines
+const followInterval = 500 * time.Millisecond
+
+// runLogs implements the logs command, which reads the files written when
+// LOG_FILE is set, including the rotated ones.
+func runLogs(path string, args []string) error {
+	if path == "" {
+		return errors.New("LOG_FILE is not set, logs are not written to file")
+	}
+	if len(args) == 0 {
+		return errors.New(logsUsage)
+	}
+
+	switch args[0] {
+	case "tail":
+		return tailLogs(path, args[1:], os.Stdout)
+	case "search":
+		return searchLogs(path, args[1:], os.Stdout)
+	default:
+		return fmt.Errorf("unknown logs command %q\n%s", args[0], logsUsage)
+	}
+}
+
+// tailLogs prints the last entries of the log and, with -f, keeps printing
+// new ones as they are written.
+func tailLogs(path string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("logs tail", flag.ContinueOnError)
+	lines := flags.Int("n", 20, "number of entries to print")
+	follow := flags.Bool("f", false, "keep printing new entries")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	last := make([]logger.LogEntry, 0, *lines)
+	err := logger.ReadLogFiles(path, func(entry logger.LogEntry) bool {
+		if *lines <= 0 {
+			return true
+		}
+		if len(last) == *lines {
+			last = append(last[:0], last[1:]...)
+		}
+		last = append(last, entry)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range last {
+		fmt.Fprintln(out, entry)
+	}
+
+	if !*follow {
+		return nil
+	}
+	return followLogs(path, out)
+}
+
+// followLogs prints the lines appended to the active log file, starting over
+// when the file is rotated.
+func followLogs(path string, out io.Writer) error {
+	offset := int64(0)
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	var partial []byte
+	for {
+		time.Sleep(followInterval)
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.Size() < offset {
+			// Rotated, the new file is read from the start
+			offset = 0
+			partial = nil
+		}
+		if info.Size() == offset {
+			continue
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			file.Close()
+			return err
+		}
+
+		reader := bufio.NewReader(file)
+		for {
+			line, err := reader.ReadBytes('\n')
+			offset += int64(len(line))
+			if err != nil {
+				// Keep an incomplete line until the rest is written
+				partial = append(partial, line...)
+				break
+			}
+			line = append(partial, line...)
+			partial = nil
+			if entry, ok := logger.ParseLogLine(line); ok {
+				fmt.Fprintln(out, entry)
+			}
+		}
+		file.Close()
+	}
+}
+
+// searchLogs prints the entries containing all the given words, ignoring case.
+func searchLogs(path string, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("logs search", flag.ContinueOnError)
+	levelName := flags.String("level", "debug", "minimum level: debug, info, warn or error")
+	since := flags.Duration("since", 0, "only entries newer than this, e.g. 2h")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*levelName)); err != nil {
+		return fmt.Errorf("invalid level %q", *levelName)
+	}
+
+	var after time.Time
+	if *since > 0 {
+		after = time.Now().Add(-*since)
+	}
+
+	words := make([]string, 0, flags.NArg())
+	for _, word := range flags.Args() {
+		words = append(words, strings.ToLower(word))
+	}
+
+	return logger.ReadLogFiles(path, func(entry logger.LogEntry) bool {
+		if entry.Level < level || entry.Timestamp.Before(after) {
+			return true
+		}
+
+		line := entry.String()
+		text := strings.ToLower(line)
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				return true
+			}
+		}
+
+		fmt.Fprintln(out, line)
+		return true
+	})
+}
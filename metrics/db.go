@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"quattrinitrack/database"
+	"strings"
+	"time"
+)
+
+// instrumentedDB times the queries run through it.
+type instrumentedDB struct {
+	db database.DBTX
+}
+
+// InstrumentDB wraps db so that the duration of every query is recorded.
+func InstrumentDB(db database.DBTX) database.DBTX {
+	return instrumentedDB{db: db}
+}
+
+func (i instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer observeQuery(query, time.Now())
+	return i.db.ExecContext(ctx, query, args...)
+}
+
+func (i instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	defer observeQuery(query, time.Now())
+	return i.db.PrepareContext(ctx, query)
+}
+
+func (i instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer observeQuery(query, time.Now())
+	return i.db.QueryContext(ctx, query, args...)
+}
+
+func (i instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer observeQuery(query, time.Now())
+	return i.db.QueryRowContext(ctx, query, args...)
+}
+
+func observeQuery(query string, start time.Time) {
+	dbDuration.WithLabelValues(queryName(query)).Observe(time.Since(start).Seconds())
+}
+
+// queryName returns the name sqlc puts at the start of every query, e.g.
+// "-- name: GetCategoryByID :one", so that labels stay few.
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "other"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
@@ -0,0 +1,68 @@
+// Package metrics defines the Prometheus metrics exposed on /metrics
+package metrics
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quattrinitrack_http_requests_total",
+		Help: "HTTP requests served, by route and status code.",
+	}, []string{"route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "quattrinitrack_http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests, by route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "status"})
+
+	dbDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "quattrinitrack_db_query_duration_seconds",
+		Help: "Time taken by database queries, by query name.",
+		// SQLite queries are mostly well below the default buckets
+		Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"query"})
+
+	logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quattrinitrack_logins_total",
+		Help: "Login attempts, by result.",
+	}, []string{"result"})
+)
+
+// ObserveRequest records a served HTTP request.
+func ObserveRequest(route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(route, code).Inc()
+	httpDuration.WithLabelValues(route, code).Observe(duration.Seconds())
+}
+
+// LoginSucceeded counts a successful login.
+func LoginSucceeded() {
+	logins.WithLabelValues("success").Inc()
+}
+
+// LoginFailed counts a login rejected for a wrong email or password.
+func LoginFailed() {
+	logins.WithLabelValues("failure").Inc()
+}
+
+// RegisterDBFile exposes the size of the database file at path, read on
+// every scrape.
+func RegisterDBFile(path string) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "quattrinitrack_db_file_size_bytes",
+		Help: "Size of the SQLite database file.",
+	}, func() float64 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0
+		}
+		return float64(info.Size())
+	})
+}
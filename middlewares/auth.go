@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"quattrinitrack/config"
+	"quattrinitrack/logger"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -13,7 +14,7 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		auth := r.Header.Get("Authorization")
 		if !strings.HasPrefix(auth, "Bearer ") {
-			http.Error(w, "Missing token", http.StatusUnauthorized)
+			Error(w, r.Context(), http.StatusUnauthorized, CodeUnauthorized, "missing token")
 			return
 		}
 		tokenStr := strings.TrimPrefix(auth, "Bearer ")
@@ -22,12 +23,15 @@ func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return config.JWTSecret, nil
 		})
 		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			Error(w, r.Context(), http.StatusUnauthorized, CodeUnauthorized, "invalid token")
 			return
 		}
 
 		claims := token.Claims.(jwt.MapClaims)
 		userID := int64(claims["user_id"].(float64))
+		if info := logger.RequestInfoFromContext(r.Context()); info != nil {
+			info.UserID = userID
+		}
 		ctx := context.WithValue(r.Context(), "userID", userID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// legacyFields maps the fields of the resources served before the /v1 routes
+// to the names they had then. Other fields did not exist yet.
+var legacyFields = map[string]string{
+	"id":            "ID",
+	"name":          "Name",
+	"cost":          "Cost",
+	"date":          "Date",
+	"categories_id": "CategoriesID",
+	"email":         "Email",
+}
+
+// Deprecated marks the responses of a route kept only for old clients with a
+// Deprecation header and a Link to the route replacing it.
+func Deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		slog.InfoContext(r.Context(), "deprecated route used", "path", r.URL.Path, "successor", successor)
+		next(w, r)
+	}
+}
+
+// LegacyResponse gives the resources of a successful response the shape they
+// had before the /v1 routes: capitalized field names, without the fields
+// added since. Other bodies, such as messages and errors, are left as is.
+func LegacyResponse(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rw := &bufferedWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next(rw, r)
+
+		body := rw.body.Bytes()
+		if rw.statusCode >= 200 && rw.statusCode < 300 {
+			var value any
+			if err := json.Unmarshal(body, &value); err == nil {
+				if legacy, ok := legacyResource(value); ok {
+					if encoded, err := json.Marshal(legacy); err == nil {
+						body = append(encoded, '\n')
+						w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+					}
+				}
+			}
+		}
+
+		w.WriteHeader(rw.statusCode)
+		w.Write(body)
+	}
+}
+
+// legacyResource converts a resource, or a list of resources, to its legacy
+// shape. Objects without an id are not resources.
+func legacyResource(value any) (any, bool) {
+	switch value := value.(type) {
+	case map[string]any:
+		if _, ok := value["id"]; !ok {
+			return nil, false
+		}
+		legacy := make(map[string]any)
+		for name, field := range value {
+			if former, ok := legacyFields[name]; ok {
+				legacy[former] = field
+			}
+		}
+		return legacy, true
+	case []any:
+		list := make([]any, 0, len(value))
+		for _, item := range value {
+			legacy, ok := legacyResource(item)
+			if !ok {
+				return nil, false
+			}
+			list = append(list, legacy)
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+// bufferedWriter holds the body and status of a response back, for them to be
+// rewritten before being sent.
+type bufferedWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (bw *bufferedWriter) WriteHeader(code int) {
+	bw.statusCode = code
+}
+
+func (bw *bufferedWriter) Write(b []byte) (int, error) {
+	return bw.body.Write(b)
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Machine-readable error codes, stable across releases.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeInvalidJSON      = "invalid_json"
+	CodeValidation       = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodeInternal         = "internal_error"
+)
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+type APIError struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// FieldError describes why a single field of the request was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error replies with an ErrorResponse. The request ID lets the failure be
+// matched with the server logs.
+func Error(w http.ResponseWriter, ctx context.Context, status int, code, message string, details ...FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error: APIError{
+			Code:      code,
+			Message:   message,
+			Details:   details,
+			RequestID: RequestID(ctx),
+		},
+	})
+}
@@ -2,8 +2,9 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"quattrinitrack/logger"
 	"time"
 )
 
@@ -12,8 +13,17 @@ func RequestLogger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		// Every log line written while serving the request is tagged with it
+		info := &logger.RequestInfo{RequestID: requestID(r)}
+		ctx := logger.WithRequestInfo(r.Context(), info)
+		r = r.WithContext(ctx)
+		w.Header().Set(RequestIDHeader, info.RequestID)
+
 		// Log the incoming request
-		log.Printf("Started %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		slog.DebugContext(ctx, "Started request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"remote", r.RemoteAddr)
 
 		// Create a response writer that captures the status code
 		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
@@ -22,13 +32,47 @@ func RequestLogger(next http.Handler) http.Handler {
 		next.ServeHTTP(rw, r)
 
 		// Log the completion
-		duration := time.Since(start)
-		log.Printf("Completed %s %s - %d %s in %v",
-			r.Method, r.URL.Path, rw.statusCode,
-			http.StatusText(rw.statusCode), duration)
+		level := slog.LevelInfo
+		switch {
+		case rw.statusCode >= 500:
+			level = slog.LevelError
+		case rw.statusCode >= 400:
+			level = slog.LevelWarn
+		}
+		slog.Log(ctx, level, "Completed request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", routeOf(r, info),
+			"status", rw.statusCode,
+			"latency", time.Since(start))
 	})
 }
 
+// RecordRoute stores the pattern of mux matching the request in the request
+// info before calling next. The outer mux can only see the pattern it mounted
+// mux under, and next may reject the request before mux is reached.
+func RecordRoute(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info := logger.RequestInfoFromContext(r.Context()); info != nil {
+			info.Route = "unmatched"
+			if _, pattern := mux.Handler(r); pattern != "" {
+				info.Route = pattern
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func routeOf(r *http.Request, info *logger.RequestInfo) string {
+	if info.Route != "" {
+		return info.Route
+	}
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return "unmatched"
+}
+
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+	"quattrinitrack/logger"
+	"quattrinitrack/metrics"
+	"time"
+)
+
+// Metrics records the count and latency of requests by route and status. It
+// must be wrapped by RequestLogger, which provides the request info.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		info := logger.RequestInfoFromContext(r.Context())
+		if info == nil {
+			info = &logger.RequestInfo{}
+		}
+		metrics.ObserveRequest(routeOf(r, info), rw.statusCode, time.Since(start))
+	})
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"quattrinitrack/logger"
+)
+
+// RequestIDHeader carries the ID tying a request to its log lines.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 64
+
+// requestID returns the ID sent by the client when it is usable, or a new one.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); validRequestID(id) {
+		return id
+	}
+	return newRequestID()
+}
+
+// validRequestID accepts short IDs that are safe to echo in headers and logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestID returns the ID of the request being served with ctx.
+func RequestID(ctx context.Context) string {
+	if info := logger.RequestInfoFromContext(ctx); info != nil {
+		return info.RequestID
+	}
+	return ""
+}
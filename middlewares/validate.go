@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"quattrinitrack/openapi"
+	"strings"
+)
+
+// maxBodySize caps the JSON bodies read for validation.
+const maxBodySize = 1 << 20
+
+// ValidateBody rejects requests whose JSON body does not match the schema the
+// OpenAPI document gives for the operation. Handlers of operations without a
+// body schema are returned unchanged. Fields sent under a former name are
+// renamed before the handler sees them, and flagged with a Warning header.
+func ValidateBody(doc *openapi.Document, method, path string, next http.HandlerFunc) http.HandlerFunc {
+	schema := doc.BodySchema(method, path)
+	if schema == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				Error(w, r.Context(), http.StatusRequestEntityTooLarge, CodeBadRequest, "request body too large")
+				return
+			}
+			Error(w, r.Context(), http.StatusBadRequest, CodeBadRequest, "could not read the request body")
+			return
+		}
+
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil || decoder.More() {
+			Error(w, r.Context(), http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+			return
+		}
+
+		// Former field names are accepted for a while, under their current
+		// name for the handler
+		renamed := doc.Normalize(schema, value)
+
+		if violations := doc.Validate(schema, value); len(violations) > 0 {
+			details := make([]FieldError, 0, len(violations))
+			message := "invalid JSON:"
+			for i, v := range violations {
+				details = append(details, FieldError{Field: v.Field, Message: v.Message})
+				if i > 0 {
+					message += ";"
+				}
+				message += " " + v.Field + " " + v.Message
+			}
+			Error(w, r.Context(), http.StatusBadRequest, CodeValidation, message, details...)
+			return
+		}
+
+		if len(renamed) > 0 {
+			slog.InfoContext(r.Context(), "deprecated fields used", "fields", renamed)
+			w.Header().Set("Warning", `299 - "deprecated field names: `+strings.Join(renamed, ", ")+`"`)
+			if body, err = json.Marshal(value); err != nil {
+				Error(w, r.Context(), http.StatusInternalServerError, CodeInternal, "internal server error")
+				return
+			}
+		}
+
+		// The handler decodes the body again
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next(w, r)
+	}
+}
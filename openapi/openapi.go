@@ -0,0 +1,81 @@
+// Package openapi embeds the OpenAPI document of the API and validates
+// request bodies against it
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// Document is the part of an OpenAPI 3.1 document the server relies on.
+type Document struct {
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type Operation struct {
+	Summary     string       `json:"summary"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+var (
+	loadOnce sync.Once
+	document *Document
+	loadErr  error
+)
+
+// Load parses the embedded document. It is parsed once and shared.
+func Load() (*Document, error) {
+	loadOnce.Do(func() {
+		document = &Document{}
+		loadErr = json.Unmarshal(spec, document)
+	})
+	return document, loadErr
+}
+
+// Handler serves the embedded document.
+func Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec)
+	}
+}
+
+// Endpoints lists the operations of the document as "METHOD /path", the form
+// of the router patterns.
+func (d *Document) Endpoints() []string {
+	var endpoints []string
+	for path, operations := range d.Paths {
+		for method := range operations {
+			endpoints = append(endpoints, strings.ToUpper(method)+" "+path)
+		}
+	}
+	return endpoints
+}
+
+// BodySchema returns the schema of the JSON body of an operation, or nil when
+// the operation takes no JSON body.
+func (d *Document) BodySchema(method, path string) *Schema {
+	operation := d.Paths[path][strings.ToLower(method)]
+	if operation == nil || operation.RequestBody == nil {
+		return nil
+	}
+	return operation.RequestBody.Content["application/json"].Schema
+}
@@ -0,0 +1,245 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema used by the request bodies.
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	MinLength            *int               `json:"minLength"`
+	Minimum              *float64           `json:"minimum"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum"`
+	Maximum              *float64           `json:"maximum"`
+
+	// Aliases are former names of a property, still accepted in requests
+	Aliases []string `json:"x-aliases"`
+}
+
+// Violation is a value that does not match its schema.
+type Violation struct {
+	Field   string
+	Message string
+}
+
+// Validate checks a value decoded with json.Decoder.UseNumber against the
+// schema. Object keys are matched to properties ignoring case, as
+// encoding/json does when decoding into a struct, or to their aliases.
+func (d *Document) Validate(schema *Schema, value any) []Violation {
+	var violations []Violation
+	d.validate(schema, value, "", &violations)
+	return violations
+}
+
+func (d *Document) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		schema = d.Components.Schemas[name]
+	}
+	return schema
+}
+
+func (d *Document) validate(schema *Schema, value any, field string, violations *[]Violation) {
+	schema = d.resolve(schema)
+	if schema == nil {
+		return
+	}
+	fail := func(format string, args ...any) {
+		*violations = append(*violations, Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		d.validateObject(schema, object, field, violations)
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		for i, item := range items {
+			d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", field, i), violations)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if schema.MinLength != nil && len([]rune(s)) < *schema.MinLength {
+			if *schema.MinLength == 1 {
+				fail("must not be empty")
+			} else {
+				fail("must be at least %d characters", *schema.MinLength)
+			}
+			return
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				fail("must be an RFC 3339 date-time")
+				return
+			}
+		}
+	case "number", "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			fail("must be a %s", schema.Type)
+			return
+		}
+		if schema.Type == "integer" {
+			if _, err := n.Int64(); err != nil {
+				fail("must be an integer")
+				return
+			}
+		}
+		f, err := n.Float64()
+		if err != nil {
+			fail("must be a %s", schema.Type)
+			return
+		}
+		switch {
+		case schema.Minimum != nil && f < *schema.Minimum:
+			fail("must be at least %v", *schema.Minimum)
+		case schema.ExclusiveMinimum != nil && f <= *schema.ExclusiveMinimum:
+			fail("must be greater than %v", *schema.ExclusiveMinimum)
+		case schema.Maximum != nil && f > *schema.Maximum:
+			fail("must be at most %v", *schema.Maximum)
+		}
+		return
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+			return
+		}
+	}
+
+	if len(schema.Enum) > 0 {
+		for _, allowed := range schema.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				return
+			}
+		}
+		fail("must be one of %v", schema.Enum)
+	}
+}
+
+func (d *Document) validateObject(schema *Schema, object map[string]any, field string, violations *[]Violation) {
+	// Match the keys to the properties the way encoding/json would
+	matched := make(map[string]any, len(object))
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name, ok := propertyName(schema, key)
+		if !ok {
+			if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+				*violations = append(*violations, Violation{Field: join(field, key), Message: "is not allowed"})
+			}
+			continue
+		}
+		matched[name] = object[key]
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := matched[name]; !ok {
+			*violations = append(*violations, Violation{Field: join(field, name), Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(matched))
+	for name := range matched {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d.validate(schema.Properties[name], matched[name], join(field, name), violations)
+	}
+}
+
+// Normalize renames the object keys of a value to the names of the properties
+// they match, and returns the keys renamed. Clients still sending the former
+// capitalized or aliased names are then understood by the handlers. A key is
+// dropped when its property is also given under its current name.
+func (d *Document) Normalize(schema *Schema, value any) []string {
+	var renamed []string
+	d.normalize(schema, value, "", &renamed)
+	return renamed
+}
+
+func (d *Document) normalize(schema *Schema, value any, field string, renamed *[]string) {
+	schema = d.resolve(schema)
+	if schema == nil {
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			name, ok := propertyName(schema, key)
+			if !ok {
+				continue
+			}
+			if name != key {
+				if _, taken := value[name]; !taken {
+					value[name] = value[key]
+				}
+				delete(value, key)
+				*renamed = append(*renamed, join(field, key))
+			}
+			d.normalize(schema.Properties[name], value[name], join(field, name), renamed)
+		}
+	case []any:
+		for i, item := range value {
+			d.normalize(schema.Items, item, fmt.Sprintf("%s[%d]", field, i), renamed)
+		}
+	}
+}
+
+func propertyName(schema *Schema, key string) (string, bool) {
+	if _, ok := schema.Properties[key]; ok {
+		return key, true
+	}
+	for name, property := range schema.Properties {
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+		for _, alias := range property.Aliases {
+			if strings.EqualFold(alias, key) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
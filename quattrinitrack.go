@@ -3,14 +3,15 @@ package main
 import (
 	"context"
 	"database/sql"
-	_ "embed"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"quattrinitrack/config"
 	"quattrinitrack/database"
 	"quattrinitrack/logger"
+	"quattrinitrack/metrics"
 	"quattrinitrack/router"
 	"quattrinitrack/tui"
 	"syscall"
@@ -19,11 +20,11 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-//go:embed database/SQL/schema.sql
-var createTables string
+const dbFile = "db.sqlite"
 
 func initDB(ctx context.Context) *sql.DB {
-	db, err := sql.Open("sqlite", "db.sqlite?_foreign_keys=on")
+	// The pragma is run on every new connection of the pool
+	db, err := sql.Open("sqlite", dbFile+"?_pragma=foreign_keys(1)")
 	if err != nil {
 		panic(err)
 	}
@@ -32,11 +33,6 @@ func initDB(ctx context.Context) *sql.DB {
 		panic(err)
 	}
 
-	_, err = db.ExecContext(ctx, "PRAGMA foreign_keys = ON")
-	if err != nil {
-		panic(err)
-	}
-
 	// Verify foreign keys are enabled
 	var fkEnabled int
 	err = db.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&fkEnabled)
@@ -47,8 +43,7 @@ func initDB(ctx context.Context) *sql.DB {
 		panic("Foreign key constraints are not enabled")
 	}
 
-	_, err = db.ExecContext(ctx, createTables)
-	if err != nil {
+	if err := database.Migrate(ctx, db); err != nil {
 		panic(err)
 	}
 
@@ -58,19 +53,68 @@ func initDB(ctx context.Context) *sql.DB {
 func main() {
 	logger.SetupLogCapture()
 
+	logConfig, err := config.LoadLogConfig()
+	if err != nil {
+		slog.Error("Invalid log config", "error", err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "logs" {
+		if err := runLogs(logConfig.File, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	config.LoadEnv()
 
+	if logConfig.File != "" {
+		err := logger.EnableFileSink(logConfig.File, logger.RotateOptions{
+			MaxSize:     logConfig.MaxSize,
+			RotateEvery: logConfig.RotateEvery,
+			MaxAge:      logConfig.MaxAge,
+			MaxBackups:  logConfig.MaxBackups,
+			Compress:    logConfig.Compress,
+		})
+		if err != nil {
+			slog.Error("Error opening log file", "error", err)
+			os.Exit(1)
+		}
+		defer logger.CloseFileSink()
+	}
+
+	if err := config.LoadTrashConfig(); err != nil {
+		slog.Error("Invalid trash config", "error", err)
+		os.Exit(1)
+	}
+
+	tuiConfig, err := config.LoadTUIConfig()
+	if err != nil {
+		slog.Error("Error loading TUI config", "error", err)
+		os.Exit(1)
+	}
+	if err := tui.Configure(tuiConfig); err != nil {
+		slog.Error("Invalid TUI config", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize database
 	ctx := context.Background()
 	db := initDB(ctx)
 	defer db.Close()
 
-	queries := database.New(db)
+	queries := database.New(metrics.InstrumentDB(db))
+	metrics.RegisterDBFile(dbFile)
+
+	purgeCtx, stopPurge := context.WithCancel(ctx)
+	defer stopPurge()
+	go purgeTrash(purgeCtx, db)
 
 	// Create HTTP server
 	server := &http.Server{
 		Addr:    ":8080",
-		Handler: router.New(queries),
+		Handler: router.New(db, queries),
 	}
 
 	// Channel to handle server shutdown
@@ -78,9 +122,9 @@ func main() {
 
 	// Start the server in a goroutine
 	go func() {
-		log.Println("Server started on localhost:8080")
+		slog.Info("Server started", "addr", "localhost:8080")
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("Server failed to start: %v", err)
+			slog.Error("Server failed to start", "error", err)
 			serverDone <- err
 		} else {
 			serverDone <- nil
@@ -102,39 +146,39 @@ func main() {
 
 	select {
 	case err := <-tuiDone:
-		log.Println("TUI shutting down...")
+		slog.Info("TUI shutting down...")
 		if err != nil {
-			log.Printf("TUI error: %v", err)
+			slog.Error("TUI error", "error", err)
 		}
 
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
 		if err := server.Shutdown(shutdownCtx); err != nil {
-			log.Printf("Server forced to shutdown: %v", err)
+			slog.Error("Server forced to shutdown", "error", err)
 		} else {
-			log.Println("Server shutdown gracefully")
+			slog.Info("Server shutdown gracefully")
 		}
 
 	case err := <-serverDone:
 		if err != nil {
-			log.Printf("Server error: %v", err)
+			slog.Error("Server error", "error", err)
 		}
-		log.Println("Server stopped")
+		slog.Info("Server stopped")
 
 	case sig := <-quit:
 		// Received shutdown signal
-		log.Printf("Received signal: %v", sig)
+		slog.Info("Received signal", "signal", sig)
 
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
 		if err := server.Shutdown(shutdownCtx); err != nil {
-			log.Printf("Server forced to shutdown: %v", err)
+			slog.Error("Server forced to shutdown", "error", err)
 		} else {
-			log.Println("Server shutdown gracefully")
+			slog.Info("Server shutdown gracefully")
 		}
 	}
 
-	log.Println("Application shutting down...")
+	slog.Info("Application shutting down...")
 }
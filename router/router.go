@@ -2,32 +2,183 @@
 package router
 
 import (
+	"database/sql"
+	"fmt"
 	"net/http"
 	"quattrinitrack/database"
 	"quattrinitrack/handlers"
 	middleware "quattrinitrack/middlewares"
+	"quattrinitrack/openapi"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func New(queries *database.Queries) http.Handler {
-	mux := http.NewServeMux()
+// apiVersion prefixes the paths of the versioned routes.
+const apiVersion = "/v1"
+
+// legacyPaths were served without a version before apiVersion was added.
+// They remain deprecated aliases of the versioned routes.
+var legacyPaths = map[string]bool{
+	"/register":    true,
+	"/login":       true,
+	"/transaction": true,
+	"/category":    true,
+	"/me":          true,
+}
 
-	// Public routes
-	mux.HandleFunc("POST /register", handlers.Register(queries))
-	mux.HandleFunc("POST /login", handlers.Login(queries))
+type route struct {
+	method    string
+	path      string
+	versioned bool
+	protected bool
+	handler   http.HandlerFunc
+}
+
+// canonicalPath is the path a route is documented under.
+func (r route) canonicalPath() string {
+	if r.versioned {
+		return apiVersion + r.path
+	}
+	return r.path
+}
+
+func (r route) pattern() string {
+	return r.method + " " + r.canonicalPath()
+}
+
+// routes lists every endpoint. Each one must be described in the OpenAPI
+// document, which the tests check. Versioned routes are served under
+// apiVersion and, when in legacyPaths, at their bare path too.
+func routes(db *sql.DB, queries *database.Queries) []route {
+	// Changes spanning several rows, such as a change and its audit entry,
+	// are made in a database transaction
+	txQueries := handlers.TxQueries{Queries: queries, DB: db}
+
+	return []route{
+		// Public routes
+		{"POST", "/register", true, false, handlers.Register(queries)},
+		{"POST", "/login", true, false, handlers.Login(queries)},
+		{"GET", "/healthz", false, false, handlers.Health()},
+		{"GET", "/readyz", false, false, handlers.Ready(db)},
+		{"GET", "/metrics", false, false, promhttp.Handler().ServeHTTP},
+		{"GET", "/openapi.json", false, false, openapi.Handler()},
+
+		// Protected routes
+		{"GET", "/transaction", true, true, handlers.Transaction(txQueries)},
+		{"POST", "/transaction", true, true, handlers.Transaction(txQueries)},
+		{"DELETE", "/transaction", true, true, handlers.Transaction(txQueries)},
+		{"PUT", "/transaction/notes", true, true, handlers.TransactionNotes(txQueries)},
+		{"POST", "/transaction/restore", true, true, handlers.TransactionRestore(txQueries)},
+		{"GET", "/transaction/history", true, true, handlers.TransactionHistory(queries)},
+		{"GET", "/transaction/attachment", true, true, handlers.Attachment(queries)},
+		{"POST", "/transaction/attachment", true, true, handlers.Attachment(queries)},
+		{"DELETE", "/transaction/attachment", true, true, handlers.Attachment(queries)},
+		{"GET", "/category", true, true, handlers.Category(txQueries)},
+		{"POST", "/category", true, true, handlers.Category(txQueries)},
+		{"PUT", "/category", true, true, handlers.Category(txQueries)},
+		{"DELETE", "/category", true, true, handlers.Category(txQueries)},
+		{"GET", "/category/report", true, true, handlers.CategoryReport(txQueries)},
+		{"GET", "/category/suggest", true, true, handlers.CategorySuggest(txQueries)},
+		{"POST", "/category/restore", true, true, handlers.CategoryRestore(txQueries)},
+		{"GET", "/tag", true, true, handlers.Tag(txQueries)},
+		{"POST", "/tag", true, true, handlers.Tag(txQueries)},
+		{"PUT", "/tag", true, true, handlers.Tag(txQueries)},
+		{"DELETE", "/tag", true, true, handlers.Tag(txQueries)},
+		{"GET", "/tag/report", true, true, handlers.TagReport(txQueries)},
+		{"GET", "/payee", true, true, handlers.Payee(txQueries)},
+		{"POST", "/payee", true, true, handlers.Payee(txQueries)},
+		{"DELETE", "/payee", true, true, handlers.Payee(txQueries)},
+		{"POST", "/payee/alias", true, true, handlers.PayeeAlias(txQueries)},
+		{"DELETE", "/payee/alias", true, true, handlers.PayeeAlias(txQueries)},
+		{"GET", "/payee/report", true, true, handlers.PayeeReport(txQueries)},
+		{"GET", "/rule", true, true, handlers.Rule(txQueries)},
+		{"POST", "/rule", true, true, handlers.Rule(txQueries)},
+		{"DELETE", "/rule", true, true, handlers.Rule(txQueries)},
+		{"GET", "/rule/preview", true, true, handlers.RulePreview(txQueries)},
+		{"POST", "/rule/apply", true, true, handlers.RuleApply(txQueries)},
+		{"GET", "/trash", true, true, handlers.Trash(txQueries)},
+		{"GET", "/audit", true, true, handlers.Audit(queries)},
+		{"GET", "/me", true, true, handlers.Me(queries)},
+	}
+}
 
-	// Protected routes
+// Endpoints lists the patterns of every route, e.g. "GET /v1/me". The
+// deprecated aliases are not included.
+func Endpoints() []string {
+	var endpoints []string
+	for _, r := range routes(nil, nil) {
+		endpoints = append(endpoints, r.pattern())
+	}
+	return endpoints
+}
+
+func New(db *sql.DB, queries *database.Queries) http.Handler {
+	doc, err := openapi.Load()
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded OpenAPI document: %v", err))
+	}
+
+	mux := http.NewServeMux()
 	protected := http.NewServeMux()
-	protected.HandleFunc("GET /transaction", handlers.Transaction(queries))
-	protected.HandleFunc("POST /transaction", handlers.Transaction(queries))
-	protected.HandleFunc("DELETE /transaction", handlers.Transaction(queries))
-	protected.HandleFunc("GET /category", handlers.Category(queries))
-	protected.HandleFunc("POST /category", handlers.Category(queries))
-	protected.HandleFunc("DELETE /category", handlers.Category(queries))
-	protected.HandleFunc("GET /me", handlers.Me(queries))
-
-	// Mount protected routes under auth middleware
-	mux.Handle("/", middleware.AuthMiddleware(protected.ServeHTTP))
-
-	// Wrap the entire mux with request logging middleware
-	return middleware.RequestLogger(mux)
+	// The methods served at every path, to tell a wrong method from an
+	// unknown path
+	methods := make(map[string][]string)
+
+	for _, r := range routes(db, queries) {
+		methods[r.canonicalPath()] = append(methods[r.canonicalPath()], r.method)
+		// Bodies are validated against the OpenAPI document
+		handler := middleware.ValidateBody(doc, r.method, r.canonicalPath(), r.handler)
+		if r.protected {
+			protected.HandleFunc(r.pattern(), handler)
+		} else {
+			mux.HandleFunc(r.pattern(), handler)
+		}
+
+		if r.versioned && legacyPaths[r.path] {
+			// Registered on the outer mux, so that even a 401 tells old
+			// clients where to move. Resources keep their former shape there.
+			alias := middleware.LegacyResponse(handler)
+			if r.protected {
+				alias = middleware.AuthMiddleware(alias)
+			}
+			mux.HandleFunc(r.method+" "+r.path, middleware.Deprecated(r.canonicalPath(), alias))
+			methods[r.path] = append(methods[r.path], r.method)
+		}
+	}
+
+	// Mount protected routes under auth middleware. The requests no route
+	// matches are answered without asking for a token.
+	authorized := middleware.AuthMiddleware(protected.ServeHTTP)
+	mux.Handle("/", middleware.RecordRoute(protected, matched(protected, authorized, noRoute(methods))))
+
+	// Wrap the entire mux with request logging and metrics middlewares
+	return middleware.RequestLogger(middleware.Metrics(mux))
+}
+
+// matched calls next for the requests mux has a route for, and fallback for
+// the others.
+func matched(mux *http.ServeMux, next, fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern == "" {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// noRoute answers the requests no route matches in the JSON error envelope,
+// rather than the plain text of the mux: 405 listing the methods in the Allow
+// header when the path is served, 404 otherwise.
+func noRoute(methods map[string][]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed := methods[r.URL.Path]
+		if len(allowed) == 0 {
+			middleware.Error(w, r.Context(), http.StatusNotFound, middleware.CodeNotFound, "no route found for the given path")
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		middleware.Error(w, r.Context(), http.StatusMethodNotAllowed, middleware.CodeMethodNotAllowed, "method not allowed for the given path")
+	}
 }
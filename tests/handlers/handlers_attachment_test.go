@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"quattrinitrack/database"
+	"quattrinitrack/handlers"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var testPDF = []byte("%PDF-1.7\n1 0 obj\n<<>>\nendobj\n")
+
+// newUploadRequest builds a multipart upload of a single file field.
+func newUploadRequest(t *testing.T, target, filename string, data []byte) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	assert.NoError(t, err)
+	_, err = part.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+// POST request /transaction/attachment?transaction_id=someId
+func TestInsertAttachmentSniffsContentType(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetTransactionByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Transaction{ID: 3}, nil)
+	mockQueries.On("InsertAttachment", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertAttachmentParams) bool {
+		return arg.TransactionID == 3 && arg.Filename == "receipt.pdf" && arg.ContentType == "application/pdf" &&
+			arg.Size == int64(len(testPDF)) && bytes.Equal(arg.Data, testPDF) && !arg.CreatedAt.IsZero()
+	})).Return(int64(7), nil)
+
+	handler := handlers.Attachment(mockQueries)
+	// The directory is dropped from the name
+	req := newUploadRequest(t, "/transaction/attachment?transaction_id=3", "scans/receipt.pdf", testPDF)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":7`)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestInsertAttachmentRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		status int
+	}{
+		{"unsupported type", []byte("#!/bin/sh\necho hello\n"), http.StatusUnsupportedMediaType},
+		{"empty file", []byte{}, http.StatusUnsupportedMediaType},
+		{"too large", append(append([]byte{}, testPDF...), make([]byte, 10<<20)...), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockQueries)
+
+			handler := handlers.Attachment(mockQueries)
+			req := newUploadRequest(t, "/transaction/attachment?transaction_id=3", "receipt.pdf", tt.data)
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			mockQueries.AssertNotCalled(t, "InsertAttachment", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestInsertAttachmentWithoutFile(t *testing.T) {
+	mockQueries := new(MockQueries)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	assert.NoError(t, form.WriteField("name", "receipt"))
+	assert.NoError(t, form.Close())
+
+	handler := handlers.Attachment(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/transaction/attachment?transaction_id=3", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"file"`)
+}
+
+func TestInsertAttachmentUnknownTransaction(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetTransactionByID", mock.AnythingOfType("context.backgroundCtx"), int64(9)).Return(database.Transaction{}, sql.ErrNoRows)
+
+	handler := handlers.Attachment(mockQueries)
+	req := newUploadRequest(t, "/transaction/attachment?transaction_id=9", "receipt.pdf", testPDF)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockQueries.AssertNotCalled(t, "InsertAttachment", mock.Anything, mock.Anything)
+}
+
+// GET request /transaction/attachment?id=someId
+func TestGetAttachmentDownload(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetAttachmentByID", mock.AnythingOfType("context.backgroundCtx"), int64(7)).Return(database.Attachment{
+		ID:          7,
+		Filename:    "scontrino caffè.pdf",
+		ContentType: "application/pdf",
+		Size:        int64(len(testPDF)),
+		Data:        testPDF,
+	}, nil)
+
+	handler := handlers.Attachment(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/transaction/attachment?id=7", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "attachment; filename*=utf-8''scontrino%20caff%C3%A8.pdf", w.Header().Get("Content-Disposition"))
+	assert.Equal(t, testPDF, w.Body.Bytes())
+}
+
+// DELETE request /transaction/attachment?id=someId
+func TestDeleteAttachmentNotFound(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetAttachmentByID", mock.AnythingOfType("context.backgroundCtx"), int64(9)).Return(database.Attachment{}, sql.ErrNoRows)
+
+	handler := handlers.Attachment(mockQueries)
+	req := httptest.NewRequest(http.MethodDelete, "/transaction/attachment?id=9", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockQueries.AssertNotCalled(t, "DeleteAttachment", mock.Anything, mock.Anything)
+}
+
+// PUT request /transaction/notes?id=someId
+func TestTransactionNotesUpdated(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockAudit(mockQueries)
+	mockQueries.On("GetTransactionByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Transaction{ID: 3}, nil)
+	mockQueries.On("SetTransactionNotes", mock.AnythingOfType("context.backgroundCtx"), database.SetTransactionNotesParams{Notes: "Warranty until 2028", ID: 3}).Return(nil)
+
+	handler := handlers.TransactionNotes(mockQueries)
+	req := httptest.NewRequest(http.MethodPut, "/transaction/notes?id=3", bytes.NewBufferString(`{"notes":"Warranty until 2028"}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestTransactionNotesTooLong(t *testing.T) {
+	mockQueries := new(MockQueries)
+
+	handler := handlers.TransactionNotes(mockQueries)
+	body := `{"notes":"` + strings.Repeat("a", 10001) + `"}`
+	req := httptest.NewRequest(http.MethodPut, "/transaction/notes?id=3", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"notes"`)
+	mockQueries.AssertNotCalled(t, "SetTransactionNotes", mock.Anything, mock.Anything)
+}
+
+// GET request /transaction?id=someId lists the attachments
+func TestGetTransactionWithAttachments(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetTransactionByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Transaction{ID: 3, Name: "Laptop", Cost: 900, CategoriesID: 1, Notes: "Warranty until 2028"}, nil)
+	mockQueries.On("GetCategoriesByIDs", mock.AnythingOfType("context.backgroundCtx"), []int64{1}).Return([]database.Category{{ID: 1, Name: "Electronics"}}, nil)
+	mockQueries.On("GetTransactionTags", mock.AnythingOfType("context.backgroundCtx"), []int64{3}).Return([]database.GetTransactionTagsRow{}, nil)
+	mockQueries.On("GetTransactionSplits", mock.AnythingOfType("context.backgroundCtx"), []int64{3}).Return([]database.TransactionSplit{}, nil)
+	mockQueries.On("GetAttachments", mock.AnythingOfType("context.backgroundCtx"), []int64{3}).Return([]database.GetAttachmentsRow{
+		{ID: 7, TransactionID: 3, Filename: "receipt.pdf", ContentType: "application/pdf", Size: 1200},
+	}, nil)
+
+	handler := handlers.Transaction(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/transaction?id=3", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"notes":"Warranty until 2028"`)
+	assert.Contains(t, w.Body.String(), `"attachments":[{"id":7,"filename":"receipt.pdf","content_type":"application/pdf","size":1200,`)
+	mockQueries.AssertExpectations(t)
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"quattrinitrack/database"
+	"quattrinitrack/handlers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockAudit accepts the audit entries written by the handlers under test.
+func mockAudit(mockQueries *MockQueries) {
+	mockQueries.On("InsertAuditEntry", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.InsertAuditEntryParams")).Return(nil).Maybe()
+}
+
+// GET request /audit
+func TestAuditFeed(t *testing.T) {
+	createdAt := time.Date(2026, 5, 2, 10, 0, 0, 0, time.UTC)
+	mockQueries := new(MockQueries)
+	mockQueries.On("CountAuditEntries", mock.AnythingOfType("context.backgroundCtx")).Return(int64(12), nil)
+	mockQueries.On("GetAuditEntries", mock.AnythingOfType("context.backgroundCtx"), database.GetAuditEntriesParams{PageLimit: 2, PageOffset: 4}).Return([]database.GetAuditEntriesRow{
+		{ID: 8, UserID: sql.NullInt64{Int64: 7, Valid: true}, UserEmail: sql.NullString{String: "anna@example.com", Valid: true},
+			Entity: "category", EntityID: 5, Action: "update",
+			Before: sql.NullString{String: `{"name":"Food"}`, Valid: true}, After: sql.NullString{String: `{"name":"Groceries"}`, Valid: true},
+			CreatedAt: createdAt},
+		{ID: 7, Entity: "transaction", EntityID: 3, Action: "purge",
+			Before: sql.NullString{String: `{"id":3}`, Valid: true}, CreatedAt: createdAt},
+	}, nil)
+
+	handler := handlers.Audit(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/audit?limit=2&offset=4", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var entries []handlers.AuditEntryResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "12", w.Header().Get("X-Total-Count"))
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&entries))
+	assert.Len(t, entries, 2)
+	assert.Equal(t, int64(7), *entries[0].UserID)
+	assert.Equal(t, "anna@example.com", entries[0].UserEmail)
+	assert.JSONEq(t, `{"name":"Groceries"}`, string(entries[0].After))
+	// Purged by the server, with nothing after
+	assert.Nil(t, entries[1].UserID)
+	assert.JSONEq(t, `null`, string(entries[1].After))
+	mockQueries.AssertExpectations(t)
+}
+
+func TestAuditFeedInvalidLimit(t *testing.T) {
+	mockQueries := new(MockQueries)
+
+	handler := handlers.Audit(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/audit?limit=0", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockQueries.AssertNotCalled(t, "GetAuditEntries", mock.Anything, mock.Anything)
+}
+
+// GET request /transaction/history?id=someId
+func TestTransactionHistory(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetEntityAuditEntries", mock.AnythingOfType("context.backgroundCtx"), database.GetEntityAuditEntriesParams{Entity: "transaction", EntityID: 3}).Return([]database.GetEntityAuditEntriesRow{
+		{ID: 1, Entity: "transaction", EntityID: 3, Action: "insert", After: sql.NullString{String: `{"id":3,"notes":""}`, Valid: true}},
+		{ID: 4, Entity: "transaction", EntityID: 3, Action: "update",
+			Before: sql.NullString{String: `{"notes":""}`, Valid: true}, After: sql.NullString{String: `{"notes":"Shared"}`, Valid: true}},
+	}, nil)
+
+	handler := handlers.TransactionHistory(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/transaction/history?id=3", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var entries []handlers.AuditEntryResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&entries))
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "insert", entries[0].Action)
+	assert.JSONEq(t, `null`, string(entries[0].Before))
+	assert.JSONEq(t, `{"notes":"Shared"}`, string(entries[1].After))
+	mockQueries.AssertExpectations(t)
+}
+
+func TestTransactionHistoryNotFound(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetEntityAuditEntries", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.GetEntityAuditEntriesParams")).Return([]database.GetEntityAuditEntriesRow{}, nil)
+
+	handler := handlers.TransactionHistory(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/transaction/history?id=99", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// The acting user and the state before the delete are recorded
+func TestDeleteTransactionRecordsAudit(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InTransactionTx", mock.Anything).Return(nil)
+	mockQueries.On("GetTransactionByID", mock.Anything, int64(3)).Return(database.Transaction{ID: 3, Name: "Concert", Cost: 40, CategoriesID: 5}, nil)
+	mockQueries.On("TrashTransaction", mock.Anything, mock.AnythingOfType("database.TrashTransactionParams")).Return(nil)
+	mockQueries.On("InsertAuditEntry", mock.Anything, mock.MatchedBy(func(arg database.InsertAuditEntryParams) bool {
+		var before map[string]any
+		if err := json.Unmarshal([]byte(arg.Before.String), &before); err != nil {
+			return false
+		}
+		return arg.UserID == sql.NullInt64{Int64: 7, Valid: true} && arg.Entity == "transaction" && arg.EntityID == 3 &&
+			arg.Action == "delete" && before["name"] == "Concert" && !arg.After.Valid
+	})).Return(nil).Once()
+
+	handler := handlers.Transaction(mockQueries)
+	req := httptest.NewRequest(http.MethodDelete, "/transaction?id=3", nil)
+	req = req.WithContext(context.WithValue(req.Context(), "userID", int64(7)))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+// Only the fields that changed are recorded for an update
+func TestCategoryPUTRecordsChanges(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Category{ID: 3, Name: "Grocery"}, nil)
+	mockQueries.On("UpdateCategory", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.UpdateCategoryParams")).Return(nil)
+	mockQueries.On("InsertAuditEntry", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertAuditEntryParams) bool {
+		return arg.Action == "update" && !arg.UserID.Valid &&
+			arg.Before.String == `{"name":"Grocery"}` && arg.After.String == `{"name":"Groceries"}`
+	})).Return(nil).Once()
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest(http.MethodPut, "/category?id=3", bytes.NewBufferString(`{"name": "Groceries"}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestCategoryPUTUnchangedNotRecorded(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Category{ID: 3, Name: "Groceries"}, nil)
+	mockQueries.On("UpdateCategory", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.UpdateCategoryParams")).Return(nil)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest(http.MethodPut, "/category?id=3", bytes.NewBufferString(`{"name": "Groceries"}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockQueries.AssertNotCalled(t, "InsertAuditEntry", mock.Anything, mock.Anything)
+}
+
+func TestCategoryPOSTAuditFailure(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("InsertCategory", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.InsertCategoryParams")).Return(int64(4), nil)
+	mockQueries.On("InsertAuditEntry", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertAuditEntryParams) bool {
+		return arg.Entity == "category" && arg.EntityID == 4 && arg.Action == "insert"
+	})).Return(errors.New("disk full"))
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/category", bytes.NewBufferString(`{"name": "Travel"}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockQueries.AssertExpectations(t)
+}
@@ -9,15 +9,18 @@ import (
 	"net/http/httptest"
 	"quattrinitrack/database"
 	"quattrinitrack/handlers"
+	"quattrinitrack/logger"
+	middleware "quattrinitrack/middlewares"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // GET request /category
 func TestGetAllCategoriesSuccess(t *testing.T) {
-	var actualCategories []database.Category
+	var actualCategories []handlers.CategoryResource
 	w, _, expectedCategories, mockQueries := setUpCategoriesGetTest()
 
 	err := json.NewDecoder(w.Body).Decode(&actualCategories)
@@ -43,7 +46,7 @@ func TestGetCategoryByIDSuccess(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	handler(w, req)
-	var actualCategory database.Category
+	var actualCategory handlers.CategoryResource
 	err := json.NewDecoder(w.Body).Decode(&actualCategory)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedCategories[0].ID, actualCategory.ID)
@@ -104,13 +107,45 @@ func TestCategoryInvalidJSONName(t *testing.T) {
 	mockQueries.AssertExpectations(t)
 }
 
+func TestCategoryErrorIncludesRequestID(t *testing.T) {
+	mockQueries := new(MockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/category", bytes.NewBufferString("{"))
+	ctx := logger.WithRequestInfo(req.Context(), &logger.RequestInfo{RequestID: "req-42"})
+	w := httptest.NewRecorder()
+	handler := handlers.Category(mockQueries)
+	handler(w, req.WithContext(ctx))
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeInvalidJSON, response.Error.Code)
+	assert.Equal(t, "req-42", response.Error.RequestID)
+}
+
+func TestGetCategoryInvalidID(t *testing.T) {
+	mockQueries := new(MockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/category?id=abc", nil)
+	w := httptest.NewRecorder()
+	handler := handlers.Category(mockQueries)
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeBadRequest, response.Error.Code)
+	assert.Equal(t, []middleware.FieldError{{Field: "id", Message: "must be an integer"}}, response.Error.Details)
+	mockQueries.AssertExpectations(t)
+}
+
 func setUpCategoriesPostTest() (*httptest.ResponseRecorder, *http.Request, database.Category, *MockQueries) {
 	category := database.Category{
 		Name: "test",
 	}
 
 	mockQueries := new(MockQueries)
-	mockQueries.On("InsertCategory", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(nil)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("InsertCategory", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.InsertCategoryParams")).Return(int64(1), nil)
+	mockAudit(mockQueries)
 
 	handler := handlers.Category(mockQueries)
 	jsonData, _ := json.Marshal(category)
@@ -126,9 +161,14 @@ func setUpCategoriesPostTest() (*httptest.ResponseRecorder, *http.Request, datab
 func TestDeleteCategorySuccess(t *testing.T) {
 	_, _, expectedCategories, _ := setUpCategoriesGetTest()
 	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockAudit(mockQueries)
 
 	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(expectedCategories[0], nil)
-	mockQueries.On("DeleteCategory", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(nil)
+	mockQueries.On("CountCategoryUses", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.CountCategoryUsesRow{}, nil)
+	mockQueries.On("TrashCategory", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.TrashCategoryParams) bool {
+		return arg.ID == 1 && arg.DeletedAt.Valid
+	})).Return(nil)
 
 	handler := handlers.Category(mockQueries)
 	req := httptest.NewRequest("DELETE", "/category/?id=1", nil)
@@ -142,6 +182,7 @@ func TestDeleteCategorySuccess(t *testing.T) {
 
 func TestDeleteCategoryNotFound(t *testing.T) {
 	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
 
 	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(database.Category{}, sql.ErrNoRows)
 
@@ -150,7 +191,10 @@ func TestDeleteCategoryNotFound(t *testing.T) {
 	w := httptest.NewRecorder()
 	handler(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeNotFound, response.Error.Code)
 
 	mockQueries.AssertExpectations(t)
 }
@@ -158,10 +202,12 @@ func TestDeleteCategoryNotFound(t *testing.T) {
 func TestDeleteCategoryError(t *testing.T) {
 	_, _, expectedCategories, _ := setUpCategoriesGetTest()
 	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
 
 	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(expectedCategories[0], nil)
 
-	mockQueries.On("DeleteCategory", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(errors.New("database error"))
+	mockQueries.On("CountCategoryUses", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.CountCategoryUsesRow{}, nil)
+	mockQueries.On("TrashCategory", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.TrashCategoryParams")).Return(errors.New("database error"))
 
 	handler := handlers.Category(mockQueries)
 	req := httptest.NewRequest("DELETE", "/category/?id=1", nil)
@@ -172,3 +218,469 @@ func TestDeleteCategoryError(t *testing.T) {
 
 	mockQueries.AssertExpectations(t)
 }
+
+func TestDeleteCategoryInUse(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Category{ID: 1, Name: "Food"}, nil)
+	mockQueries.On("CountCategoryUses", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.CountCategoryUsesRow{Transactions: 3}, nil)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest("DELETE", "/category/?id=1", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Contains(t, response.Error.Message, "used by 3 transactions")
+	assert.Equal(t, []middleware.FieldError{{Field: "transactions", Message: "3"}}, response.Error.Details)
+	mockQueries.AssertNotCalled(t, "TrashCategory", mock.Anything, mock.Anything)
+}
+
+func TestDeleteCategoryWithSubcategories(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Category{ID: 1, Name: "Food"}, nil)
+	mockQueries.On("CountCategoryUses", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.CountCategoryUsesRow{Subcategories: 2}, nil)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest("DELETE", "/category/?id=1", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeConflict, response.Error.Code)
+	assert.Equal(t, []middleware.FieldError{{Field: "subcategories", Message: "2"}}, response.Error.Details)
+	mockQueries.AssertNotCalled(t, "TrashCategory", mock.Anything, mock.Anything)
+}
+
+// DELETE /category?id=someid&move_to=otherid
+func TestDeleteCategoryMoveTo(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Category{ID: 1, Name: "Food"}, nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(2)).Return(database.Category{ID: 2, Name: "Groceries"}, nil)
+	mockQueries.On("CountCategoryUses", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.CountCategoryUsesRow{Transactions: 3}, nil)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("MoveCategoryTransactions", mock.AnythingOfType("context.backgroundCtx"), database.MoveCategoryTransactionsParams{ToID: 2, FromID: 1}).Return([]int64{10, 11}, nil)
+	// Transaction 12 is split, with a line in the category
+	mockQueries.On("MoveCategorySplits", mock.AnythingOfType("context.backgroundCtx"), database.MoveCategorySplitsParams{ToID: 2, FromID: 1}).Return([]int64{11, 12}, nil)
+	mockQueries.On("TrashCategory", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.TrashCategoryParams) bool {
+		return arg.ID == 1 && arg.DeletedAt.Valid
+	})).Return(nil)
+	mockQueries.On("InsertAuditEntry", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertAuditEntryParams) bool {
+		return arg.Entity == "transaction" && arg.Action == "update" && arg.After.String == `{"categories_id":2}`
+	})).Return(nil).Twice()
+	mockQueries.On("InsertAuditEntry", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertAuditEntryParams) bool {
+		return arg.Entity == "category" && arg.EntityID == 1 && arg.Action == "delete"
+	})).Return(nil).Once()
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest("DELETE", "/category?id=1&move_to=2", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response map[string]string
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "Category deleted successfully, 3 transactions moved", response["message"])
+	mockQueries.AssertExpectations(t)
+	mockQueries.AssertNotCalled(t, "MoveSubcategories", mock.Anything, mock.Anything)
+	mockQueries.AssertNotCalled(t, "MoveCategoryRules", mock.Anything, mock.Anything)
+}
+
+func TestDeleteCategoryMoveToWithSubcategories(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Category{ID: 1, Name: "Food"}, nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(2)).Return(database.Category{ID: 2, Name: "Shopping"}, nil)
+	mockQueries.On("CountCategoryUses", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.CountCategoryUsesRow{Transactions: 3, Subcategories: 1}, nil)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest("DELETE", "/category?id=1&move_to=2", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, []middleware.FieldError{{Field: "subcategories", Message: "1"}}, response.Error.Details)
+	mockQueries.AssertNotCalled(t, "MoveCategoryTransactions", mock.Anything, mock.Anything)
+}
+
+// DELETE /category?id=someid&merge_into=otherid
+func TestDeleteCategoryMergeInto(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockAudit(mockQueries)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Category{ID: 1, Name: "Food"}, nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(4)).Return(database.Category{ID: 4, Name: "Eating"}, nil)
+	mockQueries.On("CountCategoryUses", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.CountCategoryUsesRow{Transactions: 1, Subcategories: 1}, nil)
+	mockQueries.On("GetCategoryAncestors", mock.AnythingOfType("context.backgroundCtx"), int64(4)).Return([]int64{4}, nil)
+	mockQueries.On("GetAllCategories", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Category{
+		{ID: 1, Name: "Food"},
+		{ID: 3, Name: "Groceries", ParentID: sql.NullInt64{Int64: 1, Valid: true}},
+		{ID: 4, Name: "Eating"},
+		{ID: 5, Name: "Restaurants", ParentID: sql.NullInt64{Int64: 4, Valid: true}},
+	}, nil)
+	move := database.MoveCategoryTransactionsParams{ToID: 4, FromID: 1}
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("MoveCategoryTransactions", mock.AnythingOfType("context.backgroundCtx"), move).Return([]int64{10}, nil)
+	mockQueries.On("MoveCategorySplits", mock.AnythingOfType("context.backgroundCtx"), database.MoveCategorySplitsParams(move)).Return([]int64{}, nil)
+	mockQueries.On("MoveSubcategories", mock.AnythingOfType("context.backgroundCtx"), database.MoveSubcategoriesParams(move)).Return([]int64{3}, nil)
+	mockQueries.On("MoveCategoryRules", mock.AnythingOfType("context.backgroundCtx"), database.MoveCategoryRulesParams(move)).Return(nil)
+	mockQueries.On("TrashCategory", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.TrashCategoryParams")).Return(nil)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest("DELETE", "/category?id=1&merge_into=4", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response map[string]string
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "Category merged successfully, 1 transactions moved", response["message"])
+	mockQueries.AssertExpectations(t)
+}
+
+func TestDeleteCategoryMergeIntoSubcategory(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Category{ID: 1, Name: "Food"}, nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Category{ID: 3, Name: "Groceries"}, nil)
+	mockQueries.On("CountCategoryUses", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.CountCategoryUsesRow{Subcategories: 1}, nil)
+	// Groceries is under Food
+	mockQueries.On("GetCategoryAncestors", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return([]int64{3, 1}, nil)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest("DELETE", "/category?id=1&merge_into=3", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockQueries.AssertNotCalled(t, "MoveCategoryTransactions", mock.Anything, mock.Anything)
+}
+
+func TestDeleteCategoryMergeNameTaken(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Category{ID: 1, Name: "Food"}, nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(4)).Return(database.Category{ID: 4, Name: "Eating"}, nil)
+	mockQueries.On("CountCategoryUses", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.CountCategoryUsesRow{Subcategories: 1}, nil)
+	mockQueries.On("GetCategoryAncestors", mock.AnythingOfType("context.backgroundCtx"), int64(4)).Return([]int64{4}, nil)
+	mockQueries.On("GetAllCategories", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Category{
+		{ID: 1, Name: "Food"},
+		{ID: 3, Name: "Restaurants", ParentID: sql.NullInt64{Int64: 1, Valid: true}},
+		{ID: 4, Name: "Eating"},
+		{ID: 5, Name: "Restaurants", ParentID: sql.NullInt64{Int64: 4, Valid: true}},
+	}, nil)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest("DELETE", "/category?id=1&merge_into=4", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockQueries.AssertNotCalled(t, "MoveCategoryTransactions", mock.Anything, mock.Anything)
+}
+
+func TestDeleteCategoryInvalidTarget(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"both options", "id=1&move_to=2&merge_into=2"},
+		{"itself", "id=1&move_to=1"},
+		{"not a number", "id=1&merge_into=food"},
+		{"missing", "id=1&move_to=99"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockQueries)
+			mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil).Maybe()
+			mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Category{ID: 1, Name: "Food"}, nil)
+			mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(99)).Return(database.Category{}, sql.ErrNoRows)
+			mockQueries.On("CountCategoryUses", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.CountCategoryUsesRow{Transactions: 3}, nil)
+
+			handler := handlers.Category(mockQueries)
+			req := httptest.NewRequest("DELETE", "/category?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockQueries.AssertNotCalled(t, "MoveCategoryTransactions", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestDeleteCategoryMoveToRollback(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Category{ID: 1, Name: "Food"}, nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(2)).Return(database.Category{ID: 2, Name: "Groceries"}, nil)
+	mockQueries.On("CountCategoryUses", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.CountCategoryUsesRow{Transactions: 3}, nil)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("MoveCategoryTransactions", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.MoveCategoryTransactionsParams")).Return([]int64{}, errors.New("database is locked"))
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest("DELETE", "/category?id=1&move_to=2", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockQueries.AssertNotCalled(t, "TrashCategory", mock.Anything, mock.Anything)
+}
+
+// Subcategories
+func TestGetCategoryTree(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetAllCategories", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Category{
+		{ID: 1, Name: "Food"},
+		{ID: 2, Name: "Restaurants", ParentID: sql.NullInt64{Int64: 1, Valid: true}},
+		{ID: 3, Name: "Groceries", ParentID: sql.NullInt64{Int64: 1, Valid: true}},
+		{ID: 4, Name: "Sport"},
+	}, nil)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/category?tree=true", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var tree []handlers.CategoryNode
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&tree))
+	assert.Len(t, tree, 2)
+	assert.Equal(t, "Food", tree[0].Name)
+	assert.Nil(t, tree[0].ParentID)
+	assert.Len(t, tree[0].Children, 2)
+	assert.Equal(t, "Groceries", tree[0].Children[0].Name)
+	assert.Equal(t, int64(1), *tree[0].Children[0].ParentID)
+	assert.Equal(t, "Restaurants", tree[0].Children[1].Name)
+	assert.Equal(t, "Sport", tree[1].Name)
+	assert.Empty(t, tree[1].Children)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestCategoryPOSTUnknownParent(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(42)).Return(database.Category{}, sql.ErrNoRows)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/category", bytes.NewBufferString(`{"name": "Groceries", "parent_id": 42}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, []middleware.FieldError{{Field: "parent_id", Message: "must be an existing category"}}, response.Error.Details)
+	mockQueries.AssertNotCalled(t, "InsertCategory", mock.Anything, mock.Anything)
+}
+
+// uniqueViolation returns the error sqlite fails with when a unique
+// constraint does.
+func uniqueViolation(t *testing.T) error {
+	db, err := sql.Open("sqlite", "file::memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE names(name TEXT UNIQUE); INSERT INTO names VALUES ('Food'), ('Food')")
+	require.Error(t, err)
+	return err
+}
+
+// Only a name already taken is a conflict
+func TestCategoryWriteError(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		err    error
+		status int
+		code   string
+	}{
+		{"insert of a taken name", http.MethodPost, uniqueViolation(t), http.StatusConflict, middleware.CodeConflict},
+		{"insert failing", http.MethodPost, errors.New("disk I/O error"), http.StatusInternalServerError, middleware.CodeInternal},
+		{"update to a taken name", http.MethodPut, uniqueViolation(t), http.StatusConflict, middleware.CodeConflict},
+		{"update failing", http.MethodPut, errors.New("disk I/O error"), http.StatusInternalServerError, middleware.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockQueries)
+			mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+			mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Category{ID: 3, Name: "Groceries"}, nil).Maybe()
+			mockQueries.On("InsertCategory", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.InsertCategoryParams")).Return(int64(0), tt.err).Maybe()
+			mockQueries.On("UpdateCategory", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.UpdateCategoryParams")).Return(tt.err).Maybe()
+
+			handler := handlers.Category(mockQueries)
+			req := httptest.NewRequest(tt.method, "/category?id=3", bytes.NewBufferString(`{"name": "Food"}`))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			var response middleware.ErrorResponse
+			assert.Equal(t, tt.status, w.Code)
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			assert.Equal(t, tt.code, response.Error.Code)
+			mockQueries.AssertNotCalled(t, "InsertAuditEntry", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// A failing lookup of the parent is not taken for a missing one
+func TestCategoryPOSTParentLookupError(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(42)).Return(database.Category{}, errors.New("database is locked"))
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/category", bytes.NewBufferString(`{"name": "Groceries", "parent_id": 42}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeInternal, response.Error.Code)
+	mockQueries.AssertNotCalled(t, "InsertCategory", mock.Anything, mock.Anything)
+}
+
+func TestCategoryPUTMove(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockAudit(mockQueries)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Category{ID: 1, Name: "Food"}, nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Category{ID: 3, Name: "Groceries"}, nil)
+	mockQueries.On("GetCategoryAncestors", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return([]int64{1}, nil)
+	mockQueries.On("UpdateCategory", mock.AnythingOfType("context.backgroundCtx"), database.UpdateCategoryParams{
+		Name:     "Groceries",
+		ParentID: sql.NullInt64{Int64: 1, Valid: true},
+		ID:       3,
+	}).Return(nil)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest(http.MethodPut, "/category?id=3", bytes.NewBufferString(`{"name": "Groceries", "parent_id": 1}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestCategoryPUTCycle(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Category{ID: 1, Name: "Food"}, nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Category{ID: 3, Name: "Groceries"}, nil)
+	// Groceries is under Food
+	mockQueries.On("GetCategoryAncestors", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return([]int64{3, 1}, nil)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest(http.MethodPut, "/category?id=1", bytes.NewBufferString(`{"name": "Food", "parent_id": 3}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeConflict, response.Error.Code)
+	mockQueries.AssertNotCalled(t, "UpdateCategory", mock.Anything, mock.Anything)
+}
+
+func TestCategoryPUTNotFound(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(9)).Return(database.Category{}, sql.ErrNoRows)
+
+	handler := handlers.Category(mockQueries)
+	req := httptest.NewRequest(http.MethodPut, "/category?id=9", bytes.NewBufferString(`{"name": "Food"}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockQueries.AssertNotCalled(t, "UpdateCategory", mock.Anything, mock.Anything)
+}
+
+func TestCategoryReportRollsUp(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetCategoryReport", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.GetCategoryReportParams")).Return([]database.GetCategoryReportRow{
+		{ID: 1, Name: "Food", Transactions: 1, Total: 10},
+		{ID: 2, Name: "Groceries", ParentID: sql.NullInt64{Int64: 1, Valid: true}, Transactions: 2, Total: 55.5},
+		{ID: 3, Name: "Supermarket", ParentID: sql.NullInt64{Int64: 2, Valid: true}, Transactions: 1, Total: 4.5},
+		{ID: 4, Name: "Restaurants", ParentID: sql.NullInt64{Int64: 1, Valid: true}, Transactions: 3, Total: 90},
+	}, nil)
+
+	handler := handlers.CategoryReport(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/category/report", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var report []handlers.CategoryReportResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	assert.Len(t, report, 1)
+	food := report[0]
+	assert.Equal(t, int64(7), food.Transactions)
+	assert.Equal(t, 160.0, food.Total)
+	assert.Equal(t, int64(1), food.OwnTransactions)
+	assert.Equal(t, 10.0, food.OwnTotal)
+	assert.Equal(t, "Groceries", food.Children[0].Name)
+	assert.Equal(t, 60.0, food.Children[0].Total)
+	assert.Equal(t, 55.5, food.Children[0].OwnTotal)
+	assert.Equal(t, "Restaurants", food.Children[1].Name)
+	mockQueries.AssertExpectations(t)
+}
+
+// GET request /category/suggest
+func setupCategorySuggestTest(samples []database.GetCategorySamplesRow, target string) (*httptest.ResponseRecorder, *MockQueries) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetCategorySamples", mock.AnythingOfType("context.backgroundCtx"), "Uncategorized").Return(samples, nil)
+	mockQueries.On("GetAllCategories", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Category{
+		{ID: 1, Name: "Groceries"}, {ID: 2, Name: "Fuel"}, {ID: 3, Name: "Subscriptions"},
+	}, nil)
+
+	handler := handlers.CategorySuggest(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+	return w, mockQueries
+}
+
+func TestCategorySuggestFromHistory(t *testing.T) {
+	w, mockQueries := setupCategorySuggestTest([]database.GetCategorySamplesRow{
+		{Name: "LIDL 0042", Amount: 34.2, CategoriesID: 1},
+		{Name: "Lidl", Amount: 51, CategoriesID: 1},
+		{Name: "Esselunga", Amount: 60, CategoriesID: 1},
+		{Name: "Shell station", Amount: 55, CategoriesID: 2},
+		{Name: "Eni station", Amount: 62, CategoriesID: 2},
+		{Name: "Netflix", Amount: 12.99, CategoriesID: 3},
+	}, "/category/suggest?name=Lidl%200117&cost=40&limit=2")
+
+	var suggestions []handlers.CategorySuggestionResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&suggestions))
+	assert.Len(t, suggestions, 2)
+	assert.Equal(t, int64(1), suggestions[0].CategoriesID)
+	assert.Equal(t, "Groceries", suggestions[0].CategoryName)
+	assert.Greater(t, suggestions[0].Confidence, 0.5)
+	assert.Greater(t, suggestions[0].Confidence, suggestions[1].Confidence)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestCategorySuggestWithoutHistory(t *testing.T) {
+	w, _ := setupCategorySuggestTest([]database.GetCategorySamplesRow{}, "/category/suggest?name=Lidl")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
+
+func TestCategorySuggestInvalidParams(t *testing.T) {
+	for _, target := range []string{"/category/suggest", "/category/suggest?name=Lidl&cost=-3", "/category/suggest?name=Lidl&limit=50"} {
+		mockQueries := new(MockQueries)
+		handler := handlers.CategorySuggest(mockQueries)
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+		mockQueries.AssertNotCalled(t, "GetCategorySamples", mock.Anything, mock.Anything)
+	}
+}
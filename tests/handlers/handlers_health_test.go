@@ -0,0 +1,67 @@
+package handlers_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"quattrinitrack/database"
+	"quattrinitrack/handlers"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", "file::memory:?_pragma=foreign_keys(1)")
+	require.NoError(t, err)
+	// Every connection to :memory: is a different database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestHealth(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	handlers.Health()(w, req)
+
+	var response handlers.HealthResponse
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "ok", response.Status)
+	assert.Equal(t, handlers.Version, response.Build.Version)
+}
+
+func TestReadyMigrated(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, database.Migrate(context.Background(), db))
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	handlers.Ready(db)(w, req)
+
+	var response handlers.ReadinessResponse
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "ready", response.Status)
+	assert.True(t, response.ForeignKeys)
+	assert.Equal(t, response.LatestSchemaVersion, response.SchemaVersion)
+}
+
+func TestReadyNotMigrated(t *testing.T) {
+	db := openTestDB(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	handlers.Ready(db)(w, req)
+
+	var response handlers.ReadinessResponse
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "not ready", response.Status)
+	assert.Equal(t, 0, response.SchemaVersion)
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"quattrinitrack/database"
+	"quattrinitrack/handlers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// GET request /payee
+func TestGetAllPayeesSuccess(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetAllPayees", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Payee{{ID: 1, Name: "Amazon"}, {ID: 2, Name: "Lidl"}}, nil)
+	mockQueries.On("GetPayeeAliases", mock.AnythingOfType("context.backgroundCtx")).Return([]database.PayeeAlias{
+		{ID: 1, PayeeID: 1, Alias: "Amazon", Normalized: "amazon"},
+		{ID: 2, PayeeID: 1, Alias: "AMZN Mktp", Normalized: "amzn mktp"},
+	}, nil)
+
+	handler := handlers.Payee(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/payee", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var payees []handlers.PayeeResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&payees))
+	assert.Equal(t, []handlers.PayeeResource{
+		{ID: 1, Name: "Amazon", Aliases: []handlers.PayeeAliasResource{{ID: 1, Alias: "Amazon"}, {ID: 2, Alias: "AMZN Mktp"}}},
+		{ID: 2, Name: "Lidl", Aliases: []handlers.PayeeAliasResource{}},
+	}, payees)
+	mockQueries.AssertExpectations(t)
+}
+
+// POST request /payee
+func TestInsertPayeeLinksTransactions(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InPayeeTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockAudit(mockQueries)
+	mockQueries.On("GetPayeeIDByAlias", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(int64(0), sql.ErrNoRows)
+	mockQueries.On("InsertPayee", mock.AnythingOfType("context.backgroundCtx"), "Amazon").Return(int64(4), nil)
+	mockQueries.On("InsertPayeeAlias", mock.AnythingOfType("context.backgroundCtx"), database.InsertPayeeAliasParams{PayeeID: 4, Alias: "Amazon", Normalized: "amazon"}).Return(nil).Once()
+	mockQueries.On("InsertPayeeAlias", mock.AnythingOfType("context.backgroundCtx"), database.InsertPayeeAliasParams{PayeeID: 4, Alias: "AMZN Mktp", Normalized: "amzn mktp"}).Return(nil).Once()
+	mockQueries.On("GetPayeeAliases", mock.AnythingOfType("context.backgroundCtx")).Return([]database.PayeeAlias{
+		{ID: 1, PayeeID: 4, Alias: "Amazon", Normalized: "amazon"},
+		{ID: 2, PayeeID: 4, Alias: "AMZN Mktp", Normalized: "amzn mktp"},
+	}, nil)
+	mockQueries.On("GetTransactionsWithoutPayee", mock.AnythingOfType("context.backgroundCtx")).Return([]database.GetTransactionsWithoutPayeeRow{
+		{ID: 10, Name: "AMAZON EU SARL"},
+		{ID: 11, Name: "amzn mktp 0042"},
+		{ID: 12, Name: "Bakery"},
+	}, nil)
+	mockQueries.On("SetTransactionPayee", mock.AnythingOfType("context.backgroundCtx"), database.SetTransactionPayeeParams{PayeeID: sql.NullInt64{Int64: 4, Valid: true}, ID: 10}).Return(nil).Once()
+	mockQueries.On("SetTransactionPayee", mock.AnythingOfType("context.backgroundCtx"), database.SetTransactionPayeeParams{PayeeID: sql.NullInt64{Int64: 4, Valid: true}, ID: 11}).Return(nil).Once()
+
+	handler := handlers.Payee(mockQueries)
+	// "Amazon." normalizes like the name, so it is not added twice
+	req := httptest.NewRequest(http.MethodPost, "/payee", bytes.NewBufferString(`{"name":" Amazon ","aliases":["AMZN Mktp","Amazon."]}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockQueries.AssertExpectations(t)
+	mockQueries.AssertNumberOfCalls(t, "InsertPayeeAlias", 2)
+	mockQueries.AssertNumberOfCalls(t, "SetTransactionPayee", 2)
+}
+
+func TestInsertPayeeAliasTaken(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetPayeeIDByAlias", mock.AnythingOfType("context.backgroundCtx"), "amazon").Return(int64(0), sql.ErrNoRows)
+	mockQueries.On("GetPayeeIDByAlias", mock.AnythingOfType("context.backgroundCtx"), "amzn").Return(int64(2), nil)
+
+	handler := handlers.Payee(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/payee", bytes.NewBufferString(`{"name":"Amazon","aliases":["AMZN"]}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockQueries.AssertNotCalled(t, "InsertPayee", mock.Anything, mock.Anything)
+}
+
+// POST request /payee/alias
+func TestInsertPayeeAliasUnknownPayee(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetPayeeByID", mock.AnythingOfType("context.backgroundCtx"), int64(9)).Return(database.Payee{}, sql.ErrNoRows)
+
+	handler := handlers.PayeeAlias(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/payee/alias", bytes.NewBufferString(`{"payee_id":9,"alias":"AMZN"}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockQueries.AssertNotCalled(t, "InsertPayeeAlias", mock.Anything, mock.Anything)
+}
+
+// DELETE request /payee?id=someId
+func TestDeletePayeeNotFound(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InPayeeTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetPayeeByID", mock.AnythingOfType("context.backgroundCtx"), int64(9)).Return(database.Payee{}, sql.ErrNoRows)
+
+	handler := handlers.Payee(mockQueries)
+	req := httptest.NewRequest(http.MethodDelete, "/payee?id=9", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockQueries.AssertNotCalled(t, "DeletePayee", mock.Anything, mock.Anything)
+}
+
+func TestDeletePayeeClearsTransactions(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InPayeeTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetPayeeByID", mock.AnythingOfType("context.backgroundCtx"), int64(2)).Return(database.Payee{ID: 2, Name: "Amazon"}, nil)
+	mockQueries.On("GetPayeeTransactionIDs", mock.AnythingOfType("context.backgroundCtx"), sql.NullInt64{Int64: 2, Valid: true}).Return([]int64{5}, nil)
+	mockQueries.On("SetTransactionPayee", mock.AnythingOfType("context.backgroundCtx"), database.SetTransactionPayeeParams{ID: 5}).Return(nil)
+	mockQueries.On("InsertAuditEntry", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertAuditEntryParams) bool {
+		return arg.Entity == "transaction" && arg.EntityID == 5 && arg.Action == "update" &&
+			arg.Before.String == `{"payee_id":2}` && arg.After.String == `{"payee_id":null}`
+	})).Return(nil).Once()
+	mockQueries.On("DeletePayee", mock.AnythingOfType("context.backgroundCtx"), int64(2)).Return(nil)
+
+	handler := handlers.Payee(mockQueries)
+	req := httptest.NewRequest(http.MethodDelete, "/payee?id=2", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+// GET request /payee/report
+func TestPayeeReport(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetPayeeReport", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.GetPayeeReportParams) bool {
+		return arg.DateFrom.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	})).Return([]database.GetPayeeReportRow{{ID: 1, Name: "Amazon", Transactions: 3, Total: 54.5}}, nil)
+
+	handler := handlers.PayeeReport(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/payee/report?from=2026-01-01", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var report []handlers.PayeeReportResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	assert.Equal(t, []handlers.PayeeReportResource{{ID: 1, Name: "Amazon", Transactions: 3, Total: 54.5}}, report)
+	mockQueries.AssertExpectations(t)
+}
+
+// POST request /transaction with a payee not seen before
+func TestTransactionPOSTCreatesPayee(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockAudit(mockQueries)
+	mockQueries.On("GetPayeeIDByAlias", mock.AnythingOfType("context.backgroundCtx"), "corner bakery").Return(int64(0), sql.ErrNoRows)
+	mockQueries.On("InsertPayee", mock.AnythingOfType("context.backgroundCtx"), "Corner Bakery").Return(int64(5), nil)
+	mockQueries.On("InsertPayeeAlias", mock.AnythingOfType("context.backgroundCtx"), database.InsertPayeeAliasParams{PayeeID: 5, Alias: "Corner Bakery", Normalized: "corner bakery"}).Return(nil)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("InsertTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertTransactionParams) bool {
+		return arg.PayeeID == sql.NullInt64{Int64: 5, Valid: true}
+	})).Return(int64(8), nil)
+	mockNoRules(mockQueries)
+	mockExistingCategory(mockQueries)
+
+	handler := handlers.Transaction(mockQueries)
+	body := `{"name":"Bread","cost":3.2,"date":"2026-05-01T00:00:00Z","categories_id":1,"payee":"Corner Bakery"}`
+	req := httptest.NewRequest(http.MethodPost, "/transaction", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockQueries.AssertExpectations(t)
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"quattrinitrack/database"
+	"quattrinitrack/handlers"
+	middleware "quattrinitrack/middlewares"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Rules for small market purchases on Saturdays and anything sold by Amazon,
+// and one tagging big purchases for review
+var testRules = []database.Rule{
+	{
+		ID: 1, Name: "Weekend market", MatchField: "name", MatchType: "contains", Pattern: "market",
+		AmountMax: sql.NullFloat64{Float64: 50, Valid: true}, Weekdays: 1 << time.Saturday,
+		CategoriesID: sql.NullInt64{Int64: 2, Valid: true},
+	},
+	{
+		ID: 2, Name: "Amazon", MatchField: "payee", MatchType: "regex", Pattern: "^amazon",
+		CategoriesID: sql.NullInt64{Int64: 3, Valid: true},
+	},
+	{
+		ID: 3, Name: "Big purchases", MatchField: "name", MatchType: "contains",
+		AmountMin: sql.NullFloat64{Float64: 1000, Valid: true},
+	},
+}
+
+var testRuleTags = []database.GetRuleTagsRow{{RuleID: 3, TagID: 7, Name: "review"}}
+
+func mockRules(mockQueries *MockQueries) {
+	mockQueries.On("GetAllRules", mock.AnythingOfType("context.backgroundCtx")).Return(testRules, nil)
+	mockQueries.On("GetRuleTags", mock.AnythingOfType("context.backgroundCtx")).Return(testRuleTags, nil)
+}
+
+// POST request /transaction without a category
+func TestTransactionPOSTRuleConditions(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		category int64
+		tags     bool
+	}{
+		{"matches name, cost and day", `{"name":"Farmers Market","cost":12,"date":"2026-05-02T10:00:00Z"}`, 2, false},
+		{"wrong day", `{"name":"Farmers Market","cost":12,"date":"2026-05-04T10:00:00Z"}`, 9, false},
+		{"above the highest cost", `{"name":"Farmers Market","cost":60,"date":"2026-05-02T10:00:00Z"}`, 9, false},
+		{"matches payee", `{"name":"Book","cost":12,"date":"2026-05-04T10:00:00Z","payee":"Amazon"}`, 3, false},
+		{"only adds tags", `{"name":"Laptop","cost":1200,"date":"2026-05-04T10:00:00Z"}`, 9, true},
+		{"keeps the category sent", `{"name":"Farmers Market","cost":12,"date":"2026-05-02T10:00:00Z","categories_id":5}`, 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockQueries)
+			mockAudit(mockQueries)
+			mockRules(mockQueries)
+			mockExistingCategory(mockQueries)
+			mockQueries.On("GetPayeeIDByAlias", mock.AnythingOfType("context.backgroundCtx"), "amazon").Return(int64(4), nil)
+			mockQueries.On("GetPayeeIDByAlias", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(int64(0), sql.ErrNoRows)
+			mockQueries.On("GetPayeeByID", mock.AnythingOfType("context.backgroundCtx"), int64(4)).Return(database.Payee{ID: 4, Name: "Amazon"}, nil)
+			mockQueries.On("GetRootCategoryByName", mock.AnythingOfType("context.backgroundCtx"), "Uncategorized").Return(database.Category{ID: 9, Name: "Uncategorized"}, nil)
+			mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+			mockQueries.On("InsertTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertTransactionParams) bool {
+				return arg.CategoriesID == tt.category
+			})).Return(int64(10), nil)
+			if tt.tags {
+				mockQueries.On("EnsureTag", mock.AnythingOfType("context.backgroundCtx"), "review").Return(nil)
+				mockQueries.On("GetTagByName", mock.AnythingOfType("context.backgroundCtx"), "review").Return(database.Tag{ID: 7, Name: "review"}, nil)
+				mockQueries.On("TagTransaction", mock.AnythingOfType("context.backgroundCtx"), database.TagTransactionParams{TransactionID: 10, TagID: 7}).Return(nil)
+			}
+
+			handler := handlers.Transaction(mockQueries)
+			req := httptest.NewRequest(http.MethodPost, "/transaction", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			mockQueries.AssertCalled(t, "InsertTransaction", mock.Anything, mock.Anything)
+			if !tt.tags {
+				mockQueries.AssertNotCalled(t, "TagTransaction", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestTransactionPOSTCreatesUncategorized(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockAudit(mockQueries)
+	mockNoPayee(mockQueries)
+	mockNoRules(mockQueries)
+	mockExistingCategory(mockQueries)
+	mockQueries.On("GetRootCategoryByName", mock.AnythingOfType("context.backgroundCtx"), "Uncategorized").Return(database.Category{}, sql.ErrNoRows).Once()
+	mockQueries.On("EnsureRootCategory", mock.AnythingOfType("context.backgroundCtx"), "Uncategorized").Return(nil)
+	mockQueries.On("GetRootCategoryByName", mock.AnythingOfType("context.backgroundCtx"), "Uncategorized").Return(database.Category{ID: 9, Name: "Uncategorized"}, nil).Once()
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("InsertTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertTransactionParams) bool {
+		return arg.CategoriesID == 9
+	})).Return(int64(10), nil)
+
+	handler := handlers.Transaction(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/transaction", bytes.NewBufferString(`{"name":"Plumber","cost":80,"date":"2026-05-04T10:00:00Z"}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+// POST request /rule
+func TestInsertRuleWithTags(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InRuleTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("InsertRule", mock.AnythingOfType("context.backgroundCtx"), database.InsertRuleParams{
+		Name:       "Big purchases",
+		MatchField: "name",
+		MatchType:  "contains",
+		AmountMin:  sql.NullFloat64{Float64: 1000, Valid: true},
+		Weekdays:   1<<time.Saturday | 1<<time.Sunday,
+	}).Return(int64(3), nil)
+	mockQueries.On("EnsureTag", mock.AnythingOfType("context.backgroundCtx"), "review").Return(nil)
+	mockQueries.On("GetTagByName", mock.AnythingOfType("context.backgroundCtx"), "review").Return(database.Tag{ID: 7, Name: "review"}, nil)
+	mockQueries.On("AddRuleTag", mock.AnythingOfType("context.backgroundCtx"), database.AddRuleTagParams{RuleID: 3, TagID: 7}).Return(nil)
+
+	handler := handlers.Rule(mockQueries)
+	body := `{"name":"Big purchases","amount_min":1000,"weekdays":["Saturday","sunday"],"tags":["review"]}`
+	req := httptest.NewRequest(http.MethodPost, "/rule", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+// POST request /rule failing to tag the rule, which undoes its insert
+func TestInsertRuleTagError(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InRuleTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("InsertRule", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.InsertRuleParams")).Return(int64(3), nil)
+	mockQueries.On("EnsureTag", mock.AnythingOfType("context.backgroundCtx"), "review").Return(errors.New("database is locked"))
+
+	handler := handlers.Rule(mockQueries)
+	body := `{"name":"Big purchases","amount_min":1000,"tags":["review"]}`
+	req := httptest.NewRequest(http.MethodPost, "/rule", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeInternal, response.Error.Code)
+	mockQueries.AssertNotCalled(t, "AddRuleTag", mock.Anything, mock.Anything)
+}
+
+func TestInsertRuleValidationDetails(t *testing.T) {
+	mockQueries := new(MockQueries)
+
+	handler := handlers.Rule(mockQueries)
+	body := `{"name":"Broken","match_type":"regex","pattern":"(amzn","amount_min":30,"amount_max":10,"weekdays":["someday"]}`
+	req := httptest.NewRequest(http.MethodPost, "/rule", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, []middleware.FieldError{
+		{Field: "pattern", Message: "must be a valid regular expression"},
+		{Field: "amount_max", Message: "must not be less than amount_min"},
+		{Field: "weekdays", Message: "must be days of the week, such as monday"},
+		{Field: "categories_id", Message: "is required unless tags are given"},
+	}, response.Error.Details)
+	mockQueries.AssertNotCalled(t, "InsertRule", mock.Anything, mock.Anything)
+}
+
+// GET request /rule
+func TestGetAllRules(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockRules(mockQueries)
+
+	handler := handlers.Rule(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/rule", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var rules []handlers.RuleResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&rules))
+	assert.Len(t, rules, 3)
+	assert.Equal(t, []string{"saturday"}, rules[0].Weekdays)
+	assert.Nil(t, rules[0].AmountMin)
+	assert.Equal(t, 50.0, *rules[0].AmountMax)
+	assert.Nil(t, rules[2].CategoriesID)
+	assert.Equal(t, []string{"review"}, rules[2].Tags)
+	assert.Equal(t, []string{}, rules[1].Tags)
+	mockQueries.AssertExpectations(t)
+}
+
+func setupUncategorizedTest() *MockQueries {
+	mockQueries := new(MockQueries)
+	mockRules(mockQueries)
+	mockQueries.On("GetRootCategoryByName", mock.AnythingOfType("context.backgroundCtx"), "Uncategorized").Return(database.Category{ID: 9, Name: "Uncategorized"}, nil)
+	mockQueries.On("GetUncategorizedTransactions", mock.AnythingOfType("context.backgroundCtx"), int64(9)).Return([]database.GetUncategorizedTransactionsRow{
+		{ID: 20, Name: "Kindle book", Cost: 8, Date: time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC), CategoriesID: 9, PayeeName: sql.NullString{String: "Amazon", Valid: true}},
+		{ID: 21, Name: "Plumber", Cost: 80, Date: time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC), CategoriesID: 9},
+		{ID: 22, Name: "Sofa", Cost: 1500, Date: time.Date(2026, 5, 5, 0, 0, 0, 0, time.UTC), CategoriesID: 9},
+	}, nil)
+	mockQueries.On("GetTransactionTags", mock.AnythingOfType("context.backgroundCtx"), []int64{20, 22}).Return([]database.GetTransactionTagsRow{{TransactionID: 22, Name: "home"}}, nil)
+	mockQueries.On("GetAllCategories", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Category{{ID: 3, Name: "Books"}, {ID: 9, Name: "Uncategorized"}}, nil)
+	return mockQueries
+}
+
+// GET request /rule/preview
+func TestRulePreview(t *testing.T) {
+	mockQueries := setupUncategorizedTest()
+
+	handler := handlers.RulePreview(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/rule/preview", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var changes []handlers.RuleChangeResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&changes))
+	books := int64(3)
+	assert.Equal(t, []handlers.RuleChangeResource{
+		{TransactionID: 20, Name: "Kindle book", RuleID: 2, RuleName: "Amazon", CategoriesID: &books, CategoryName: "Books", Tags: []string{}},
+		{TransactionID: 22, Name: "Sofa", RuleID: 3, RuleName: "Big purchases", Tags: []string{"review"}},
+	}, changes)
+	mockQueries.AssertNotCalled(t, "SetTransactionCategory", mock.Anything, mock.Anything)
+	mockQueries.AssertNotCalled(t, "TagTransaction", mock.Anything, mock.Anything)
+}
+
+// A rule only adding tags, tried before one giving a category, does not keep
+// it from placing the transaction, and a match changing nothing is left out
+func TestRulePreviewTagsOnlyRuleFirst(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetAllRules", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Rule{
+		{ID: 3, Name: "Big purchases", MatchField: "name", MatchType: "contains", AmountMin: sql.NullFloat64{Float64: 1000, Valid: true}},
+		{ID: 4, Name: "Furniture", MatchField: "name", MatchType: "contains", Pattern: "sofa", CategoriesID: sql.NullInt64{Int64: 5, Valid: true}},
+	}, nil)
+	mockQueries.On("GetRuleTags", mock.AnythingOfType("context.backgroundCtx")).Return(testRuleTags, nil)
+	mockQueries.On("GetRootCategoryByName", mock.AnythingOfType("context.backgroundCtx"), "Uncategorized").Return(database.Category{ID: 9, Name: "Uncategorized"}, nil)
+	mockQueries.On("GetUncategorizedTransactions", mock.AnythingOfType("context.backgroundCtx"), int64(9)).Return([]database.GetUncategorizedTransactionsRow{
+		{ID: 22, Name: "Sofa", Cost: 1500, Date: time.Date(2026, 5, 5, 0, 0, 0, 0, time.UTC), CategoriesID: 9},
+		{ID: 23, Name: "Laptop", Cost: 1200, Date: time.Date(2026, 5, 5, 0, 0, 0, 0, time.UTC), CategoriesID: 9},
+	}, nil)
+	mockQueries.On("GetTransactionTags", mock.AnythingOfType("context.backgroundCtx"), []int64{22, 23}).Return([]database.GetTransactionTagsRow{{TransactionID: 23, Name: "review"}}, nil)
+	mockQueries.On("GetAllCategories", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Category{{ID: 5, Name: "Furniture"}, {ID: 9, Name: "Uncategorized"}}, nil)
+
+	handler := handlers.RulePreview(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/rule/preview", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var changes []handlers.RuleChangeResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&changes))
+	furniture := int64(5)
+	assert.Equal(t, []handlers.RuleChangeResource{
+		{TransactionID: 22, Name: "Sofa", RuleID: 4, RuleName: "Furniture", CategoriesID: &furniture, CategoryName: "Furniture", Tags: []string{"review"}},
+	}, changes)
+}
+
+func TestRulePreviewWithoutUncategorized(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetRootCategoryByName", mock.AnythingOfType("context.backgroundCtx"), "Uncategorized").Return(database.Category{}, sql.ErrNoRows)
+	mockQueries.On("GetAllCategories", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Category{}, nil)
+
+	handler := handlers.RulePreview(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/rule/preview", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
+
+// POST request /rule/apply
+func TestRuleApply(t *testing.T) {
+	mockQueries := setupUncategorizedTest()
+	mockQueries.On("InRuleTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("SetTransactionCategory", mock.AnythingOfType("context.backgroundCtx"), database.SetTransactionCategoryParams{CategoriesID: 3, ID: 20}).Return(nil)
+	mockQueries.On("TagTransaction", mock.AnythingOfType("context.backgroundCtx"), database.TagTransactionParams{TransactionID: 22, TagID: 7}).Return(nil)
+	mockQueries.On("InsertAuditEntry", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertAuditEntryParams) bool {
+		return arg.Entity == "transaction" && arg.EntityID == 20 && arg.Action == "update" &&
+			arg.Before.String == `{"categories_id":9}` && arg.After.String == `{"categories_id":3}`
+	})).Return(nil).Once()
+	mockQueries.On("InsertAuditEntry", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertAuditEntryParams) bool {
+		return arg.Entity == "transaction" && arg.EntityID == 22 && arg.Action == "update" &&
+			arg.Before.String == `{"tags":["home"]}` && arg.After.String == `{"tags":["home","review"]}`
+	})).Return(nil).Once()
+
+	handler := handlers.RuleApply(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/rule/apply", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockQueries.AssertExpectations(t)
+	mockQueries.AssertNumberOfCalls(t, "SetTransactionCategory", 1)
+}
+
+// POST request /rule/apply failing on a later match, which undoes the
+// earlier ones
+func TestRuleApplyError(t *testing.T) {
+	mockQueries := setupUncategorizedTest()
+	mockAudit(mockQueries)
+	mockQueries.On("InRuleTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("SetTransactionCategory", mock.AnythingOfType("context.backgroundCtx"), database.SetTransactionCategoryParams{CategoriesID: 3, ID: 20}).Return(nil)
+	mockQueries.On("TagTransaction", mock.AnythingOfType("context.backgroundCtx"), database.TagTransactionParams{TransactionID: 22, TagID: 7}).Return(errors.New("database is locked"))
+
+	handler := handlers.RuleApply(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/rule/apply", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeInternal, response.Error.Code)
+}
+
+// DELETE request /rule?id=someId
+func TestDeleteRuleNotFound(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetRuleByID", mock.AnythingOfType("context.backgroundCtx"), int64(9)).Return(database.Rule{}, sql.ErrNoRows)
+
+	handler := handlers.Rule(mockQueries)
+	req := httptest.NewRequest(http.MethodDelete, "/rule?id=9", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockQueries.AssertNotCalled(t, "DeleteRule", mock.Anything, mock.Anything)
+}
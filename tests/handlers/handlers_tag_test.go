@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"quattrinitrack/database"
+	"quattrinitrack/handlers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// GET request /tag
+func TestGetAllTagsSuccess(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetAllTags", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Tag{{ID: 1, Name: "gift"}, {ID: 2, Name: "vacation-2026"}}, nil)
+
+	handler := handlers.Tag(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/tag", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var tags []handlers.TagResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&tags))
+	assert.Equal(t, []handlers.TagResource{{ID: 1, Name: "gift"}, {ID: 2, Name: "vacation-2026"}}, tags)
+	mockQueries.AssertExpectations(t)
+}
+
+// POST request /tag
+func TestInsertTagConflict(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InsertTag", mock.AnythingOfType("context.backgroundCtx"), "gift").Return(errors.New("UNIQUE constraint failed"))
+
+	handler := handlers.Tag(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/tag", bytes.NewBufferString(`{"name":" gift "}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestInsertTagEmptyName(t *testing.T) {
+	mockQueries := new(MockQueries)
+
+	handler := handlers.Tag(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/tag", bytes.NewBufferString(`{"name":"  "}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockQueries.AssertNotCalled(t, "InsertTag", mock.Anything, mock.Anything)
+}
+
+// mockTagHolders makes tag 1, "gift", carried by transaction 4 along with
+// "home".
+func mockTagHolders(mockQueries *MockQueries) {
+	mockQueries.On("InTagTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetTagByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Tag{ID: 1, Name: "gift"}, nil)
+	mockQueries.On("GetTagTransactionIDs", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return([]int64{4}, nil)
+	mockQueries.On("GetTransactionTags", mock.AnythingOfType("context.backgroundCtx"), []int64{4}).Return([]database.GetTransactionTagsRow{
+		{TransactionID: 4, Name: "gift"},
+		{TransactionID: 4, Name: "home"},
+	}, nil)
+}
+
+// PUT request /tag?id=someId
+func TestRenameTagSuccess(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockTagHolders(mockQueries)
+	mockQueries.On("RenameTag", mock.AnythingOfType("context.backgroundCtx"), database.RenameTagParams{Name: "gifts", ID: 1}).Return(nil)
+	mockQueries.On("InsertAuditEntry", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertAuditEntryParams) bool {
+		return arg.Entity == "transaction" && arg.EntityID == 4 && arg.Action == "update" &&
+			arg.Before.String == `{"tags":["gift","home"]}` && arg.After.String == `{"tags":["gifts","home"]}`
+	})).Return(nil).Once()
+
+	handler := handlers.Tag(mockQueries)
+	req := httptest.NewRequest(http.MethodPut, "/tag?id=1", bytes.NewBufferString(`{"name":"gifts"}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestRenameTagNotFound(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InTagTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetTagByID", mock.AnythingOfType("context.backgroundCtx"), int64(9)).Return(database.Tag{}, sql.ErrNoRows)
+
+	handler := handlers.Tag(mockQueries)
+	req := httptest.NewRequest(http.MethodPut, "/tag?id=9", bytes.NewBufferString(`{"name":"gifts"}`))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockQueries.AssertNotCalled(t, "RenameTag", mock.Anything, mock.Anything)
+}
+
+// DELETE request /tag?id=someId
+func TestDeleteTagSuccess(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockTagHolders(mockQueries)
+	mockQueries.On("DeleteTag", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(nil)
+	mockQueries.On("InsertAuditEntry", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertAuditEntryParams) bool {
+		return arg.Entity == "transaction" && arg.EntityID == 4 && arg.Action == "update" &&
+			arg.Before.String == `{"tags":["gift","home"]}` && arg.After.String == `{"tags":["home"]}`
+	})).Return(nil).Once()
+
+	handler := handlers.Tag(mockQueries)
+	req := httptest.NewRequest(http.MethodDelete, "/tag?id=1", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+// GET request /tag/report
+func TestTagReport(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetTagReport", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.GetTagReportParams) bool {
+		return arg.DateFrom.Equal(time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+	})).Return([]database.GetTagReportRow{{ID: 2, Name: "vacation-2026", Transactions: 3, Total: 1250.5}}, nil)
+
+	handler := handlers.TagReport(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/tag/report?from=2026-01-01", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var report []handlers.TagReportResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+	assert.Equal(t, []handlers.TagReportResource{{ID: 2, Name: "vacation-2026", Transactions: 3, Total: 1250.5}}, report)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestTagReportInvalidDate(t *testing.T) {
+	mockQueries := new(MockQueries)
+
+	handler := handlers.TagReport(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/tag/report?to=soon", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockQueries.AssertNotCalled(t, "GetTagReport", mock.Anything, mock.Anything)
+}
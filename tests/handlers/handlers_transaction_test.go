@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"os"
 	"quattrinitrack/database"
 	"quattrinitrack/handlers"
+	middleware "quattrinitrack/middlewares"
 	"testing"
 	"time"
 
@@ -41,7 +43,7 @@ func TestTransactionGETHeaderAndCode(t *testing.T) {
 }
 
 func TestTransactionGETResponse(t *testing.T) {
-	var responseTransactions []database.Transaction
+	var responseTransactions []handlers.TransactionResource
 	w, _, expectedTransactions, mockQueries := setupTransactionGetTest()
 
 	err := json.NewDecoder(w.Body).Decode(&responseTransactions)
@@ -53,6 +55,8 @@ func TestTransactionGETResponse(t *testing.T) {
 		assert.Equal(t, expected.Name, actual.Name, "at index %d", i)
 		assert.Equal(t, expected.Cost, actual.Cost, "at index %d", i)
 		assert.WithinDuration(t, expected.Date, actual.Date, time.Second, "at index %d", i)
+		assert.Equal(t, expected.CategoriesID, actual.CategoriesID, "at index %d", i)
+		assert.Equal(t, "Food", actual.CategoryName, "at index %d", i)
 	}
 
 	mockQueries.AssertExpectations(t)
@@ -60,11 +64,12 @@ func TestTransactionGETResponse(t *testing.T) {
 
 // getTransactionByID /transaction/?id=someid
 func TestGetTransactionByIDSuccess(t *testing.T) {
-	var actualTransaction database.Transaction
+	var actualTransaction handlers.TransactionResource
 	_, _, expectedTransactions, _ := setupTransactionGetTest()
 
 	mockQueries := new(MockQueries)
 	mockQueries.On("GetTransactionByID", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(expectedTransactions[0], nil)
+	mockLookups(mockQueries)
 
 	handler := handlers.Transaction(mockQueries)
 	req := httptest.NewRequest("GET", "/transaction/?id=1", nil)
@@ -79,6 +84,7 @@ func TestGetTransactionByIDSuccess(t *testing.T) {
 	assert.Equal(t, expectedTransactions[0].Name, actualTransaction.Name)
 	assert.Equal(t, expectedTransactions[0].Cost, actualTransaction.Cost)
 	assert.WithinDuration(t, expectedTransactions[0].Date, actualTransaction.Date, time.Second)
+	assert.Equal(t, "Food", actualTransaction.CategoryName)
 }
 
 func TestGetTransactionByIDError(t *testing.T) {
@@ -95,11 +101,12 @@ func TestGetTransactionByIDError(t *testing.T) {
 
 // getTransactionByName /transaction/?name=someName
 func TestGetTransactionByNameSuccess(t *testing.T) {
-	var actualTransaction []database.Transaction
+	var actualTransaction []handlers.TransactionResource
 	_, _, expectedTransactions, _ := setupTransactionGetTest()
 
 	mockQueries := new(MockQueries)
 	mockQueries.On("GetTransactionByName", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(expectedTransactions, nil)
+	mockLookups(mockQueries)
 
 	handler := handlers.Transaction(mockQueries)
 	req := httptest.NewRequest("GET", "/transaction/?name=Coffee", nil)
@@ -131,12 +138,13 @@ func TestGetTransactionByNameError(t *testing.T) {
 
 func setupTransactionGetTest() (*httptest.ResponseRecorder, *http.Request, []database.Transaction, *MockQueries) {
 	expectedTransactions := []database.Transaction{
-		{Name: "Coffee", Cost: 5.50, Date: time.Now()},
-		{Name: "Hamburger", Cost: 12.00, Date: time.Now()},
+		{Name: "Coffee", Cost: 5.50, Date: time.Now(), CategoriesID: 1},
+		{Name: "Hamburger", Cost: 12.00, Date: time.Now(), CategoriesID: 1},
 	}
 
 	mockQueries := new(MockQueries)
 	mockQueries.On("GetAllTransactions", mock.AnythingOfType("context.backgroundCtx")).Return(expectedTransactions, nil)
+	mockLookups(mockQueries)
 
 	handler := handlers.Transaction(mockQueries)
 	req := httptest.NewRequest("GET", "/transaction", nil)
@@ -147,6 +155,107 @@ func setupTransactionGetTest() (*httptest.ResponseRecorder, *http.Request, []dat
 	return w, req, expectedTransactions, mockQueries
 }
 
+// mockLookups sets up the categories and tags looked up to describe the
+// transactions returned. The payees are only looked up when there are some.
+func mockLookups(mockQueries *MockQueries) {
+	mockQueries.On("GetCategoriesByIDs", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("[]int64")).Return([]database.Category{{ID: 1, Name: "Food"}}, nil)
+	mockQueries.On("GetTransactionTags", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("[]int64")).Return([]database.GetTransactionTagsRow{{TransactionID: 1, Name: "gift"}}, nil)
+	mockQueries.On("GetTransactionSplits", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("[]int64")).Return([]database.TransactionSplit{}, nil)
+	mockQueries.On("GetPayeesByIDs", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("[]int64")).Return([]database.Payee{}, nil).Maybe()
+	mockQueries.On("GetAttachments", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("[]int64")).Return([]database.GetAttachmentsRow{}, nil)
+}
+
+// mockNoPayee mocks the lookup of a payee matching nothing.
+func mockNoPayee(mockQueries *MockQueries) {
+	mockQueries.On("GetPayeeIDByAlias", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("string")).Return(int64(0), sql.ErrNoRows)
+}
+
+// mockNoRules mocks the loading of the rules when there are none.
+func mockNoRules(mockQueries *MockQueries) {
+	mockQueries.On("GetAllRules", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Rule{}, nil)
+	mockQueries.On("GetRuleTags", mock.AnythingOfType("context.backgroundCtx")).Return([]database.GetRuleTagsRow{}, nil)
+}
+
+// mockExistingCategory mocks the lookup of the category a transaction is
+// created with, which exists whatever its ID.
+func mockExistingCategory(mockQueries *MockQueries) {
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(database.Category{ID: 1, Name: "Food"}, nil).Maybe()
+}
+
+// getTransactionsPage /transaction?limit=..&offset=..&sort=..&order=..&q=..
+func TestGetTransactionsPageSuccess(t *testing.T) {
+	var actualTransactions []handlers.TransactionResource
+	_, _, expectedTransactions, _ := setupTransactionGetTest()
+
+	mockQueries := new(MockQueries)
+	mockQueries.On("CountTransactions", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.CountTransactionsParams) bool {
+		return arg.Pattern == "%c%f%"
+	})).Return(int64(42), nil)
+	mockQueries.On("GetTransactionsPage", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.GetTransactionsPageParams) bool {
+		return arg.Pattern == "%c%f%" && arg.SortKey == "cost" && arg.Descending && arg.PageLimit == 2 && arg.PageOffset == 4
+	})).Return(expectedTransactions, nil)
+	mockLookups(mockQueries)
+
+	handler := handlers.Transaction(mockQueries)
+	req := httptest.NewRequest("GET", "/transaction?limit=2&offset=4&sort=cost&order=desc&q=cf", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "42", w.Header().Get("X-Total-Count"))
+	err := json.NewDecoder(w.Body).Decode(&actualTransactions)
+	assert.NoError(t, err)
+	assert.Len(t, actualTransactions, len(expectedTransactions))
+	mockQueries.AssertExpectations(t)
+}
+
+func TestGetTransactionsByTag(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("CountTransactions", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.CountTransactionsParams) bool {
+		return arg.Tag == "gift"
+	})).Return(int64(1), nil)
+	mockQueries.On("GetTransactionsPage", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.GetTransactionsPageParams) bool {
+		return arg.Tag == "gift" && arg.PageLimit == 100
+	})).Return([]database.Transaction{{ID: 1, Name: "Flowers", Cost: 20, Date: time.Now(), CategoriesID: 1}}, nil)
+	mockLookups(mockQueries)
+
+	handler := handlers.Transaction(mockQueries)
+	req := httptest.NewRequest("GET", "/transaction?tag=gift", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var actualTransactions []handlers.TransactionResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&actualTransactions))
+	assert.Len(t, actualTransactions, 1)
+	assert.Equal(t, []string{"gift"}, actualTransactions[0].Tags)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestGetTransactionsPageInvalidSort(t *testing.T) {
+	mockQueries := new(MockQueries)
+
+	handler := handlers.Transaction(mockQueries)
+	req := httptest.NewRequest("GET", "/transaction?sort=color", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockQueries.AssertNotCalled(t, "GetTransactionsPage", mock.Anything, mock.Anything)
+}
+
+func TestGetTransactionsPageInvalidLimit(t *testing.T) {
+	mockQueries := new(MockQueries)
+
+	handler := handlers.Transaction(mockQueries)
+	req := httptest.NewRequest("GET", "/transaction?limit=0", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockQueries.AssertNotCalled(t, "GetTransactionsPage", mock.Anything, mock.Anything)
+}
+
 // POST request /transaction
 func TestTransactionPOSTHeaderAndCode(t *testing.T) {
 	w, _, _, mockQueries := setupTransactionPostTest()
@@ -211,15 +320,235 @@ func TestTransactionPOSTInvalidJSONDate(t *testing.T) {
 	mockQueries.AssertExpectations(t)
 }
 
-func setupTransactionPostTest() (*httptest.ResponseRecorder, *http.Request, database.Transaction, *MockQueries) {
-	transaction := database.Transaction{
-		Name: "test",
-		Cost: 100.99,
-		Date: time.Now(),
+func TestTransactionPOSTWithTags(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockAudit(mockQueries)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("InsertTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.InsertTransactionParams")).Return(int64(7), nil)
+	mockNoPayee(mockQueries)
+	mockNoRules(mockQueries)
+	mockExistingCategory(mockQueries)
+	for id, name := range []string{"gift", "reimbursable"} {
+		mockQueries.On("EnsureTag", mock.AnythingOfType("context.backgroundCtx"), name).Return(nil).Once()
+		mockQueries.On("GetTagByName", mock.AnythingOfType("context.backgroundCtx"), name).Return(database.Tag{ID: int64(id + 1), Name: name}, nil).Once()
+		mockQueries.On("TagTransaction", mock.AnythingOfType("context.backgroundCtx"), database.TagTransactionParams{TransactionID: 7, TagID: int64(id + 1)}).Return(nil).Once()
+	}
+
+	body := `{"name":"Flowers","cost":20,"date":"2026-05-01T00:00:00Z","categories_id":1,"tags":["gift"," reimbursable ",""]}`
+	req := httptest.NewRequest("POST", "/transaction", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler := handlers.Transaction(mockQueries)
+	handler(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestTransactionPOSTWithSplits(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockAudit(mockQueries)
+	mockQueries.On("GetAllCategories", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Category{{ID: 1, Name: "Groceries"}, {ID: 2, Name: "Household"}}, nil)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("InsertTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.InsertTransactionParams) bool {
+		return arg.CategoriesID == 2
+	})).Return(int64(7), nil)
+	mockQueries.On("InsertTransactionSplit", mock.AnythingOfType("context.backgroundCtx"), database.InsertTransactionSplitParams{TransactionID: 7, CategoriesID: 2, Amount: 12.3}).Return(nil).Once()
+	mockQueries.On("InsertTransactionSplit", mock.AnythingOfType("context.backgroundCtx"), database.InsertTransactionSplitParams{TransactionID: 7, CategoriesID: 1, Amount: 30.1}).Return(nil).Once()
+	mockNoPayee(mockQueries)
+	mockNoRules(mockQueries)
+	mockExistingCategory(mockQueries)
+
+	body := `{"name":"Supermarket","cost":42.4,"date":"2026-05-01T00:00:00Z","splits":[{"categories_id":2,"amount":12.3},{"categories_id":1,"amount":30.1}]}`
+	req := httptest.NewRequest("POST", "/transaction", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler := handlers.Transaction(mockQueries)
+	handler(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+// POST request /transaction failing part way, after which nothing is kept
+func TestTransactionPOSTTxError(t *testing.T) {
+	tests := []struct {
+		name    string
+		beginTx error
+		tagErr  error
+	}{
+		{"transaction not begun", errors.New("database is locked"), nil},
+		{"tagging fails", nil, errors.New("disk full")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockQueries)
+			mockAudit(mockQueries)
+			mockNoPayee(mockQueries)
+			mockNoRules(mockQueries)
+			mockExistingCategory(mockQueries)
+			mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(tt.beginTx)
+			mockQueries.On("InsertTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.InsertTransactionParams")).Return(int64(7), nil).Maybe()
+			mockQueries.On("EnsureTag", mock.AnythingOfType("context.backgroundCtx"), "gift").Return(tt.tagErr).Maybe()
+
+			body := `{"name":"Flowers","cost":20,"date":"2026-05-01T00:00:00Z","categories_id":1,"tags":["gift"]}`
+			req := httptest.NewRequest("POST", "/transaction", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+			handler := handlers.Transaction(mockQueries)
+			handler(w, req)
+
+			var response middleware.ErrorResponse
+			assert.Equal(t, http.StatusInternalServerError, w.Code)
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			assert.Equal(t, middleware.CodeInternal, response.Error.Code)
+			if tt.beginTx != nil {
+				mockQueries.AssertNotCalled(t, "InsertTransaction", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestTransactionPOSTSplitsNotAddingUp(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetAllCategories", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Category{{ID: 1, Name: "Groceries"}, {ID: 2, Name: "Household"}}, nil)
+
+	body := `{"name":"Supermarket","cost":42.4,"date":"2026-05-01T00:00:00Z","splits":[{"categories_id":2,"amount":12.3},{"categories_id":1,"amount":30}]}`
+	req := httptest.NewRequest("POST", "/transaction", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler := handlers.Transaction(mockQueries)
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, []middleware.FieldError{{Field: "splits", Message: "must add up to the cost"}}, response.Error.Details)
+	mockQueries.AssertNotCalled(t, "InsertTransaction", mock.Anything, mock.Anything)
+}
+
+func TestTransactionPOSTSplitUnknownCategory(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetAllCategories", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Category{{ID: 1, Name: "Groceries"}}, nil)
+
+	body := `{"name":"Supermarket","cost":20,"date":"2026-05-01T00:00:00Z","splits":[{"categories_id":1,"amount":10},{"categories_id":9,"amount":10}]}`
+	req := httptest.NewRequest("POST", "/transaction", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler := handlers.Transaction(mockQueries)
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, []middleware.FieldError{{Field: "splits[1].categories_id", Message: "must be an existing category"}}, response.Error.Details)
+	mockQueries.AssertNotCalled(t, "InsertTransaction", mock.Anything, mock.Anything)
+}
+
+// POST request /transaction with a category that does not exist, or is in
+// the trash, which the lookup leaves out
+func TestTransactionPOSTMissingCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		category int64
+	}{
+		{"unknown", 9},
+		{"in the trash", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockQueries := new(MockQueries)
+			mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+			mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), tt.category).Return(database.Category{}, sql.ErrNoRows)
+
+			body := fmt.Sprintf(`{"name":"Flowers","cost":20,"date":"2026-05-01T00:00:00Z","categories_id":%d}`, tt.category)
+			req := httptest.NewRequest("POST", "/transaction", bytes.NewBufferString(body))
+			w := httptest.NewRecorder()
+			handler := handlers.Transaction(mockQueries)
+			handler(w, req)
+
+			var response middleware.ErrorResponse
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			assert.Equal(t, middleware.CodeValidation, response.Error.Code)
+			assert.Equal(t, []middleware.FieldError{{Field: "categories_id", Message: "must be an existing category"}}, response.Error.Details)
+			mockQueries.AssertNotCalled(t, "InsertTransaction", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestGetTransactionWithSplits(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("GetTransactionByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Transaction{ID: 3, Name: "Supermarket", Cost: 20, CategoriesID: 1}, nil)
+	mockQueries.On("GetTransactionTags", mock.AnythingOfType("context.backgroundCtx"), []int64{3}).Return([]database.GetTransactionTagsRow{}, nil)
+	mockQueries.On("GetTransactionSplits", mock.AnythingOfType("context.backgroundCtx"), []int64{3}).Return([]database.TransactionSplit{
+		{ID: 1, TransactionID: 3, CategoriesID: 1, Amount: 15},
+		{ID: 2, TransactionID: 3, CategoriesID: 2, Amount: 5},
+	}, nil)
+	// Only the categories of the transaction and of its lines are looked up
+	mockQueries.On("GetCategoriesByIDs", mock.AnythingOfType("context.backgroundCtx"), []int64{1, 2}).Return([]database.Category{{ID: 1, Name: "Groceries"}, {ID: 2, Name: "Household"}}, nil)
+	mockQueries.On("GetAttachments", mock.AnythingOfType("context.backgroundCtx"), []int64{3}).Return([]database.GetAttachmentsRow{}, nil)
+
+	req := httptest.NewRequest("GET", "/transaction?id=3", nil)
+	w := httptest.NewRecorder()
+	handler := handlers.Transaction(mockQueries)
+	handler(w, req)
+
+	var transaction handlers.TransactionResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&transaction))
+	assert.Equal(t, []handlers.SplitResource{
+		{CategoriesID: 1, CategoryName: "Groceries", Amount: 15},
+		{CategoriesID: 2, CategoryName: "Household", Amount: 5},
+	}, transaction.Splits)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestTransactionPOSTValidationDetails(t *testing.T) {
+	mockQueries := new(MockQueries)
+	req := httptest.NewRequest("POST", "/transaction", bytes.NewBufferString(`{"Name":"","Cost":-3,"Date":"2024-01-02T00:00:00Z"}`))
+	w := httptest.NewRecorder()
+	handler := handlers.Transaction(mockQueries)
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeValidation, response.Error.Code)
+	assert.Equal(t, []middleware.FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "cost", Message: "must be greater than 0"},
+	}, response.Error.Details)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestDeleteTransactionInvalidID(t *testing.T) {
+	mockQueries := new(MockQueries)
+	req := httptest.NewRequest("DELETE", "/transaction?id=abc", nil)
+	w := httptest.NewRecorder()
+	handler := handlers.Transaction(mockQueries)
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeBadRequest, response.Error.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+func setupTransactionPostTest() (*httptest.ResponseRecorder, *http.Request, handlers.NewTransaction, *MockQueries) {
+	transaction := handlers.NewTransaction{
+		Name:         "test",
+		Cost:         100.99,
+		Date:         time.Now(),
+		CategoriesID: 1,
 	}
 
 	mockQueries := new(MockQueries)
-	mockQueries.On("InsertTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.InsertTransactionParams")).Return(nil)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("InsertTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.InsertTransactionParams")).Return(int64(1), nil)
+	mockNoPayee(mockQueries)
+	mockNoRules(mockQueries)
+	mockExistingCategory(mockQueries)
+	mockAudit(mockQueries)
 
 	handler := handlers.Transaction(mockQueries)
 	jsonData, _ := json.Marshal(transaction)
@@ -235,9 +564,13 @@ func setupTransactionPostTest() (*httptest.ResponseRecorder, *http.Request, data
 func TestDeleteTransactionSuccess(t *testing.T) {
 	_, _, expectedTransactions, _ := setupTransactionGetTest()
 	mockQueries := new(MockQueries)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockAudit(mockQueries)
 
 	mockQueries.On("GetTransactionByID", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(expectedTransactions[0], nil)
-	mockQueries.On("DeleteTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(nil)
+	mockQueries.On("TrashTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.MatchedBy(func(arg database.TrashTransactionParams) bool {
+		return arg.ID == 1 && arg.DeletedAt.Valid
+	})).Return(nil)
 
 	handler := handlers.Transaction(mockQueries)
 	req := httptest.NewRequest("DELETE", "/transaction/?id=1", nil)
@@ -251,6 +584,7 @@ func TestDeleteTransactionSuccess(t *testing.T) {
 
 func TestDeleteTransactionNotFound(t *testing.T) {
 	mockQueries := new(MockQueries)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
 
 	mockQueries.On("GetTransactionByID", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(database.Transaction{}, sql.ErrNoRows)
 
@@ -259,25 +593,47 @@ func TestDeleteTransactionNotFound(t *testing.T) {
 	w := httptest.NewRecorder()
 	handler(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeNotFound, response.Error.Code)
 
 	mockQueries.AssertExpectations(t)
 }
 
+// A failing lookup is not taken for a missing transaction
+func TestDeleteTransactionLookupError(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetTransactionByID", mock.AnythingOfType("context.backgroundCtx"), int64(1)).Return(database.Transaction{}, errors.New("database is locked"))
+
+	handler := handlers.Transaction(mockQueries)
+	req := httptest.NewRequest("DELETE", "/transaction/?id=1", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeInternal, response.Error.Code)
+	mockQueries.AssertNotCalled(t, "TrashTransaction", mock.Anything, mock.Anything)
+}
+
 func TestDeleteTransactionDeleteError(t *testing.T) {
 	_, _, expectedTransactions, _ := setupTransactionGetTest()
 	mockQueries := new(MockQueries)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
 
 	mockQueries.On("GetTransactionByID", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(expectedTransactions[0], nil)
 
-	mockQueries.On("DeleteTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("int64")).Return(errors.New("database error"))
+	mockQueries.On("TrashTransaction", mock.AnythingOfType("context.backgroundCtx"), mock.AnythingOfType("database.TrashTransactionParams")).Return(errors.New("database error"))
 
 	handler := handlers.Transaction(mockQueries)
 	req := httptest.NewRequest("DELETE", "/transaction/?id=1", nil)
 	w := httptest.NewRecorder()
 	handler(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
 
 	mockQueries.AssertExpectations(t)
 }
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"quattrinitrack/database"
+	"quattrinitrack/handlers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// GET request /trash
+func TestTrashListsDeletedItems(t *testing.T) {
+	deletedAt := time.Date(2026, 5, 2, 10, 0, 0, 0, time.UTC)
+	mockQueries := new(MockQueries)
+	mockLookups(mockQueries)
+	mockQueries.On("GetTrashedTransactions", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Transaction{
+		{ID: 3, Name: "Concert", Cost: 40, CategoriesID: 5, DeletedAt: sql.NullTime{Time: deletedAt, Valid: true}},
+	}, nil)
+	mockQueries.On("GetTrashedCategories", mock.AnythingOfType("context.backgroundCtx")).Return([]database.Category{
+		{ID: 5, Name: "Music", DeletedAt: sql.NullTime{Time: deletedAt, Valid: true}},
+	}, nil)
+
+	handler := handlers.Trash(mockQueries)
+	req := httptest.NewRequest(http.MethodGet, "/trash", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	var trash handlers.TrashResource
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&trash))
+	assert.Len(t, trash.Transactions, 1)
+	// The name of the trashed category is still shown
+	assert.Equal(t, "Music", trash.Transactions[0].CategoryName)
+	assert.Equal(t, deletedAt, trash.Transactions[0].DeletedAt)
+	assert.Equal(t, deletedAt.Add(30*24*time.Hour), *trash.Transactions[0].PurgeAt)
+	assert.Len(t, trash.Categories, 1)
+	assert.Equal(t, "Music", trash.Categories[0].Name)
+	mockQueries.AssertExpectations(t)
+}
+
+// POST request /transaction/restore?id=someId
+func TestTransactionRestoreSuccess(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockAudit(mockQueries)
+	mockQueries.On("GetTrashedTransactionByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Transaction{ID: 3}, nil)
+	mockQueries.On("CountTrashedTransactionCategories", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(int64(0), nil)
+	mockQueries.On("RestoreTransaction", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(nil)
+
+	handler := handlers.TransactionRestore(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/transaction/restore?id=3", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockQueries.AssertExpectations(t)
+}
+
+func TestTransactionRestoreNotInTrash(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetTrashedTransactionByID", mock.AnythingOfType("context.backgroundCtx"), int64(9)).Return(database.Transaction{}, sql.ErrNoRows)
+
+	handler := handlers.TransactionRestore(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/transaction/restore?id=9", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockQueries.AssertNotCalled(t, "RestoreTransaction", mock.Anything, mock.Anything)
+}
+
+func TestTransactionRestoreCategoryTrashed(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InTransactionTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetTrashedTransactionByID", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(database.Transaction{ID: 3}, nil)
+	mockQueries.On("CountTrashedTransactionCategories", mock.AnythingOfType("context.backgroundCtx"), int64(3)).Return(int64(1), nil)
+
+	handler := handlers.TransactionRestore(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/transaction/restore?id=3", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockQueries.AssertNotCalled(t, "RestoreTransaction", mock.Anything, mock.Anything)
+}
+
+// POST request /category/restore?id=someId
+func TestCategoryRestoreParentTrashed(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetTrashedCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(6)).Return(database.Category{ID: 6, Name: "Concerts", ParentID: sql.NullInt64{Int64: 5, Valid: true}}, nil)
+	mockQueries.On("GetCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(5)).Return(database.Category{}, sql.ErrNoRows)
+
+	handler := handlers.CategoryRestore(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/category/restore?id=6", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockQueries.AssertNotCalled(t, "RestoreCategory", mock.Anything, mock.Anything)
+}
+
+func TestCategoryRestoreNameTaken(t *testing.T) {
+	mockQueries := new(MockQueries)
+	mockQueries.On("InCategoryTx", mock.AnythingOfType("context.backgroundCtx")).Return(nil)
+	mockQueries.On("GetTrashedCategoryByID", mock.AnythingOfType("context.backgroundCtx"), int64(5)).Return(database.Category{ID: 5, Name: "Music"}, nil)
+	mockQueries.On("RestoreCategory", mock.AnythingOfType("context.backgroundCtx"), int64(5)).Return(errors.New("UNIQUE constraint failed"))
+
+	handler := handlers.CategoryRestore(mockQueries)
+	req := httptest.NewRequest(http.MethodPost, "/category/restore?id=5", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockQueries.AssertExpectations(t)
+}
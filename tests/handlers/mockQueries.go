@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"quattrinitrack/database"
+	"quattrinitrack/handlers"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -33,13 +35,33 @@ func (m *MockQueries) GetTransactionByCategoryID(ctx context.Context, categoryID
 	return args.Get(0).([]database.Transaction), args.Error(1)
 }
 
-func (m *MockQueries) DeleteTransaction(ctx context.Context, id int64) error {
-	args := m.Called(ctx, id)
+func (m *MockQueries) GetTransactionsPage(ctx context.Context, arg database.GetTransactionsPageParams) ([]database.Transaction, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.Transaction), args.Error(1)
+}
+
+func (m *MockQueries) CountTransactions(ctx context.Context, arg database.CountTransactionsParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) TrashTransaction(ctx context.Context, arg database.TrashTransactionParams) error {
+	args := m.Called(ctx, arg)
 	return args.Error(0)
 }
 
-func (m *MockQueries) InsertTransaction(ctx context.Context, params database.InsertTransactionParams) error {
+func (m *MockQueries) InsertTransaction(ctx context.Context, params database.InsertTransactionParams) (int64, error) {
 	args := m.Called(ctx, params)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) GetTransactionSplits(ctx context.Context, ids []int64) ([]database.TransactionSplit, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]database.TransactionSplit), args.Error(1)
+}
+
+func (m *MockQueries) InsertTransactionSplit(ctx context.Context, arg database.InsertTransactionSplitParams) error {
+	args := m.Called(ctx, arg)
 	return args.Error(0)
 }
 
@@ -50,17 +72,375 @@ func (m *MockQueries) GetAllCategories(ctx context.Context) ([]database.Category
 	return args.Get(0).([]database.Category), args.Error(1)
 }
 
+func (m *MockQueries) GetCategoriesByIDs(ctx context.Context, ids []int64) ([]database.Category, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]database.Category), args.Error(1)
+}
+
 func (m *MockQueries) GetCategoryByID(ctx context.Context, id int64) (database.Category, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(database.Category), args.Error(1)
 }
 
-func (m *MockQueries) DeleteCategory(ctx context.Context, id int64) error {
+func (m *MockQueries) GetCategorySamples(ctx context.Context, name string) ([]database.GetCategorySamplesRow, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).([]database.GetCategorySamplesRow), args.Error(1)
+}
+
+func (m *MockQueries) CountCategoryUses(ctx context.Context, id int64) (database.CountCategoryUsesRow, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.CountCategoryUsesRow), args.Error(1)
+}
+
+func (m *MockQueries) TrashCategory(ctx context.Context, arg database.TrashCategoryParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) InsertCategory(ctx context.Context, arg database.InsertCategoryParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) MoveCategoryTransactions(ctx context.Context, arg database.MoveCategoryTransactionsParams) ([]int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockQueries) MoveCategorySplits(ctx context.Context, arg database.MoveCategorySplitsParams) ([]int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockQueries) MoveSubcategories(ctx context.Context, arg database.MoveSubcategoriesParams) ([]int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockQueries) MoveCategoryRules(ctx context.Context, arg database.MoveCategoryRulesParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+// InCategoryTx runs fn on the mock itself, unless the transaction is set up to
+// fail to begin.
+func (m *MockQueries) InCategoryTx(ctx context.Context, fn func(handlers.CategoryTxQuerier) error) error {
+	args := m.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+// InTransactionTx runs fn on the mock itself, like InCategoryTx.
+func (m *MockQueries) InTransactionTx(ctx context.Context, fn func(handlers.TransactionTxQuerier) error) error {
+	args := m.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+// InRuleTx runs fn on the mock itself, like InCategoryTx.
+func (m *MockQueries) InRuleTx(ctx context.Context, fn func(handlers.RuleTxQuerier) error) error {
+	args := m.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+// InPayeeTx runs fn on the mock itself, like InCategoryTx.
+func (m *MockQueries) InPayeeTx(ctx context.Context, fn func(handlers.PayeeTxQuerier) error) error {
+	args := m.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+// InTagTx runs fn on the mock itself, like InCategoryTx.
+func (m *MockQueries) InTagTx(ctx context.Context, fn func(handlers.TagTxQuerier) error) error {
+	args := m.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+func (m *MockQueries) UpdateCategory(ctx context.Context, arg database.UpdateCategoryParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetCategoryAncestors(ctx context.Context, id int64) ([]int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockQueries) GetCategoryReport(ctx context.Context, arg database.GetCategoryReportParams) ([]database.GetCategoryReportRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetCategoryReportRow), args.Error(1)
+}
+
+// Tags
+
+func (m *MockQueries) GetAllTags(ctx context.Context) ([]database.Tag, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.Tag), args.Error(1)
+}
+
+func (m *MockQueries) GetTagByID(ctx context.Context, id int64) (database.Tag, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.Tag), args.Error(1)
+}
+
+func (m *MockQueries) GetTagByName(ctx context.Context, name string) (database.Tag, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(database.Tag), args.Error(1)
+}
+
+func (m *MockQueries) InsertTag(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockQueries) EnsureTag(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockQueries) RenameTag(ctx context.Context, arg database.RenameTagParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) DeleteTag(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockQueries) TagTransaction(ctx context.Context, arg database.TagTransactionParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetTransactionTags(ctx context.Context, ids []int64) ([]database.GetTransactionTagsRow, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]database.GetTransactionTagsRow), args.Error(1)
+}
+
+func (m *MockQueries) GetTagTransactionIDs(ctx context.Context, tagID int64) ([]int64, error) {
+	args := m.Called(ctx, tagID)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockQueries) GetTagReport(ctx context.Context, arg database.GetTagReportParams) ([]database.GetTagReportRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetTagReportRow), args.Error(1)
+}
+
+// Payees
+
+func (m *MockQueries) GetAllPayees(ctx context.Context) ([]database.Payee, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.Payee), args.Error(1)
+}
+
+func (m *MockQueries) GetPayeesByIDs(ctx context.Context, ids []int64) ([]database.Payee, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]database.Payee), args.Error(1)
+}
+
+func (m *MockQueries) GetPayeeByID(ctx context.Context, id int64) (database.Payee, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.Payee), args.Error(1)
+}
+
+func (m *MockQueries) GetPayeeTransactionIDs(ctx context.Context, payeeID sql.NullInt64) ([]int64, error) {
+	args := m.Called(ctx, payeeID)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockQueries) InsertPayee(ctx context.Context, name string) (int64, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) DeletePayee(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetPayeeAliases(ctx context.Context) ([]database.PayeeAlias, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.PayeeAlias), args.Error(1)
+}
+
+func (m *MockQueries) GetPayeeIDByAlias(ctx context.Context, normalized string) (int64, error) {
+	args := m.Called(ctx, normalized)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) InsertPayeeAlias(ctx context.Context, arg database.InsertPayeeAliasParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) DeletePayeeAlias(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetTransactionsWithoutPayee(ctx context.Context) ([]database.GetTransactionsWithoutPayeeRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.GetTransactionsWithoutPayeeRow), args.Error(1)
+}
+
+func (m *MockQueries) SetTransactionPayee(ctx context.Context, arg database.SetTransactionPayeeParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetPayeeReport(ctx context.Context, arg database.GetPayeeReportParams) ([]database.GetPayeeReportRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetPayeeReportRow), args.Error(1)
+}
+
+// Rules
+
+func (m *MockQueries) GetAllRules(ctx context.Context) ([]database.Rule, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.Rule), args.Error(1)
+}
+
+func (m *MockQueries) GetRuleByID(ctx context.Context, id int64) (database.Rule, error) {
 	args := m.Called(ctx, id)
+	return args.Get(0).(database.Rule), args.Error(1)
+}
+
+func (m *MockQueries) InsertRule(ctx context.Context, arg database.InsertRuleParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) DeleteRule(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockQueries) AddRuleTag(ctx context.Context, arg database.AddRuleTagParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetRuleTags(ctx context.Context) ([]database.GetRuleTagsRow, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.GetRuleTagsRow), args.Error(1)
+}
+
+func (m *MockQueries) EnsureRootCategory(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
 	return args.Error(0)
 }
 
-func (m *MockQueries) InsertCategory(ctx context.Context, name string) error {
+func (m *MockQueries) GetRootCategoryByName(ctx context.Context, name string) (database.Category, error) {
 	args := m.Called(ctx, name)
+	return args.Get(0).(database.Category), args.Error(1)
+}
+
+func (m *MockQueries) GetUncategorizedTransactions(ctx context.Context, categoriesID int64) ([]database.GetUncategorizedTransactionsRow, error) {
+	args := m.Called(ctx, categoriesID)
+	return args.Get(0).([]database.GetUncategorizedTransactionsRow), args.Error(1)
+}
+
+func (m *MockQueries) SetTransactionCategory(ctx context.Context, arg database.SetTransactionCategoryParams) error {
+	args := m.Called(ctx, arg)
 	return args.Error(0)
 }
+
+// Attachments
+
+func (m *MockQueries) SetTransactionNotes(ctx context.Context, arg database.SetTransactionNotesParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetAttachments(ctx context.Context, ids []int64) ([]database.GetAttachmentsRow, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]database.GetAttachmentsRow), args.Error(1)
+}
+
+func (m *MockQueries) GetAttachmentByID(ctx context.Context, id int64) (database.Attachment, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.Attachment), args.Error(1)
+}
+
+func (m *MockQueries) InsertAttachment(ctx context.Context, arg database.InsertAttachmentParams) (int64, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) DeleteAttachment(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// Trash
+
+func (m *MockQueries) GetTrashedTransactions(ctx context.Context) ([]database.Transaction, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.Transaction), args.Error(1)
+}
+
+func (m *MockQueries) GetTrashedTransactionByID(ctx context.Context, id int64) (database.Transaction, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.Transaction), args.Error(1)
+}
+
+func (m *MockQueries) CountTrashedTransactionCategories(ctx context.Context, id int64) (int64, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) RestoreTransaction(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetTrashedCategories(ctx context.Context) ([]database.Category, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]database.Category), args.Error(1)
+}
+
+func (m *MockQueries) GetTrashedCategoryByID(ctx context.Context, id int64) (database.Category, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(database.Category), args.Error(1)
+}
+
+func (m *MockQueries) RestoreCategory(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// Audit log
+
+func (m *MockQueries) InsertAuditEntry(ctx context.Context, arg database.InsertAuditEntryParams) error {
+	args := m.Called(ctx, arg)
+	return args.Error(0)
+}
+
+func (m *MockQueries) GetAuditEntries(ctx context.Context, arg database.GetAuditEntriesParams) ([]database.GetAuditEntriesRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetAuditEntriesRow), args.Error(1)
+}
+
+func (m *MockQueries) CountAuditEntries(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockQueries) GetEntityAuditEntries(ctx context.Context, arg database.GetEntityAuditEntriesParams) ([]database.GetEntityAuditEntriesRow, error) {
+	args := m.Called(ctx, arg)
+	return args.Get(0).([]database.GetEntityAuditEntriesRow), args.Error(1)
+}
@@ -0,0 +1,133 @@
+package logger_test
+
+import (
+	"context"
+	"log/slog"
+	"quattrinitrack/logger"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogs routes slog through the capture handler, without printing, for
+// the length of the test.
+func captureLogs(t *testing.T) *slog.Logger {
+	previous := slog.Default()
+	logger.SetupLogCapture()
+	logger.SetSuppress(true)
+	logger.ClearLogs()
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+		logger.ClearLogs()
+	})
+	return slog.Default()
+}
+
+func attrStrings(entry logger.LogEntry) []string {
+	attrs := make([]string, 0, len(entry.Attrs))
+	for _, a := range entry.Attrs {
+		attrs = append(attrs, a.String())
+	}
+	return attrs
+}
+
+func TestCaptureHandler(t *testing.T) {
+	request := logger.WithRequestInfo(context.Background(), &logger.RequestInfo{RequestID: "abc123", UserID: 7})
+
+	tests := []struct {
+		name    string
+		log     func(l *slog.Logger)
+		level   slog.Level
+		message string
+		attrs   []string
+	}{
+		{
+			name:    "attributes",
+			log:     func(l *slog.Logger) { l.Info("transaction saved", "id", 3, "cost", 4.5) },
+			level:   slog.LevelInfo,
+			message: "transaction saved",
+			attrs:   []string{"id=3", "cost=4.5"},
+		},
+		{
+			name:    "message trimmed",
+			log:     func(l *slog.Logger) { l.Debug("  listening\n") },
+			level:   slog.LevelDebug,
+			message: "listening",
+			attrs:   []string{},
+		},
+		{
+			name:    "with attributes",
+			log:     func(l *slog.Logger) { l.With("component", "db").Warn("slow query", "ms", 250) },
+			level:   slog.LevelWarn,
+			message: "slow query",
+			attrs:   []string{"component=db", "ms=250"},
+		},
+		{
+			name:    "group",
+			log:     func(l *slog.Logger) { l.WithGroup("http").Error("request failed", "status", 500) },
+			level:   slog.LevelError,
+			message: "request failed",
+			attrs:   []string{"http.status=500"},
+		},
+		{
+			name:    "attributes added in a group",
+			log:     func(l *slog.Logger) { l.WithGroup("db").With("table", "tags").WithGroup("").Info("query", "rows", 2) },
+			level:   slog.LevelInfo,
+			message: "query",
+			attrs:   []string{"db.table=tags", "db.rows=2"},
+		},
+		{
+			name: "nested group attributes",
+			log: func(l *slog.Logger) {
+				l.Info("served", slog.Group("req", "method", "GET", slog.Group("url", "path", "/v1/me")), slog.Attr{})
+			},
+			level:   slog.LevelInfo,
+			message: "served",
+			attrs:   []string{"req.method=GET", "req.url.path=/v1/me"},
+		},
+		{
+			name:    "request attributes first",
+			log:     func(l *slog.Logger) { l.With("route", "GET /v1/me").InfoContext(request, "handled") },
+			level:   slog.LevelInfo,
+			message: "handled",
+			attrs:   []string{"request_id=abc123", "user_id=7", "route=GET /v1/me"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := captureLogs(t)
+			tt.log(l)
+
+			logs := logger.GetLogs()
+			require.Len(t, logs, 1)
+			assert.Equal(t, tt.level, logs[0].Level)
+			assert.Equal(t, tt.message, logs[0].Message)
+			assert.Equal(t, tt.attrs, attrStrings(logs[0]))
+		})
+	}
+}
+
+func TestCaptureHandlerSkipsEmptyMessages(t *testing.T) {
+	l := captureLogs(t)
+	version := logger.Version()
+
+	l.Info("   ", "id", 3)
+
+	assert.Empty(t, logger.GetLogs())
+	assert.Equal(t, version, logger.Version())
+}
+
+func TestCaptureHandlerEnabled(t *testing.T) {
+	handler := logger.NewCaptureHandler(nil, slog.LevelWarn)
+
+	for level, enabled := range map[slog.Level]bool{
+		slog.LevelDebug: false,
+		slog.LevelInfo:  false,
+		slog.LevelWarn:  true,
+		slog.LevelError: true,
+	} {
+		assert.Equal(t, enabled, handler.Enabled(context.Background(), level), level.String())
+	}
+}
@@ -0,0 +1,68 @@
+package logger_test
+
+import (
+	"log/slog"
+	"quattrinitrack/logger"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogQueryMatches(t *testing.T) {
+	now := time.Date(2026, time.May, 2, 10, 0, 0, 0, time.UTC)
+	entry := logger.LogEntry{
+		Timestamp: now.Add(-10 * time.Minute),
+		Level:     slog.LevelWarn,
+		Message:   "Slow query",
+		Attrs:     []slog.Attr{slog.String("table", "Transactions"), slog.Int("ms", 250)},
+	}
+	debug := entry
+	debug.Level = slog.LevelDebug
+
+	tests := []struct {
+		name  string
+		query logger.LogQuery
+		entry logger.LogEntry
+		want  bool
+	}{
+		{"zero value", logger.LogQuery{}, entry, true},
+		// The zero MinLevel is Info
+		{"zero value leaves debug out", logger.LogQuery{}, debug, false},
+		{"debug", logger.LogQuery{MinLevel: slog.LevelDebug}, debug, true},
+		{"at the minimum level", logger.LogQuery{MinLevel: slog.LevelWarn}, entry, true},
+		{"below the minimum level", logger.LogQuery{MinLevel: slog.LevelError}, entry, false},
+		{"inside the window", logger.LogQuery{Since: now.Add(-time.Hour)}, entry, true},
+		{"at the start of the window", logger.LogQuery{Since: entry.Timestamp}, entry, true},
+		{"before the window", logger.LogQuery{Since: now.Add(-5 * time.Minute)}, entry, false},
+		{"text in the message", logger.LogQuery{Text: "slow"}, entry, true},
+		{"text in an attribute key", logger.LogQuery{Text: "MS="}, entry, true},
+		{"text in an attribute value", logger.LogQuery{Text: "transactions"}, entry, true},
+		{"text spanning key and value", logger.LogQuery{Text: "table=trans"}, entry, true},
+		{"text missing", logger.LogQuery{Text: "timeout"}, entry, false},
+		{"every filter", logger.LogQuery{MinLevel: slog.LevelInfo, Since: now.Add(-time.Hour), Text: "250"}, entry, true},
+		{"one filter failing", logger.LogQuery{MinLevel: slog.LevelInfo, Since: now.Add(-time.Minute), Text: "250"}, entry, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.query.Matches(tt.entry))
+		})
+	}
+}
+
+func TestQueryLogs(t *testing.T) {
+	l := captureLogs(t)
+	l.Debug("cache warmed")
+	l.Info("request served", "status", 200)
+	l.Error("request failed", "status", 500)
+
+	logs := logger.QueryLogs(logger.LogQuery{Text: "request"})
+
+	messages := make([]string, 0, len(logs))
+	for _, entry := range logs {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"request served", "request failed"}, messages)
+	assert.Len(t, logger.GetLogs(), 3)
+}
@@ -0,0 +1,268 @@
+package logger_test
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"quattrinitrack/logger"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func touch(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+}
+
+// readBackup returns the content of a rotated file, gunzipping it if needed.
+func readBackup(t *testing.T, name string) string {
+	file, err := os.Open(name)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var in io.Reader = file
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(file)
+		require.NoError(t, err)
+		defer gz.Close()
+		in = gz
+	}
+	data, err := io.ReadAll(in)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func readBackups(t *testing.T, path string) []string {
+	backups, err := logger.BackupFiles(path)
+	require.NoError(t, err)
+	contents := make([]string, 0, len(backups))
+	for _, backup := range backups {
+		contents = append(contents, readBackup(t, backup))
+	}
+	return contents
+}
+
+func TestBackupFiles(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir,
+		"app.log",
+		"app-20240102T150405.000.log",
+		"app-20240102T150405.000.1.log",
+		"app-20240102T150405.000.2.log.gz",
+		"app-20240101T090000.500.log.gz",
+		"app-20240103T000000.000.log",
+		// Still being compressed
+		"app-20240103T000000.000.log.gz",
+		// Not rotated files of app.log
+		"app-debug.log",
+		"app-2024.log",
+		"app-20240102T150405.000.x.log",
+		"app-20240102T150405.000.0.log",
+		"app-20240102T150405.000.log.bak",
+		"app-20241302T150405.000.log",
+		"other-20240102T150405.000.log",
+		"app-20240102T150405.000.txt",
+	)
+
+	backups, err := logger.BackupFiles(filepath.Join(dir, "app.log"))
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(backups))
+	for _, backup := range backups {
+		names = append(names, filepath.Base(backup))
+	}
+	assert.Equal(t, []string{
+		"app-20240101T090000.500.log.gz",
+		"app-20240102T150405.000.log",
+		"app-20240102T150405.000.1.log",
+		"app-20240102T150405.000.2.log.gz",
+		"app-20240103T000000.000.log",
+	}, names)
+}
+
+func TestBackupFilesMissingDirectory(t *testing.T) {
+	backups, err := logger.BackupFiles(filepath.Join(t.TempDir(), "logs", "app.log"))
+	assert.NoError(t, err)
+	assert.Empty(t, backups)
+}
+
+func TestRotatingFileRotatesOnSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+	file, err := logger.OpenRotatingFile(path, logger.RotateOptions{MaxSize: 10})
+	require.NoError(t, err)
+
+	// Each line fills the file, so the next one starts a new file, often
+	// within the same millisecond
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Close())
+
+	assert.Equal(t, []string{"first\n", "second\n", "third\n"}, readBackups(t, path))
+	active, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "fourth\n", string(active))
+}
+
+func TestRotatingFileRotatesOnAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := logger.OpenRotatingFile(path, logger.RotateOptions{RotateEvery: 20 * time.Millisecond})
+	require.NoError(t, err)
+
+	_, err = file.Write([]byte("old\n"))
+	require.NoError(t, err)
+	_, err = file.Write([]byte("still young\n"))
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+	_, err = file.Write([]byte("new\n"))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	assert.Equal(t, []string{"old\nstill young\n"}, readBackups(t, path))
+}
+
+func TestRotatingFileRotatesOnAgeAfterReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("previous run\n"), 0o644))
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+
+	file, err := logger.OpenRotatingFile(path, logger.RotateOptions{RotateEvery: time.Hour})
+	require.NoError(t, err)
+	_, err = file.Write([]byte("new\n"))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	assert.Equal(t, []string{"previous run\n"}, readBackups(t, path))
+	active, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(active))
+}
+
+func TestRotatingFilePrunesBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	touch(t, dir, "app-debug.log")
+
+	file, err := logger.OpenRotatingFile(path, logger.RotateOptions{MaxSize: 5, MaxBackups: 2})
+	require.NoError(t, err)
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Close())
+
+	assert.Equal(t, []string{"three\n", "four\n"}, readBackups(t, path))
+	// Files of another log sharing the prefix are left alone
+	assert.FileExists(t, filepath.Join(dir, "app-debug.log"))
+}
+
+func TestRotatingFilePrunesOldBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	old := filepath.Join(dir, "app-20200101T000000.000.log")
+	touch(t, dir, filepath.Base(old))
+	require.NoError(t, os.Chtimes(old, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour)))
+
+	file, err := logger.OpenRotatingFile(path, logger.RotateOptions{MaxSize: 5, MaxAge: 24 * time.Hour})
+	require.NoError(t, err)
+	for _, line := range []string{"one\n", "two\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Close())
+
+	assert.NoFileExists(t, old)
+	assert.Equal(t, []string{"one\n"}, readBackups(t, path))
+}
+
+func TestRotatingFileCompresses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := logger.OpenRotatingFile(path, logger.RotateOptions{MaxSize: 80, Compress: true})
+	require.NoError(t, err)
+
+	lines := []string{
+		`{"time":"2024-01-02T15:04:05Z","level":"INFO","msg":"started"}`,
+		`{"time":"2024-01-02T15:04:06Z","level":"WARN","msg":"slow query","ms":250}`,
+		`{"time":"2024-01-02T15:04:07Z","level":"ERROR","msg":"stopped"}`,
+	}
+	for _, line := range lines {
+		_, err := file.Write([]byte(line + "\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Close())
+
+	backups, err := logger.BackupFiles(path)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	for i, backup := range backups {
+		assert.True(t, strings.HasSuffix(backup, ".log.gz"), backup)
+		assert.Equal(t, lines[i]+"\n", readBackup(t, backup))
+		// The plain file is removed once compressed
+		assert.NoFileExists(t, strings.TrimSuffix(backup, ".gz"))
+	}
+
+	var messages []string
+	require.NoError(t, logger.ReadLogFiles(path, func(entry logger.LogEntry) bool {
+		messages = append(messages, entry.Message)
+		return true
+	}))
+	assert.Equal(t, []string{"started", "slow query", "stopped"}, messages)
+}
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		ok      bool
+		level   slog.Level
+		message string
+		attrs   []string
+	}{
+		{
+			name:    "record",
+			line:    `{"time":"2024-01-02T15:04:05.123Z","level":"INFO","msg":"served","status":200,"route":"GET /v1/me"}`,
+			ok:      true,
+			level:   slog.LevelInfo,
+			message: "served",
+			attrs:   []string{"route=GET /v1/me", "status=200"},
+		},
+		{
+			name:    "level with offset",
+			line:    `{"time":"2024-01-02T15:04:05Z","level":"WARN+2","msg":"retrying"}`,
+			ok:      true,
+			level:   slog.LevelWarn + 2,
+			message: "retrying",
+			attrs:   []string{},
+		},
+		{name: "cut short", line: `{"time":"2024-01-02T15:04:05Z","level":"IN`},
+		{name: "not JSON", line: `2024/01/02 15:04:05 INFO served`},
+		{name: "no time", line: `{"level":"INFO","msg":"served"}`},
+		{name: "bad level", line: `{"time":"2024-01-02T15:04:05Z","level":"LOUD","msg":"served"}`},
+		{name: "empty", line: ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := logger.ParseLogLine([]byte(tt.line))
+			assert.Equal(t, tt.ok, ok)
+			if !tt.ok {
+				return
+			}
+			assert.Equal(t, tt.level, entry.Level)
+			assert.Equal(t, tt.message, entry.Message)
+			assert.Equal(t, tt.attrs, attrStrings(entry))
+		})
+	}
+
+	entry, _ := logger.ParseLogLine([]byte(tests[0].line))
+	assert.True(t, entry.Timestamp.Equal(time.Date(2024, 1, 2, 15, 4, 5, 123e6, time.UTC)))
+}
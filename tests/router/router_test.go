@@ -0,0 +1,284 @@
+package router_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"quattrinitrack/database"
+	middleware "quattrinitrack/middlewares"
+	"quattrinitrack/openapi"
+	"quattrinitrack/router"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// Fails when a route is added without documenting it, or the other way round.
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, router.Endpoints(), doc.Endpoints())
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	handler := router.New(nil, database.New(nil))
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var doc map[string]any
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&doc))
+	assert.Equal(t, "3.1.0", doc["openapi"])
+}
+
+func TestRequestValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		code    string
+		details []middleware.FieldError
+	}{
+		{
+			name: "unknown field",
+			body: `{"email":"a@b.c","password":"x","admin":true}`,
+			code: middleware.CodeValidation,
+			details: []middleware.FieldError{
+				{Field: "admin", Message: "is not allowed"},
+			},
+		},
+		{
+			name: "wrong type",
+			body: `{"email":"a@b.c","password":42}`,
+			code: middleware.CodeValidation,
+			details: []middleware.FieldError{
+				{Field: "password", Message: "must be a string"},
+			},
+		},
+		{
+			name: "missing field",
+			body: `{"email":"a@b.c"}`,
+			code: middleware.CodeValidation,
+			details: []middleware.FieldError{
+				{Field: "password", Message: "is required"},
+			},
+		},
+		{
+			name: "malformed",
+			body: `{"email":`,
+			code: middleware.CodeInvalidJSON,
+		},
+	}
+
+	handler := router.New(nil, database.New(nil))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/register", bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			var response middleware.ErrorResponse
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			assert.Equal(t, tt.code, response.Error.Code)
+			assert.Equal(t, tt.details, response.Error.Details)
+		})
+	}
+}
+
+func TestRequestValidationIgnoresKeyCase(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	var value any
+	decoder := json.NewDecoder(bytes.NewBufferString(`{"Name":"Rent","Cost":800,"Date":"2024-01-01T00:00:00Z","CategoriesID":1}`))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&value))
+
+	assert.Empty(t, doc.Validate(doc.BodySchema("POST", "/v1/transaction"), value))
+}
+
+func TestUnversionedAliasDeprecated(t *testing.T) {
+	handler := router.New(nil, database.New(nil))
+
+	tests := []struct {
+		path       string
+		deprecated bool
+	}{
+		{"/v1/transaction", false},
+		{"/transaction", true},
+		{"/v1/me", false},
+		{"/me", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			// Without a token, but still routed to an existing endpoint
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			if tt.deprecated {
+				assert.Equal(t, "true", w.Header().Get("Deprecation"))
+				assert.Equal(t, "</v1"+tt.path+`>; rel="successor-version"`, w.Header().Get("Link"))
+			} else {
+				assert.Empty(t, w.Header().Get("Deprecation"))
+			}
+		})
+	}
+}
+
+// Requests matching no route get the JSON error envelope, even without a token
+func TestNoRoute(t *testing.T) {
+	handler := router.New(nil, database.New(nil))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		code   string
+		allow  string
+	}{
+		{"unknown path", http.MethodGet, "/v1/budget", http.StatusNotFound, middleware.CodeNotFound, ""},
+		{"wrong method", http.MethodPatch, "/v1/transaction", http.StatusMethodNotAllowed, middleware.CodeMethodNotAllowed, "GET, POST, DELETE"},
+		{"wrong method on a public route", http.MethodGet, "/v1/login", http.StatusMethodNotAllowed, middleware.CodeMethodNotAllowed, "POST"},
+		{"wrong method on an alias", http.MethodPut, "/me", http.StatusMethodNotAllowed, middleware.CodeMethodNotAllowed, "GET"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			var response middleware.ErrorResponse
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.allow, w.Header().Get("Allow"))
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			assert.Equal(t, tt.code, response.Error.Code)
+		})
+	}
+}
+
+func TestUnversionedAliasValidated(t *testing.T) {
+	handler := router.New(nil, database.New(nil))
+	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBufferString(`{"email":"a@b.c"}`))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var response middleware.ErrorResponse
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, middleware.CodeValidation, response.Error.Code)
+}
+
+func TestLegacyFieldNames(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	var received map[string]any
+	handler := middleware.ValidateBody(doc, "POST", "/v1/transaction", func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	})
+
+	body := `{"Name":"Rent","Cost":800,"Date":"2024-01-01T00:00:00Z","CategoriesID":1}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/transaction", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Warning"), "CategoriesID")
+	assert.Equal(t, map[string]any{
+		"name":          "Rent",
+		"cost":          float64(800),
+		"date":          "2024-01-01T00:00:00Z",
+		"categories_id": float64(1),
+	}, received)
+}
+
+func TestCurrentFieldNamesNotFlagged(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+
+	handler := middleware.ValidateBody(doc, "POST", "/v1/transaction", func(w http.ResponseWriter, r *http.Request) {})
+
+	body := `{"name":"Rent","cost":800,"date":"2024-01-01T00:00:00Z","categories_id":1}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/transaction", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Warning"))
+}
+
+func TestLegacyResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{
+			name:   "resource",
+			status: http.StatusOK,
+			body:   `{"id":7,"name":"Rent","cost":800,"date":"2024-01-01T00:00:00Z","categories_id":1,"category_name":"Home","tags":[]}`,
+			want:   `{"ID":7,"Name":"Rent","Cost":800,"Date":"2024-01-01T00:00:00Z","CategoriesID":1}`,
+		},
+		{
+			name:   "list",
+			status: http.StatusOK,
+			body:   `[{"id":1,"name":"Food","parent_id":null},{"id":2,"name":"Home","parent_id":null}]`,
+			want:   `[{"ID":1,"Name":"Food"},{"ID":2,"Name":"Home"}]`,
+		},
+		{
+			name:   "user",
+			status: http.StatusCreated,
+			body:   `{"id":3,"email":"a@b.c"}`,
+			want:   `{"ID":3,"Email":"a@b.c"}`,
+		},
+		{
+			name:   "message",
+			status: http.StatusOK,
+			body:   `{"message":"Transaction deleted successfully"}`,
+			want:   `{"message":"Transaction deleted successfully"}`,
+		},
+		{
+			name:   "error",
+			status: http.StatusNotFound,
+			body:   `{"id":3}`,
+			want:   `{"id":3}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := middleware.LegacyResponse(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body + "\n"))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/transaction", nil)
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.want, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package tui_test
+
+import (
+	"quattrinitrack/tui"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickerOptions(t *testing.T) {
+	paths := []string{"Food", "Food > Groceries", "Food > Restaurants", "Transport", "Travel > Food"}
+
+	tests := []struct {
+		name      string
+		search    string
+		suggested map[string]float64
+		want      []string
+	}{
+		{
+			name:   "no search",
+			search: "",
+			want:   []string{"Let the rules pick", "Food", "Food > Groceries", "Food > Restaurants", "Transport", "Travel > Food"},
+		},
+		{
+			name:      "suggestions first",
+			search:    "",
+			suggested: map[string]float64{"Transport": 0.2, "Food > Restaurants": 0.75},
+			want: []string{"Let the rules pick", "Food > Restaurants  (suggested, 75%)", "Transport  (suggested, 20%)",
+				"Food", "Food > Groceries", "Travel > Food"},
+		},
+		{
+			name:      "search ignores suggestions",
+			search:    "rest",
+			suggested: map[string]float64{"Food > Restaurants": 0.75},
+			want:      []string{"Food > Restaurants", `+ Create new category "rest"`},
+		},
+		{
+			name:   "search matches the parents",
+			search: "food",
+			want:   []string{"Food", "Food > Groceries", "Food > Restaurants", "Travel > Food"},
+		},
+		{
+			name:   "exact path",
+			search: "travel > food",
+			want:   []string{"Travel > Food"},
+		},
+		{
+			name:   "new category",
+			search: "Health",
+			want:   []string{`+ Create new category "Health"`},
+		},
+		{
+			name:   "spaces around the search",
+			search: "  transport ",
+			want:   []string{"Transport"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tui.PickerOptions(tt.search, paths, tt.suggested))
+		})
+	}
+}
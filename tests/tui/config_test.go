@@ -0,0 +1,88 @@
+package tui_test
+
+import (
+	"quattrinitrack/config"
+	"quattrinitrack/tui"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configure applies cfg, restoring the defaults once the test is over.
+func configure(t *testing.T, cfg config.TUIConfig) error {
+	t.Cleanup(func() {
+		require.NoError(t, tui.Configure(config.TUIConfig{}))
+	})
+	return tui.Configure(cfg)
+}
+
+func TestConfigureKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		keys map[string][]string
+		err  string
+	}{
+		{name: "defaults"},
+		{name: "override", keys: map[string][]string{"quit": {"x", "ctrl+c"}, "log_level": {"L"}}},
+		{name: "unknown action", keys: map[string][]string{"jump": {"g"}}, err: `unknown key binding action "jump"`},
+		{name: "no keys", keys: map[string][]string{"quit": {}}, err: `no keys given for action "quit"`},
+		{name: "taken by another action", keys: map[string][]string{"refresh": {"q"}}, err: `key "q" is bound to both "quit" and "refresh"`},
+		{name: "two overrides on one key", keys: map[string][]string{"add": {"a"}, "open": {"a"}}, err: `key "a" is bound to both "add" and "open"`},
+		// The calendar takes the keys while it is open
+		{name: "calendar key used by a screen", keys: map[string][]string{"calendar_today": {"r"}}},
+		{name: "calendar keys on one key", keys: map[string][]string{"calendar_next_day": {"t"}}, err: `key "t" is bound to both "calendar_next_day" and "calendar_today"`},
+		{name: "calendar key on enter", keys: map[string][]string{"calendar_today": {"enter"}}, err: `key "enter" is bound to both "calendar_today" and "enter"`},
+		{name: "screen key on a calendar key", keys: map[string][]string{"back": {"esc", "t"}}, err: `key "t" is bound to both "back" and "calendar_today"`},
+		{name: "picker key used by a screen", keys: map[string][]string{"picker_up": {"ctrl+p"}, "picker_down": {"ctrl+n"}}},
+		{name: "picker key on tab", keys: map[string][]string{"picker_down": {"tab"}}, err: `key "tab" is bound to both "picker_down" and "tab"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := configure(t, config.TUIConfig{Keys: tt.keys})
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestConfigureTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		theme   string
+		colors  map[string]string
+		noColor bool
+		err     string
+	}{
+		{name: "default"},
+		{name: "preset", theme: "high-contrast"},
+		{name: "color override", theme: "light", colors: map[string]string{"accent": "#00ff00", "muted": "244"}},
+		{name: "unknown theme", theme: "solarized", err: `unknown theme "solarized", expected one of dark, high-contrast, light`},
+		{name: "unknown color", colors: map[string]string{"shadow": "#000000"}, err: `unknown theme color "shadow"`},
+		{name: "empty color", colors: map[string]string{"accent": ""}, err: `empty value for theme color "accent"`},
+		{name: "no color", theme: "light", colors: map[string]string{"accent": "#00ff00"}, noColor: true},
+		// The config is checked even though its colors are not used
+		{name: "no color with unknown theme", theme: "solarized", noColor: true, err: `unknown theme "solarized", expected one of dark, high-contrast, light`},
+		{name: "no color with unknown color", colors: map[string]string{"shadow": "#000000"}, noColor: true, err: `unknown theme color "shadow"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.noColor {
+				t.Setenv("NO_COLOR", "1")
+			} else {
+				t.Setenv("NO_COLOR", "")
+			}
+			err := configure(t, config.TUIConfig{Theme: tt.theme, Colors: tt.colors})
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package tui_test
+
+import (
+	"quattrinitrack/tui"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		width int
+		want  string
+	}{
+		{"fits", "Food", 15, "Food"},
+		{"exact width", "Groceries", 9, "Groceries"},
+		{"cut", "Entertainment", 8, "Enterta…"},
+		// Every CJK character takes two cells, more than one per rune
+		{"wide runes", "食料品と日用品の買い物代", 15, "食料品と日用品…"},
+		{"wide rune at the cut", "食料品", 4, "食…"},
+		{"emoji", "🍕🍕🍕🍕🍕", 5, "🍕🍕…"},
+		{"one cell", "Food", 1, "F"},
+		{"wide rune in one cell", "食料品", 1, ""},
+		{"no width", "Food", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tui.Truncate(tt.input, tt.width)
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, lipgloss.Width(got), max(tt.width, 0))
+		})
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		width  int
+		want   string
+	}{
+		{"empty", nil, 10, ""},
+		{"no width", []float64{1, 2}, 0, ""},
+		{"no spending", []float64{0, 0, 0}, 10, "   "},
+		{"scaled to the largest", []float64{0, 1, 7, 14}, 10, " ▁▄█"},
+		{"refunds left blank", []float64{-5, 10}, 10, " █"},
+		// Two days per column
+		{"squeezed", []float64{1, 1, 0, 0, 2, 2}, 3, "▄ █"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tui.Sparkline(tt.values, tt.width))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package tui_test
+
+import (
+	"quattrinitrack/tui"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseDateExpr(t *testing.T) {
+	// Wednesday evening west of UTC, when it is already Thursday in UTC
+	now := time.Date(2026, time.March, 18, 22, 30, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+
+	tests := []struct {
+		input string
+		start time.Time
+		end   time.Time
+	}{
+		{"2026-02-10", date(2026, time.February, 10), date(2026, time.February, 10)},
+		{"today", date(2026, time.March, 18), date(2026, time.March, 18)},
+		{"  Today ", date(2026, time.March, 18), date(2026, time.March, 18)},
+		{"now", date(2026, time.March, 18), date(2026, time.March, 18)},
+		{"yesterday", date(2026, time.March, 17), date(2026, time.March, 17)},
+		{"tomorrow", date(2026, time.March, 19), date(2026, time.March, 19)},
+		{"-3d", date(2026, time.March, 15), date(2026, time.March, 15)},
+		{"+2w", date(2026, time.April, 1), date(2026, time.April, 1)},
+		{"1m", date(2026, time.April, 18), date(2026, time.April, 18)},
+		{"-1y", date(2025, time.March, 18), date(2025, time.March, 18)},
+		{"last monday", date(2026, time.March, 16), date(2026, time.March, 16)},
+		{"last wed", date(2026, time.March, 11), date(2026, time.March, 11)},
+		{"next wednesday", date(2026, time.March, 25), date(2026, time.March, 25)},
+		{"next fri", date(2026, time.March, 20), date(2026, time.March, 20)},
+		{"this week", date(2026, time.March, 16), date(2026, time.March, 22)},
+		{"last week", date(2026, time.March, 9), date(2026, time.March, 15)},
+		{"this month", date(2026, time.March, 1), date(2026, time.March, 31)},
+		{"last month", date(2026, time.February, 1), date(2026, time.February, 28)},
+		{"this year", date(2026, time.January, 1), date(2026, time.December, 31)},
+		{"last year", date(2025, time.January, 1), date(2025, time.December, 31)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			r, err := tui.ParseDateExpr(tt.input, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.start, r.Start)
+			assert.Equal(t, tt.end, r.End)
+			assert.Equal(t, tt.start.Equal(tt.end), r.SingleDay())
+		})
+	}
+}
+
+func TestParseDateExprInvalid(t *testing.T) {
+	now := time.Date(2026, time.March, 18, 12, 0, 0, 0, time.UTC)
+
+	for _, input := range []string{"", "   ", "someday", "2026-13-01", "next month", "this monday", "last fortnight", "3x"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := tui.ParseDateExpr(input, now)
+			assert.Error(t, err)
+		})
+	}
+}
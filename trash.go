@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"quattrinitrack/audit"
+	"quattrinitrack/config"
+	"quattrinitrack/database"
+	"quattrinitrack/metrics"
+	"time"
+)
+
+// purgeInterval is how often the trash is checked for expired items
+const purgeInterval = time.Hour
+
+// purgeTrash deletes for good the transactions and categories that have been
+// in the trash for longer than the retention. It runs until ctx is done.
+func purgeTrash(ctx context.Context, db *sql.DB) {
+	if config.TrashRetention == 0 {
+		slog.Info("Trash is kept until restored, purge disabled")
+		return
+	}
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		before := sql.NullTime{Time: time.Now().UTC().Add(-config.TrashRetention), Valid: true}
+		transactions, categories, err := purgeExpired(ctx, db, before)
+		if err != nil {
+			slog.Error("Error purging the trash", "error", err)
+		}
+		if transactions > 0 || categories > 0 {
+			slog.Info("Trash purged", "transactions", transactions, "categories", categories)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeExpired deletes the items trashed before the given time, and records
+// their purge in the audit log, in one database transaction. It returns how
+// many transactions and categories were purged.
+func purgeExpired(ctx context.Context, db *sql.DB, before sql.NullTime) (int, int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+	queries := database.New(metrics.InstrumentDB(tx))
+
+	// Transactions first, as they keep their categories from being purged
+	transactions, err := queries.PurgeTransactions(ctx, before)
+	if err != nil {
+		return 0, 0, fmt.Errorf("purging transactions: %w", err)
+	}
+	for _, t := range transactions {
+		if err := audit.Record(ctx, queries, audit.EntityTransaction, t.ID, audit.Purge, audit.NewTransaction(t), nil); err != nil {
+			return 0, 0, fmt.Errorf("recording the purge of transaction %d: %w", t.ID, err)
+		}
+	}
+	categories, err := queries.PurgeCategories(ctx, before)
+	if err != nil {
+		return 0, 0, fmt.Errorf("purging categories: %w", err)
+	}
+	for _, c := range categories {
+		if err := audit.Record(ctx, queries, audit.EntityCategory, c.ID, audit.Purge, audit.NewCategory(c), nil); err != nil {
+			return 0, 0, fmt.Errorf("recording the purge of category %d: %w", c.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return len(transactions), len(categories), nil
+}
@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// apiBaseURL is the root of the API version the TUI is written against.
+const apiBaseURL = "http://localhost:8080/v1"
+
+// apiErrorResponse mirrors the error body returned by the server.
+type apiErrorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"details"`
+		RequestID string `json:"request_id"`
+	} `json:"error"`
+}
+
+// apiError describes a failed API call with the message sent by the server
+// and the request ID it logged the call under, so the matching server log
+// lines can be found.
+func apiError(resp *http.Response) string {
+	body, _ := io.ReadAll(resp.Body)
+
+	var response apiErrorResponse
+	message := fmt.Sprintf("status %d", resp.StatusCode)
+	if err := json.Unmarshal(body, &response); err == nil && response.Error.Message != "" {
+		message = response.Error.Message
+	}
+
+	requestID := response.Error.RequestID
+	if requestID == "" {
+		requestID = resp.Header.Get("X-Request-ID")
+	}
+	if requestID != "" {
+		message += " (request ID " + requestID + ")"
+	}
+	return message
+}
+
+// getJSON decodes the response to a GET of path, below apiBaseURL, into out.
+func (m *model) getJSON(path string, out any) error {
+	req, err := http.NewRequest("GET", apiBaseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+m.authToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(apiError(resp))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
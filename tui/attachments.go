@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// attachment is a file kept with a transaction, such as its receipt.
+type attachment struct {
+	ID          int64  `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// attachmentIndicator is shown in the transaction table for the transactions
+// with notes or attachments.
+func (t transaction) attachmentIndicator() string {
+	indicator := ""
+	if len(t.Attachments) > 0 {
+		indicator = strconv.Itoa(len(t.Attachments))
+	}
+	if t.Notes != "" {
+		indicator += "*"
+	}
+	return indicator
+}
+
+// openAttachments downloads the attachments of the selected transaction and
+// opens them with the program the system uses for their type.
+func (m *model) openAttachments() {
+	cursor := m.transactionTable.Cursor()
+	if cursor < 0 || cursor >= len(m.filteredTransactions) {
+		return
+	}
+	t := m.filteredTransactions[cursor]
+	if len(t.Attachments) == 0 {
+		m.transactionMessage = fmt.Sprintf("%q has no attachments", t.Name)
+		return
+	}
+
+	dir := filepath.Join(os.TempDir(), "quattrinitrack")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		m.transactionMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	for _, a := range t.Attachments {
+		// Prefixed with the ID, as two files may share a name
+		path := filepath.Join(dir, strconv.FormatInt(a.ID, 10)+"-"+filepath.Base(a.Filename))
+		if err := m.downloadAttachment(a.ID, path); err != nil {
+			m.transactionMessage = "Error: " + err.Error()
+			return
+		}
+		if err := systemOpen(path); err != nil {
+			m.transactionMessage = fmt.Sprintf("Error opening %s: %v", a.Filename, err)
+			return
+		}
+	}
+	m.transactionMessage = fmt.Sprintf("Opened %d attachment(s) successfully", len(t.Attachments))
+}
+
+func (m *model) downloadAttachment(id int64, path string) error {
+	req, err := http.NewRequest("GET", apiBaseURL+"/transaction/attachment?id="+strconv.FormatInt(id, 10), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.authToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", apiError(resp))
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// systemOpen opens a file with the default program for its type, without
+// waiting for the program to exit.
+func systemOpen(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// Reaped in the background, so that it does not linger as a zombie
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dateField identifies which date input the calendar writes back to.
+type dateField int
+
+const (
+	noDateField dateField = iota
+	transactionDateField
+	transactionDateFromField
+	transactionDateToField
+)
+
+// calendar is a month grid used to pick a date with the keyboard.
+type calendar struct {
+	active bool
+	target dateField
+	cursor time.Time
+}
+
+// open shows the calendar positioned on the date currently typed in the
+// field, falling back to today.
+func (c *calendar) open(target dateField, value string, now time.Time) {
+	c.active = true
+	c.target = target
+	c.cursor = startOfDay(now)
+	if r, err := ParseDateExpr(value, now); err == nil {
+		c.cursor = r.Start
+	}
+}
+
+func (c *calendar) close() {
+	c.active = false
+	c.target = noDateField
+}
+
+// update moves the cursor and reports whether the user picked a date.
+func (c calendar) update(msg tea.KeyMsg) (calendar, bool) {
+	switch {
+	case key.Matches(msg, keys.prevDay):
+		c.cursor = c.cursor.AddDate(0, 0, -1)
+	case key.Matches(msg, keys.nextDay):
+		c.cursor = c.cursor.AddDate(0, 0, 1)
+	case key.Matches(msg, keys.prevWeek):
+		c.cursor = c.cursor.AddDate(0, 0, -7)
+	case key.Matches(msg, keys.nextWeek):
+		c.cursor = c.cursor.AddDate(0, 0, 7)
+	case key.Matches(msg, keys.prevMonth):
+		c.cursor = c.cursor.AddDate(0, -1, 0)
+	case key.Matches(msg, keys.nextMonth):
+		c.cursor = c.cursor.AddDate(0, 1, 0)
+	case key.Matches(msg, keys.today):
+		c.cursor = startOfDay(time.Now())
+	case key.Matches(msg, keys.enter):
+		return c, true
+	}
+	return c, false
+}
+
+func (c calendar) view(now time.Time) string {
+	var s strings.Builder
+
+	first := time.Date(c.cursor.Year(), c.cursor.Month(), 1, 0, 0, 0, 0, c.cursor.Location())
+	header := first.Format("January 2006")
+	s.WriteString(titleStyle.Render(fmt.Sprintf("%-20s", header)) + "\n")
+	s.WriteString(mutedStyle.Render("Mo Tu We Th Fr Sa Su") + "\n")
+
+	// Weeks start on Monday
+	offset := (int(first.Weekday()) + 6) % 7
+	s.WriteString(strings.Repeat("   ", offset))
+
+	today := startOfDay(now)
+	for d := first; d.Month() == first.Month(); d = d.AddDate(0, 0, 1) {
+		cell := fmt.Sprintf("%2d", d.Day())
+		switch {
+		case d.Equal(c.cursor):
+			cell = selectedMenuStyle.UnsetPadding().Render(cell)
+		case d.Equal(today):
+			cell = focusedStyle.Render(cell)
+		}
+		s.WriteString(cell)
+
+		if d.Weekday() == time.Sunday {
+			s.WriteString("\n")
+		} else {
+			s.WriteString(" ")
+		}
+	}
+
+	s.WriteString("\n\n")
+	s.WriteString(helpLine(
+		helpKey(keys.prevDay)+"/"+helpKey(keys.nextDay)+": day",
+		helpKey(keys.prevWeek)+"/"+helpKey(keys.nextWeek)+": week",
+		helpKey(keys.prevMonth)+"/"+helpKey(keys.nextMonth)+": month",
+		hint(keys.today, "today"),
+		hint(keys.enter, "pick"),
+		hint(keys.back, "close")) + "\n")
+	return tableStyle.Render(s.String())
+}
+
+// dateInput returns the input the given field refers to.
+func (m *model) dateInput(field dateField) *textinput.Model {
+	switch field {
+	case transactionDateField:
+		return &m.transactionDateInput
+	case transactionDateFromField:
+		return &m.transactionDateFrom
+	case transactionDateToField:
+		return &m.transactionDateTo
+	default:
+		return nil
+	}
+}
+
+// focusedDateField returns the date field that currently has focus.
+func (m *model) focusedDateField() dateField {
+	switch {
+	case m.transactionMode == addTransactionMode && m.transactionDateInput.Focused():
+		return transactionDateField
+	case m.transactionMode == filterTransactionMode && m.transactionDateFrom.Focused():
+		return transactionDateFromField
+	case m.transactionMode == filterTransactionMode && m.transactionDateTo.Focused():
+		return transactionDateToField
+	default:
+		return noDateField
+	}
+}
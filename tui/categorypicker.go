@@ -0,0 +1,240 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const pickerVisibleOptions = 5
+
+// categoryPicker is a searchable list of categories that also offers to
+// create a new category named after the search text.
+type categoryPicker struct {
+	input     textinput.Model
+	cursor    int
+	suggested []categorySuggestion
+}
+
+// pickerOption is a single row of the picker, either an existing category,
+// with its confidence when it is suggested, the inline "create new category"
+// entry or the entry leaving the category to the server's rules.
+type pickerOption struct {
+	category   category
+	confidence float64
+	create     bool
+	auto       bool
+}
+
+func newCategoryPicker() categoryPicker {
+	input := textinput.New()
+	input.Placeholder = "Search categories"
+	input.CharLimit = 50
+	input.Width = 30
+	return categoryPicker{input: input}
+}
+
+func (p *categoryPicker) reset() {
+	p.input.SetValue("")
+	p.cursor = 0
+	p.suggested = nil
+}
+
+// suggest lists the suggested categories first, highlighting the top one when
+// it is likely enough and nothing is searched.
+func (p *categoryPicker) suggest(suggestions []categorySuggestion) {
+	p.suggested = suggestions
+	if p.query() != "" {
+		return
+	}
+	p.cursor = 0
+	if len(suggestions) > 0 && suggestions[0].Confidence >= suggestionPreselect {
+		p.cursor = 1
+	}
+}
+
+func (p categoryPicker) query() string {
+	return strings.TrimSpace(p.input.Value())
+}
+
+// options returns the categories matching the search text followed, when the
+// text is not the exact name or path of a category, by the create entry.
+// Without search text, the rules entry comes first, then the suggestions.
+func (p categoryPicker) options(categories []category) []pickerOption {
+	query := strings.ToLower(p.query())
+
+	var options []pickerOption
+	suggested := make(map[int64]bool)
+	if query == "" {
+		options = append(options, pickerOption{auto: true})
+		for _, s := range p.suggested {
+			for _, c := range categories {
+				if c.ID == s.CategoriesID {
+					options = append(options, pickerOption{category: c, confidence: s.Confidence})
+					suggested[c.ID] = true
+				}
+			}
+		}
+	}
+	exact := false
+	for _, c := range categories {
+		if suggested[c.ID] {
+			continue
+		}
+		label := strings.ToLower(c.label())
+		if strings.ToLower(c.Name) == query || label == query {
+			exact = true
+		}
+		if query == "" || strings.Contains(label, query) {
+			options = append(options, pickerOption{category: c})
+		}
+	}
+
+	if query != "" && !exact {
+		options = append(options, pickerOption{create: true})
+	}
+	return options
+}
+
+// selected returns the highlighted option, if any.
+func (p categoryPicker) selected(categories []category) (pickerOption, bool) {
+	options := p.options(categories)
+	if len(options) == 0 {
+		return pickerOption{}, false
+	}
+	return options[min(p.cursor, len(options)-1)], true
+}
+
+// update moves the highlight on the picker keys and restarts it when the
+// search text changes.
+func (p categoryPicker) update(msg tea.KeyMsg, categories []category) categoryPicker {
+	switch {
+	case key.Matches(msg, keys.pickerUp):
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case key.Matches(msg, keys.pickerDown):
+		if p.cursor < len(p.options(categories))-1 {
+			p.cursor++
+		}
+	default:
+		before := p.input.Value()
+		p.input, _ = p.input.Update(msg)
+		if p.input.Value() != before {
+			p.cursor = 0
+		}
+	}
+	return p
+}
+
+func (p categoryPicker) view(categories []category) string {
+	var s strings.Builder
+	s.WriteString(p.input.View())
+
+	if !p.input.Focused() {
+		if option, ok := p.selected(categories); ok && !option.create && !option.auto && (p.query() != "" || option.confidence > 0) {
+			s.WriteString("  " + successStyle.Render("→ "+option.category.label()))
+		}
+		return s.String()
+	}
+
+	options := p.options(categories)
+	if len(categories) == 0 && p.query() == "" {
+		s.WriteString("\n" + mutedStyle.Render("  No categories yet, type a name to create one"))
+	}
+
+	cursor := min(p.cursor, len(options)-1)
+	start := max(0, cursor-pickerVisibleOptions+1)
+	end := min(len(options), start+pickerVisibleOptions)
+	for i := start; i < end; i++ {
+		label := p.label(options[i])
+		if i == cursor {
+			s.WriteString("\n" + selectedMenuStyle.Render("▶ "+label))
+		} else {
+			s.WriteString("\n" + menuItemStyle.Render(label))
+		}
+	}
+	return s.String()
+}
+
+// label describes an option as the picker lists it.
+func (p categoryPicker) label(option pickerOption) string {
+	switch {
+	case option.create:
+		return fmt.Sprintf("+ Create new category %q", p.query())
+	case option.auto:
+		return "Let the rules pick"
+	case option.confidence > 0:
+		return option.category.label() + fmt.Sprintf("  (suggested, %.0f%%)", option.confidence*100)
+	}
+	return option.category.label()
+}
+
+// PickerOptions lists the options the category picker offers for a search
+// text, as it labels them. The categories are given by their paths, such as
+// "Food > Groceries", and the suggested ones by their path and confidence.
+func PickerOptions(search string, paths []string, suggested map[string]float64) []string {
+	categories := make([]category, 0, len(paths))
+	var suggestions []categorySuggestion
+	for i, path := range paths {
+		_, name := splitCategoryPath(path)
+		c := category{ID: int64(i + 1), Name: name, depth: strings.Count(path, categoryPathSeparator), path: path}
+		categories = append(categories, c)
+		if confidence, ok := suggested[path]; ok {
+			suggestions = append(suggestions, categorySuggestion{CategoriesID: c.ID, Confidence: confidence})
+		}
+	}
+	// Most likely first, as the server sends them
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Confidence > suggestions[j].Confidence
+	})
+
+	p := newCategoryPicker()
+	p.input.SetValue(search)
+	p.suggest(suggestions)
+	labels := make([]string, 0, len(paths)+1)
+	for _, option := range p.options(categories) {
+		labels = append(labels, p.label(option))
+	}
+	return labels
+}
+
+// categoryName returns the name of the category with the given ID.
+func (m model) categoryName(id int64) string {
+	for _, c := range m.categories {
+		if c.ID == id {
+			return c.Name
+		}
+	}
+	return fmt.Sprintf("#%d", id)
+}
+
+// resolvePickedCategory returns the ID of the category highlighted in the
+// picker, creating the category first when the create entry is chosen. It
+// returns 0 when the category is left to the rules.
+func (m *model) resolvePickedCategory() (int64, error) {
+	option, ok := m.categoryPicker.selected(m.categories)
+	if !ok {
+		return 0, fmt.Errorf("category is required")
+	}
+	if option.auto {
+		return 0, nil
+	}
+	if !option.create {
+		return option.category.ID, nil
+	}
+
+	path := m.categoryPicker.query()
+	if err := m.addCategory(path); err != nil {
+		return 0, err
+	}
+	m.loadCategories()
+	if c, err := m.categoryByPath(path); err == nil {
+		return c.ID, nil
+	}
+	return 0, fmt.Errorf("category %q was created but could not be loaded", path)
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// categoryPathSeparator joins the names of a category and its parents, as in
+// "Food > Groceries". It may also be typed to create a subcategory.
+const categoryPathSeparator = " > "
+
+// categoryNode is a category as listed by GET /category?tree=true.
+type categoryNode struct {
+	category
+	Children []categoryNode `json:"children"`
+}
+
+// flattenCategories lists the categories of a tree parents first, recording
+// their depth and path.
+func flattenCategories(nodes []categoryNode, depth int, parentPath string) []category {
+	var categories []category
+	for _, n := range nodes {
+		c := n.category
+		c.depth = depth
+		c.path = c.Name
+		if parentPath != "" {
+			c.path = parentPath + categoryPathSeparator + c.Name
+		}
+		categories = append(categories, c)
+		categories = append(categories, flattenCategories(n.Children, depth+1, c.path)...)
+	}
+	return categories
+}
+
+// treeLabel indents the name of a category under its parent.
+func (c category) treeLabel() string {
+	if c.depth == 0 {
+		return c.Name
+	}
+	return strings.Repeat("  ", c.depth-1) + "└ " + c.Name
+}
+
+// label names a category with its parents, so that subcategories with the
+// same name can be told apart.
+func (c category) label() string {
+	if c.path == "" {
+		return c.Name
+	}
+	return c.path
+}
+
+// splitCategoryPath separates a typed "Parent > Name" into the path of the
+// parent and the name of the category.
+func splitCategoryPath(path string) (string, string) {
+	i := strings.LastIndex(path, strings.TrimSpace(categoryPathSeparator))
+	if i < 0 {
+		return "", strings.TrimSpace(path)
+	}
+	return strings.TrimSpace(path[:i]), strings.TrimSpace(path[i+1:])
+}
+
+// categoryByPath finds a category from its path, ignoring case and the spaces
+// around separators.
+func (m model) categoryByPath(path string) (category, error) {
+	parts := strings.Split(path, strings.TrimSpace(categoryPathSeparator))
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	path = strings.Join(parts, categoryPathSeparator)
+
+	for _, c := range m.categories {
+		if strings.EqualFold(c.label(), path) {
+			return c, nil
+		}
+	}
+	return category{}, fmt.Errorf("no category %q", path)
+}
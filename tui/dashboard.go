@@ -0,0 +1,335 @@
+package tui
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	sparklineDays    = 90
+	topTransactions  = 5
+	sideBySideWidth  = 100
+	barLabelWidth    = 15
+	barAmountWidth   = 10
+	dashboardPadding = 6
+)
+
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+type categoryTotal struct {
+	name  string
+	total float64
+}
+
+// categoryReport is a node of the category report, with the totals of its
+// subcategories added to its own.
+type categoryReport struct {
+	Name     string           `json:"name"`
+	Total    float64          `json:"total"`
+	OwnTotal float64          `json:"own_total"`
+	Children []categoryReport `json:"children"`
+}
+
+// dashboard holds the figures shown on the dashboard, computed by the server
+// where it can.
+type dashboard struct {
+	thisMonth  float64
+	lastMonth  float64
+	categories []categoryTotal
+	daily      []float64
+	top        []transaction
+}
+
+// loadDashboard fetches the figures of the dashboard: the category report
+// over the whole history and over the last two months, and only the
+// transactions of the sparkline window and the largest ones.
+func (m *model) loadDashboard() {
+	now := time.Now()
+	// Dates are kept as UTC midnights, so the months and days are bounded by them
+	today := startOfDay(now)
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	window := url.Values{
+		"from": {today.AddDate(0, 0, -(sparklineDays - 1)).Format(dateLayout)},
+		"to":   {today.AddDate(0, 0, 1).Format(dateLayout)},
+	}
+
+	var d dashboard
+	var all, current, previous []categoryReport
+	var recent []transaction
+	err := m.getJSON("/category/report", &all)
+	if err == nil {
+		err = m.getJSON("/category/report?"+monthRange(thisMonth).Encode(), &current)
+	}
+	if err == nil {
+		err = m.getJSON("/category/report?"+monthRange(thisMonth.AddDate(0, -1, 0)).Encode(), &previous)
+	}
+	if err == nil {
+		recent, err = m.getTransactionPages(window)
+	}
+	if err == nil {
+		err = m.getJSON(fmt.Sprintf("/transaction?sort=cost&order=desc&limit=%d", topTransactions), &d.top)
+	}
+	if err != nil {
+		m.dashboardMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+
+	d.thisMonth = reportTotal(current)
+	d.lastMonth = reportTotal(previous)
+	d.categories = categoryTotals(all)
+	d.daily = dailySpending(recent, today, sparklineDays)
+	m.dashboard = d
+	m.dashboardMessage = ""
+}
+
+// monthRange bounds a report to the month starting at month.
+func monthRange(month time.Time) url.Values {
+	return url.Values{
+		"from": {month.Format(dateLayout)},
+		"to":   {month.AddDate(0, 1, 0).Format(dateLayout)},
+	}
+}
+
+// getTransactionPages fetches every transaction matching query, a page at a
+// time.
+func (m *model) getTransactionPages(query url.Values) ([]transaction, error) {
+	var transactions []transaction
+	for {
+		query.Set("limit", strconv.Itoa(transactionPageSize))
+		query.Set("offset", strconv.Itoa(len(transactions)))
+		var page []transaction
+		if err := m.getJSON("/transaction?"+query.Encode(), &page); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, page...)
+		if len(page) < transactionPageSize {
+			return transactions, nil
+		}
+	}
+}
+
+// reportTotal sums the spending of the top level categories of a report,
+// which include that of their subcategories.
+func reportTotal(report []categoryReport) float64 {
+	var total float64
+	for _, c := range report {
+		total += c.Total
+	}
+	return total
+}
+
+// categoryTotals lists the own spending of every category of a report,
+// largest first, leaving out those without any.
+func categoryTotals(report []categoryReport) []categoryTotal {
+	var totals []categoryTotal
+	var walk func(nodes []categoryReport)
+	walk = func(nodes []categoryReport) {
+		for _, c := range nodes {
+			if c.OwnTotal != 0 {
+				totals = append(totals, categoryTotal{name: c.Name, total: c.OwnTotal})
+			}
+			walk(c.Children)
+		}
+	}
+	walk(report)
+
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].total == totals[j].total {
+			return totals[i].name < totals[j].name
+		}
+		return totals[i].total > totals[j].total
+	})
+	return totals
+}
+
+// dailySpending returns one bucket per day for the last days days up to
+// today, oldest first. The days are counted on the UTC calendar the dates
+// are kept in, so none is shorter or longer than 24 hours.
+func dailySpending(transactions []transaction, today time.Time, days int) []float64 {
+	end := startOfDay(today)
+	start := end.AddDate(0, 0, -(days - 1))
+
+	buckets := make([]float64, days)
+	for _, t := range transactions {
+		date := t.Date.UTC()
+		day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		idx := int(day.Sub(start) / (24 * time.Hour))
+		if !day.Before(start) && idx < days {
+			buckets[idx] += t.Cost
+		}
+	}
+	return buckets
+}
+
+// Sparkline renders values as block characters, squeezing them into at most width columns.
+func Sparkline(values []float64, width int) string {
+	if width <= 0 || len(values) == 0 {
+		return ""
+	}
+
+	columns := values
+	if len(values) > width {
+		columns = make([]float64, width)
+		for i, v := range values {
+			columns[i*width/len(values)] += v
+		}
+	}
+
+	var maxValue float64
+	for _, v := range columns {
+		if v > maxValue {
+			maxValue = v
+		}
+	}
+
+	var s strings.Builder
+	for _, v := range columns {
+		if v <= 0 || maxValue == 0 {
+			s.WriteRune(' ')
+			continue
+		}
+		level := int(v / maxValue * float64(len(sparkLevels)-1))
+		s.WriteRune(sparkLevels[level])
+	}
+	return s.String()
+}
+
+// Truncate shortens s to at most width terminal cells, ending it with an
+// ellipsis when it is cut.
+func Truncate(s string, width int) string {
+	if width <= 1 {
+		return ansi.Truncate(s, max(0, width), "")
+	}
+	return ansi.Truncate(s, width, "…")
+}
+
+// padRight fills s with spaces up to width terminal cells.
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-lipgloss.Width(s)))
+}
+
+func (m model) monthSummaryView() string {
+	current, previous := m.dashboard.thisMonth, m.dashboard.lastMonth
+
+	var change string
+	switch {
+	case previous == 0 && current == 0:
+		change = mutedStyle.Render("no spending yet")
+	case previous == 0:
+		change = mutedStyle.Render("no spending last month")
+	default:
+		diff := (current - previous) / previous * 100
+		if diff > 0 {
+			change = errorStyle.Render(fmt.Sprintf("▲ %.1f%%", diff))
+		} else {
+			change = successStyle.Render(fmt.Sprintf("▼ %.1f%%", -diff))
+		}
+	}
+
+	return fmt.Sprintf("This month: %s   Last month: %s   %s",
+		focusedStyle.Render(fmt.Sprintf("%.2f", current)),
+		fmt.Sprintf("%.2f", previous),
+		change)
+}
+
+func (m model) categoryChartView(width int) string {
+	var s strings.Builder
+	s.WriteString("Spending by category\n\n")
+
+	totals := m.dashboard.categories
+	if len(totals) == 0 {
+		s.WriteString(mutedStyle.Render("No transactions yet"))
+		return s.String()
+	}
+
+	barWidth := max(1, width-barLabelWidth-barAmountWidth-2)
+	maxTotal := totals[0].total
+	for _, ct := range totals {
+		length := int(ct.total / maxTotal * float64(barWidth))
+		bar := barStyle.Render(strings.Repeat("█", max(1, length)))
+		s.WriteString(fmt.Sprintf("%s %s %*.2f\n",
+			padRight(Truncate(ct.name, barLabelWidth), barLabelWidth),
+			bar+strings.Repeat(" ", barWidth-max(1, length)),
+			barAmountWidth, ct.total))
+	}
+	return strings.TrimRight(s.String(), "\n")
+}
+
+func (m model) sparklineView(now time.Time, width int) string {
+	daily := m.dashboard.daily
+
+	var total float64
+	for _, v := range daily {
+		total += v
+	}
+
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("Daily spending, last %d days (total %.2f)\n\n", sparklineDays, total))
+	s.WriteString(barStyle.Render(Sparkline(daily, width)) + "\n")
+	s.WriteString(mutedStyle.Render(fmt.Sprintf("%s%s",
+		now.AddDate(0, 0, -(sparklineDays-1)).Format("2006-01-02"),
+		lipgloss.PlaceHorizontal(max(0, min(width, sparklineDays)-10), lipgloss.Right, now.Format("2006-01-02")))))
+	return s.String()
+}
+
+func (m model) topTransactionsView(width int) string {
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("Top %d transactions\n\n", topTransactions))
+
+	top := m.dashboard.top
+	if len(top) == 0 {
+		s.WriteString(mutedStyle.Render("No transactions yet"))
+		return s.String()
+	}
+
+	nameWidth := max(5, width-barAmountWidth-12)
+	for _, t := range top {
+		s.WriteString(fmt.Sprintf("%s %s %*.2f\n",
+			t.Date.Format("2006-01-02"),
+			padRight(Truncate(t.Name, nameWidth), nameWidth),
+			barAmountWidth, t.Cost))
+	}
+	return strings.TrimRight(s.String(), "\n")
+}
+
+func (m model) dashboardView() string {
+	var s strings.Builder
+	now := time.Now()
+
+	title := titleStyle.Render("QuattriniTrack - Dashboard")
+	s.WriteString(title + "\n\n")
+
+	if m.dashboardMessage != "" {
+		s.WriteString(errorStyle.Render(m.dashboardMessage) + "\n\n")
+	}
+
+	s.WriteString(m.monthSummaryView() + "\n\n")
+
+	width := max(20, m.width-dashboardPadding)
+	if m.width >= sideBySideWidth {
+		half := width/2 - 2
+		left := tableStyle.Width(half).Render(m.categoryChartView(half))
+		right := tableStyle.Width(half).Render(m.topTransactionsView(half))
+		s.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, left, " ", right) + "\n")
+	} else {
+		s.WriteString(tableStyle.Width(width).Render(m.categoryChartView(width)) + "\n")
+		s.WriteString(tableStyle.Width(width).Render(m.topTransactionsView(width)) + "\n")
+	}
+	s.WriteString(tableStyle.Width(width).Render(m.sparklineView(now, width)) + "\n")
+
+	s.WriteString("\n")
+	if m.showHelp {
+		s.WriteString(helpLine(hint(keys.refresh, "refresh"), hint(keys.back, "back to menu"), hint(keys.help, "toggle help")) + "\n")
+	} else {
+		s.WriteString(helpLine(hint(keys.refresh, "refresh"), hint(keys.back, "back"), hint(keys.help, "help")) + "\n")
+	}
+
+	return s.String()
+}
@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+const dateLayout = "2006-01-02"
+
+var relativeOffsetPattern = regexp.MustCompile(`^([+-]?)(\d+)\s*([dwmy])$`)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// DateRange is an inclusive range of whole days. The days are UTC midnights,
+// as the server keeps the dates. Expressions naming a single day have the
+// same start and end.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (r DateRange) SingleDay() bool {
+	return r.Start.Equal(r.End)
+}
+
+func (r DateRange) String() string {
+	if r.SingleDay() {
+		return r.Start.Format("Mon " + dateLayout)
+	}
+	return r.Start.Format(dateLayout) + " → " + r.End.Format(dateLayout)
+}
+
+// startOfDay returns the calendar day of t, in its own location, as a UTC
+// midnight.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func day(t time.Time) DateRange {
+	d := startOfDay(t)
+	return DateRange{Start: d, End: d}
+}
+
+// ParseDateExpr parses an absolute YYYY-MM-DD date or a relative expression
+// such as "today", "yesterday", "-3d", "last monday" or "this month".
+func ParseDateExpr(input string, now time.Time) (DateRange, error) {
+	expr := strings.ToLower(strings.Join(strings.Fields(input), " "))
+	today := startOfDay(now)
+
+	if expr == "" {
+		return DateRange{}, fmt.Errorf("date is required")
+	}
+
+	if t, err := time.Parse(dateLayout, expr); err == nil {
+		return day(t), nil
+	}
+
+	switch expr {
+	case "today", "now":
+		return day(today), nil
+	case "yesterday":
+		return day(today.AddDate(0, 0, -1)), nil
+	case "tomorrow":
+		return day(today.AddDate(0, 0, 1)), nil
+	}
+
+	if m := relativeOffsetPattern.FindStringSubmatch(expr); m != nil {
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return DateRange{}, fmt.Errorf("invalid offset %q", input)
+		}
+		if m[1] == "-" {
+			n = -n
+		}
+		switch m[3] {
+		case "d":
+			return day(today.AddDate(0, 0, n)), nil
+		case "w":
+			return day(today.AddDate(0, 0, 7*n)), nil
+		case "m":
+			return day(today.AddDate(0, n, 0)), nil
+		default:
+			return day(today.AddDate(n, 0, 0)), nil
+		}
+	}
+
+	words := strings.Split(expr, " ")
+	if len(words) == 2 {
+		if r, ok := periodRange(words[0], words[1], today); ok {
+			return r, nil
+		}
+	}
+
+	return DateRange{}, fmt.Errorf("unrecognised date %q (try YYYY-MM-DD, today, yesterday, -3d, last monday or this month)", strings.TrimSpace(input))
+}
+
+// periodRange resolves "last/next <weekday>" and "this/last week|month|year".
+func periodRange(which, unit string, today time.Time) (DateRange, bool) {
+	if wd, ok := weekdays[unit]; ok {
+		switch which {
+		case "last":
+			back := (int(today.Weekday()) - int(wd) + 7) % 7
+			if back == 0 {
+				back = 7
+			}
+			return day(today.AddDate(0, 0, -back)), true
+		case "next":
+			ahead := (int(wd) - int(today.Weekday()) + 7) % 7
+			if ahead == 0 {
+				ahead = 7
+			}
+			return day(today.AddDate(0, 0, ahead)), true
+		}
+		return DateRange{}, false
+	}
+
+	var start time.Time
+	var step func(t time.Time, n int) time.Time
+	switch unit {
+	case "week":
+		// Weeks start on Monday
+		offset := (int(today.Weekday()) + 6) % 7
+		start = today.AddDate(0, 0, -offset)
+		step = func(t time.Time, n int) time.Time { return t.AddDate(0, 0, 7*n) }
+	case "month":
+		start = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		step = func(t time.Time, n int) time.Time { return t.AddDate(0, n, 0) }
+	case "year":
+		start = time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		step = func(t time.Time, n int) time.Time { return t.AddDate(n, 0, 0) }
+	default:
+		return DateRange{}, false
+	}
+
+	switch which {
+	case "this":
+	case "last":
+		start = step(start, -1)
+	default:
+		return DateRange{}, false
+	}
+
+	return DateRange{Start: start, End: step(start, 1).AddDate(0, 0, -1)}, true
+}
+
+// dateHint renders the resolved value of a date input, or why it is invalid.
+func dateHint(value string, now time.Time) string {
+	if strings.TrimSpace(value) == "" {
+		return ""
+	}
+	r, err := ParseDateExpr(value, now)
+	if err != nil {
+		return errorStyle.Render(err.Error())
+	}
+	return successStyle.Render("= " + r.String())
+}
+
+// withDateHint appends the hint of a date input below its view.
+func withDateHint(input textinput.Model) string {
+	hint := dateHint(input.Value(), time.Now())
+	if hint == "" {
+		return input.View()
+	}
+	return input.View() + "\n" + hint
+}
@@ -0,0 +1,354 @@
+package tui
+
+import (
+	"fmt"
+	"quattrinitrack/config"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type keyMap struct {
+	up           key.Binding
+	down         key.Binding
+	quit         key.Binding
+	help         key.Binding
+	clear        key.Binding
+	enter        key.Binding
+	back         key.Binding
+	tab          key.Binding
+	add          key.Binding
+	del          key.Binding
+	refresh      key.Binding
+	filter       key.Binding
+	search       key.Binding
+	sortDate     key.Binding
+	sortCost     key.Binding
+	sortName     key.Binding
+	sortCategory key.Binding
+	calendar     key.Binding
+	switchAuth   key.Binding
+	logLevel     key.Binding
+	logWindow    key.Binding
+	follow       key.Binding
+	open         key.Binding
+	undo         key.Binding
+
+	// Keys of the calendar, only read while it is open
+	prevDay   key.Binding
+	nextDay   key.Binding
+	prevWeek  key.Binding
+	nextWeek  key.Binding
+	prevMonth key.Binding
+	nextMonth key.Binding
+	today     key.Binding
+
+	// Keys of the category picker, the others being typed in its search
+	pickerUp   key.Binding
+	pickerDown key.Binding
+}
+
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.help, k.quit}
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.up, k.down, k.enter},
+		{k.back, k.clear, k.quit},
+		{k.add, k.del, k.refresh},
+	}
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "move up"),
+		),
+		down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "move down"),
+		),
+		quit: key.NewBinding(
+			key.WithKeys("q", "ctrl+c"),
+			key.WithHelp("q", "quit"),
+		),
+		help: key.NewBinding(
+			key.WithKeys("?"),
+			key.WithHelp("?", "toggle help"),
+		),
+		clear: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "clear logs"),
+		),
+		enter: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "select"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc", "backspace"),
+			key.WithHelp("esc", "back to menu"),
+		),
+		tab: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "next field"),
+		),
+		add: key.NewBinding(
+			key.WithKeys("ctrl+a"),
+			key.WithHelp("ctrl+a", "add transaction/category"),
+		),
+		del: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+			key.WithHelp("ctrl+d", "delete transaction/category"),
+		),
+		refresh: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "refresh"),
+		),
+		filter: key.NewBinding(
+			key.WithKeys("ctrl+f"),
+			key.WithHelp("ctrl+f", "filter"),
+		),
+		search: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "search"),
+		),
+		sortDate: key.NewBinding(
+			key.WithKeys("1"),
+			key.WithHelp("1", "sort by date"),
+		),
+		sortCost: key.NewBinding(
+			key.WithKeys("2"),
+			key.WithHelp("2", "sort by cost"),
+		),
+		sortName: key.NewBinding(
+			key.WithKeys("3"),
+			key.WithHelp("3", "sort by name"),
+		),
+		sortCategory: key.NewBinding(
+			key.WithKeys("4"),
+			key.WithHelp("4", "sort by category"),
+		),
+		calendar: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "calendar"),
+		),
+		switchAuth: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "toggle login/register"),
+		),
+		logLevel: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", "minimum level"),
+		),
+		logWindow: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "time window"),
+		),
+		follow: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "pause/follow"),
+		),
+		open: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "open attachments"),
+		),
+		undo: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "undo delete"),
+		),
+		prevDay: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←", "previous day"),
+		),
+		nextDay: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→", "next day"),
+		),
+		prevWeek: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑", "previous week"),
+		),
+		nextWeek: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓", "next week"),
+		),
+		prevMonth: key.NewBinding(
+			key.WithKeys("pgup", "["),
+			key.WithHelp("[", "previous month"),
+		),
+		nextMonth: key.NewBinding(
+			key.WithKeys("pgdown", "]"),
+			key.WithHelp("]", "next month"),
+		),
+		today: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "today"),
+		),
+		pickerUp: key.NewBinding(
+			key.WithKeys("up"),
+			key.WithHelp("↑", "previous category"),
+		),
+		pickerDown: key.NewBinding(
+			key.WithKeys("down"),
+			key.WithHelp("↓", "next category"),
+		),
+	}
+}
+
+var keys = defaultKeyMap()
+
+// named maps the action names used in the config file to the bindings.
+func (k *keyMap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"up":            &k.up,
+		"down":          &k.down,
+		"quit":          &k.quit,
+		"help":          &k.help,
+		"clear":         &k.clear,
+		"enter":         &k.enter,
+		"back":          &k.back,
+		"tab":           &k.tab,
+		"add":           &k.add,
+		"delete":        &k.del,
+		"refresh":       &k.refresh,
+		"filter":        &k.filter,
+		"search":        &k.search,
+		"sort_date":     &k.sortDate,
+		"sort_cost":     &k.sortCost,
+		"sort_name":     &k.sortName,
+		"sort_category": &k.sortCategory,
+		"calendar":      &k.calendar,
+		"switch_auth":   &k.switchAuth,
+		"log_level":     &k.logLevel,
+		"log_window":    &k.logWindow,
+		"follow":        &k.follow,
+		"open":          &k.open,
+		"undo":          &k.undo,
+
+		"calendar_prev_day":   &k.prevDay,
+		"calendar_next_day":   &k.nextDay,
+		"calendar_prev_week":  &k.prevWeek,
+		"calendar_next_week":  &k.nextWeek,
+		"calendar_prev_month": &k.prevMonth,
+		"calendar_next_month": &k.nextMonth,
+		"calendar_today":      &k.today,
+		"picker_up":           &k.pickerUp,
+		"picker_down":         &k.pickerDown,
+	}
+}
+
+// keyScope is a part of the TUI taking the keys while it is open: its own
+// actions only apply there, along with the shared ones it also handles.
+type keyScope struct {
+	actions []string
+	shared  []string
+}
+
+// keyScopes are the calendar and the category picker. Their actions only have
+// to differ from the others of the same scope.
+var keyScopes = []keyScope{
+	{
+		actions: []string{"calendar_prev_day", "calendar_next_day", "calendar_prev_week", "calendar_next_week",
+			"calendar_prev_month", "calendar_next_month", "calendar_today"},
+		shared: []string{"enter", "back"},
+	},
+	{
+		actions: []string{"picker_up", "picker_down"},
+		shared:  []string{"enter", "back", "tab"},
+	},
+}
+
+// buildKeyMap applies the overrides to the default bindings and rejects
+// unknown actions and keys bound to more than one action.
+func buildKeyMap(overrides map[string][]string) (keyMap, error) {
+	k := defaultKeyMap()
+	bindings := k.named()
+
+	for action, keyNames := range overrides {
+		binding, ok := bindings[action]
+		if !ok {
+			return k, fmt.Errorf("unknown key binding action %q", action)
+		}
+		if len(keyNames) == 0 {
+			return k, fmt.Errorf("no keys given for action %q", action)
+		}
+		binding.SetKeys(keyNames...)
+		binding.SetHelp(strings.Join(keyNames, "/"), binding.Help().Desc)
+	}
+
+	// The screens handle every action but those of the scopes
+	scoped := make(map[string]bool)
+	groups := make([][]string, 0, len(keyScopes)+1)
+	for _, scope := range keyScopes {
+		for _, action := range scope.actions {
+			scoped[action] = true
+		}
+		groups = append(groups, slices.Concat(scope.actions, scope.shared))
+	}
+	var screens []string
+	for action := range bindings {
+		if !scoped[action] {
+			screens = append(screens, action)
+		}
+	}
+	groups = append(groups, screens)
+
+	for _, actions := range groups {
+		if err := checkConflicts(bindings, actions); err != nil {
+			return k, err
+		}
+	}
+	return k, nil
+}
+
+// checkConflicts rejects a key bound to more than one of the actions.
+func checkConflicts(bindings map[string]*key.Binding, actions []string) error {
+	actions = slices.Sorted(slices.Values(actions))
+
+	owners := make(map[string]string)
+	for _, action := range actions {
+		for _, keyName := range bindings[action].Keys() {
+			if owner, ok := owners[keyName]; ok {
+				return fmt.Errorf("key %q is bound to both %q and %q", keyName, owner, action)
+			}
+			owners[keyName] = action
+		}
+	}
+	return nil
+}
+
+// helpKey returns the label shown for a binding in the help lines.
+func helpKey(b key.Binding) string {
+	return b.Help().Key
+}
+
+// hint formats a binding for a help line, with a description fitting the screen.
+func hint(b key.Binding, desc string) string {
+	return helpKey(b) + ": " + desc
+}
+
+// helpLine joins the hints shown at the bottom of a screen.
+func helpLine(hints ...string) string {
+	return strings.Join(hints, " • ")
+}
+
+// Configure applies the key bindings and theme from the config file. It must
+// be called before Init.
+func Configure(cfg config.TUIConfig) error {
+	k, err := buildKeyMap(cfg.Keys)
+	if err != nil {
+		return err
+	}
+
+	t, err := buildTheme(cfg.Theme, cfg.Colors)
+	if err != nil {
+		return err
+	}
+
+	keys = k
+	applyTheme(t)
+	return nil
+}
@@ -0,0 +1,206 @@
+package tui
+
+import (
+	"fmt"
+	"log/slog"
+	"quattrinitrack/logger"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// logLevels are the minimum levels cycled through on the logs screen.
+var logLevels = []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+// logWindows are the time windows cycled through on the logs screen, zero
+// meaning the whole log.
+var logWindows = []time.Duration{0, 5 * time.Minute, time.Hour, 24 * time.Hour}
+
+func newLogSearch() textinput.Model {
+	search := textinput.New()
+	search.Placeholder = "search logs"
+	search.Prompt = "/"
+	search.CharLimit = 50
+	search.Width = 30
+	return search
+}
+
+// logQuery builds the query for the filters selected on the logs screen.
+func (m model) logQuery() logger.LogQuery {
+	q := logger.LogQuery{
+		MinLevel: logLevels[m.logLevel],
+		Text:     strings.TrimSpace(m.logSearch.Value()),
+	}
+	if window := logWindows[m.logWindow]; window > 0 {
+		q.Since = time.Now().Add(-window)
+	}
+	return q
+}
+
+// refreshLogs renders the matching logs, keeping the newest in view unless
+// paused.
+func (m *model) refreshLogs() {
+	if !m.ready {
+		return
+	}
+	// Read first, so that an entry added while querying refreshes again
+	m.logVersion = logger.Version()
+	q := m.logQuery()
+	logs := logger.QueryLogs(q)
+
+	// The screen next goes stale when its oldest entry leaves the time window
+	m.logExpiry = time.Time{}
+	if !q.Since.IsZero() && len(logs) > 0 {
+		m.logExpiry = logs[0].Timestamp.Add(logWindows[m.logWindow])
+	}
+
+	m.viewport.SetContent(m.formatLogs(q, logs))
+	if !m.logPaused {
+		m.viewport.GotoBottom()
+	}
+}
+
+// updateLogs handles the keys of the logs screen.
+func (m *model) updateLogs(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+
+	if m.logSearching {
+		switch msg.String() {
+		case "esc":
+			m.logSearch.SetValue("")
+			m.logSearch.Blur()
+			m.logSearching = false
+		case "enter":
+			m.logSearch.Blur()
+			m.logSearching = false
+		default:
+			m.logSearch, cmd = m.logSearch.Update(msg)
+		}
+		m.refreshLogs()
+		return cmd
+	}
+
+	switch {
+	case key.Matches(msg, keys.back):
+		m.currentScreen = menuScreen
+	case key.Matches(msg, keys.help):
+		m.showHelp = !m.showHelp
+	case key.Matches(msg, keys.clear):
+		logger.ClearLogs()
+		m.refreshLogs()
+	case key.Matches(msg, keys.search):
+		m.logSearching = true
+		cmd = m.logSearch.Focus()
+	case key.Matches(msg, keys.logLevel):
+		m.logLevel = (m.logLevel + 1) % len(logLevels)
+		m.refreshLogs()
+	case key.Matches(msg, keys.logWindow):
+		m.logWindow = (m.logWindow + 1) % len(logWindows)
+		m.refreshLogs()
+	case key.Matches(msg, keys.follow):
+		m.logPaused = !m.logPaused
+		m.refreshLogs()
+	default:
+		// Handle viewport navigation
+		m.viewport, cmd = m.viewport.Update(msg)
+	}
+	return cmd
+}
+
+// logsChanged reports whether the logs screen shows stale content.
+func (m model) logsChanged() bool {
+	if m.logPaused {
+		return false
+	}
+	// Entries leave a time window without the log changing
+	return logger.Version() != m.logVersion || (!m.logExpiry.IsZero() && !time.Now().Before(m.logExpiry))
+}
+
+// formatLogs renders the logs matching q.
+func (m model) formatLogs(q logger.LogQuery, logs []logger.LogEntry) string {
+	if logger.GetLogCount() == 0 {
+		return logStyle.Render("No logs yet... Server is running and waiting for requests.")
+	}
+
+	if len(logs) == 0 {
+		return mutedStyle.Render("No logs match the current filters.")
+	}
+
+	var content strings.Builder
+
+	for _, log := range logs {
+		timestamp := timestampStyle.Render(log.Timestamp.Format("15:04:05"))
+		style := levelStyle(log.Level)
+		level := style.Render(fmt.Sprintf("%-5s", log.Level))
+		message := highlight(log.Message, q.Text, style)
+
+		content.WriteString(fmt.Sprintf("%s [%s] %s", timestamp, level, message))
+		for _, attr := range log.Attrs {
+			content.WriteString(" " + highlight(attr.Key+"="+attr.Value.String(), q.Text, mutedStyle))
+		}
+		content.WriteString("\n")
+	}
+
+	return content.String()
+}
+
+// levelStyle picks the style a log line is rendered with from its level.
+func levelStyle(level slog.Level) lipgloss.Style {
+	switch {
+	case level >= slog.LevelError:
+		return errorStyle
+	case level >= slog.LevelWarn:
+		return timestampStyle
+	case level < slog.LevelInfo:
+		return mutedStyle
+	default:
+		return logStyle
+	}
+}
+
+// highlight renders text with style, marking the matches of search.
+func highlight(text, search string, style lipgloss.Style) string {
+	lower := strings.ToLower(text)
+	search = strings.ToLower(search)
+	// Lowering some runes changes their length, the offsets would not line up
+	if search == "" || len(lower) != len(text) {
+		return style.Render(text)
+	}
+
+	var s strings.Builder
+	for {
+		i := strings.Index(lower, search)
+		if i < 0 {
+			break
+		}
+		if i > 0 {
+			s.WriteString(style.Render(text[:i]))
+		}
+		s.WriteString(highlightStyle.Render(text[i : i+len(search)]))
+		text, lower = text[i+len(search):], lower[i+len(search):]
+	}
+	if text != "" {
+		s.WriteString(style.Render(text))
+	}
+	return s.String()
+}
+
+// logStatus describes the filters applied to the logs screen.
+func (m model) logStatus() string {
+	parts := []string{"≥ " + logLevels[m.logLevel].String()}
+	if window := logWindows[m.logWindow]; window > 0 {
+		parts = append(parts, "last "+strings.TrimSuffix(strings.TrimSuffix(window.String(), "0s"), "0m"))
+	} else {
+		parts = append(parts, "all time")
+	}
+	if m.logPaused {
+		parts = append(parts, "paused")
+	} else {
+		parts = append(parts, "following")
+	}
+	return strings.Join(parts, " • ")
+}
@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// split is the part of a split transaction going to one category.
+type split struct {
+	CategoriesID int64   `json:"categories_id"`
+	CategoryName string  `json:"category_name,omitempty"`
+	Amount       float64 `json:"amount"`
+}
+
+// splitEditor edits the lines of a split transaction, written as
+// "Groceries 30.10, Household 12.30": each line is the name or path of a
+// category followed by its amount. Left empty, the transaction is not split.
+type splitEditor struct {
+	input textinput.Model
+}
+
+// splitLine is a line of the editor, with the reason it cannot be used.
+type splitLine struct {
+	category category
+	amount   float64
+	err      error
+}
+
+func newSplitEditor() splitEditor {
+	input := textinput.New()
+	input.Placeholder = "Optional, e.g. Groceries 30.10, Household 12.30"
+	input.CharLimit = 300
+	input.Width = 45
+	return splitEditor{input: input}
+}
+
+func (e *splitEditor) reset() {
+	e.input.SetValue("")
+}
+
+func (e splitEditor) empty() bool {
+	return strings.TrimSpace(e.input.Value()) == ""
+}
+
+// splitLines parses the lines entered, skipping blank ones.
+func (m model) splitLines() []splitLine {
+	var lines []splitLine
+	for _, text := range strings.Split(m.splitEditor.input.Value(), ",") {
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var line splitLine
+		amount, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if len(fields) < 2 || err != nil || amount <= 0 {
+			line.err = fmt.Errorf("%q must end with a positive amount", strings.TrimSpace(text))
+			lines = append(lines, line)
+			continue
+		}
+		line.amount = amount
+		line.category, line.err = m.categoryByPath(strings.Join(fields[:len(fields)-1], " "))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitRemainder returns how much of the cost the lines leave unassigned, in
+// cents, and whether the cost could be read.
+func (m model) splitRemainder(lines []splitLine) (int64, bool) {
+	cost, err := strconv.ParseFloat(strings.TrimSpace(m.transactionCostInput.Value()), 64)
+	if err != nil {
+		return 0, false
+	}
+	remainder := int64(math.Round(cost * 100))
+	for _, line := range lines {
+		remainder -= int64(math.Round(line.amount * 100))
+	}
+	return remainder, true
+}
+
+// splits returns the lines to send, or why they cannot be.
+func (m model) splits() ([]split, error) {
+	lines := m.splitLines()
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("a split needs at least 2 lines")
+	}
+
+	var splits []split
+	for _, line := range lines {
+		if line.err != nil {
+			return nil, line.err
+		}
+		splits = append(splits, split{CategoriesID: line.category.ID, Amount: line.amount})
+	}
+	if remainder, ok := m.splitRemainder(lines); !ok || remainder != 0 {
+		return nil, fmt.Errorf("the split lines must add up to the cost")
+	}
+	return splits, nil
+}
+
+func (m model) splitEditorView() string {
+	var s strings.Builder
+	s.WriteString(m.splitEditor.input.View())
+	if m.splitEditor.empty() {
+		return s.String()
+	}
+
+	lines := m.splitLines()
+	for _, line := range lines {
+		if line.err != nil {
+			s.WriteString("\n" + errorStyle.Render("  "+line.err.Error()))
+			continue
+		}
+		s.WriteString("\n" + menuItemStyle.Render(fmt.Sprintf("%-30s %10.2f", line.category.label(), line.amount)))
+	}
+
+	remainder, ok := m.splitRemainder(lines)
+	switch {
+	case !ok:
+		s.WriteString("\n" + mutedStyle.Render("  Enter the cost to check the split"))
+	case remainder == 0:
+		s.WriteString("\n" + successStyle.Render("  Adds up to the cost"))
+	case remainder < 0:
+		s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("  %.2f over the cost", float64(-remainder)/100)))
+	default:
+		s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("  %.2f left to assign", float64(remainder)/100)))
+	}
+	return s.String()
+}
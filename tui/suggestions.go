@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// suggestionPreselect is the confidence from which the picker highlights the
+// top suggestion instead of leaving the category to the rules.
+const suggestionPreselect = 0.5
+
+// categorySuggestion is a category the server suggests, learned from the
+// history of transactions.
+type categorySuggestion struct {
+	CategoriesID int64   `json:"categories_id"`
+	Confidence   float64 `json:"confidence"`
+}
+
+// loadSuggestions asks for the categories matching the name and cost typed in
+// the add transaction form, and offers them in the category picker.
+func (m *model) loadSuggestions() {
+	name := strings.TrimSpace(m.transactionInput.Value())
+	if name == "" {
+		m.categoryPicker.suggest(nil)
+		return
+	}
+	query := url.Values{"name": {name}}
+	if cost, err := strconv.ParseFloat(strings.TrimSpace(m.transactionCostInput.Value()), 64); err == nil && cost > 0 {
+		query.Set("cost", strconv.FormatFloat(cost, 'f', -1, 64))
+	}
+
+	req, err := http.NewRequest("GET", apiBaseURL+"/category/suggest?"+query.Encode(), nil)
+	if err != nil {
+		m.transactionMessage = fmt.Sprintf("Error creating request: %v", err)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+m.authToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		m.transactionMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		m.transactionMessage = "Error: " + apiError(resp)
+		return
+	}
+
+	var suggestions []categorySuggestion
+	if err := json.NewDecoder(resp.Body).Decode(&suggestions); err != nil {
+		m.transactionMessage = fmt.Sprintf("Error decoding response: %v", err)
+		return
+	}
+	m.categoryPicker.suggest(suggestions)
+}
@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+type tag struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// tagInput edits the comma separated tags of a transaction, suggesting the
+// existing tags starting like the one being typed.
+type tagInput struct {
+	input textinput.Model
+}
+
+func newTagInput() tagInput {
+	input := textinput.New()
+	input.Placeholder = "Tags, comma separated"
+	input.CharLimit = 200
+	input.Width = 30
+	return tagInput{input: input}
+}
+
+func (t *tagInput) reset() {
+	t.input.SetValue("")
+}
+
+// tags returns the tags entered, without blanks and duplicates.
+func (t tagInput) tags() []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(t.input.Value(), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		tags = append(tags, name)
+	}
+	return tags
+}
+
+// current returns the tag being typed, after the last comma.
+func (t tagInput) current() string {
+	value := t.input.Value()
+	return strings.TrimSpace(value[strings.LastIndex(value, ",")+1:])
+}
+
+// suggestions returns the known tags starting with the one being typed and
+// not entered yet.
+func (t tagInput) suggestions(known []tag) []string {
+	current := strings.ToLower(t.current())
+	if current == "" {
+		return nil
+	}
+
+	entered := make(map[string]bool)
+	for _, name := range t.tags() {
+		entered[strings.ToLower(name)] = true
+	}
+
+	var suggestions []string
+	for _, k := range known {
+		name := strings.ToLower(k.Name)
+		if strings.HasPrefix(name, current) && (name == current || !entered[name]) {
+			suggestions = append(suggestions, k.Name)
+		}
+		if len(suggestions) == pickerVisibleOptions {
+			break
+		}
+	}
+	return suggestions
+}
+
+// complete replaces the tag being typed with the first suggestion. It reports
+// whether there was one to use.
+func (t *tagInput) complete(known []tag) bool {
+	suggestions := t.suggestions(known)
+	if len(suggestions) == 0 {
+		return false
+	}
+
+	value := t.input.Value()
+	prefix := value[:strings.LastIndex(value, ",")+1]
+	if prefix != "" {
+		prefix += " "
+	}
+	t.input.SetValue(prefix + suggestions[0] + ", ")
+	t.input.CursorEnd()
+	return true
+}
+
+func (t tagInput) view(known []tag) string {
+	var s strings.Builder
+	s.WriteString(t.input.View())
+	if !t.input.Focused() {
+		return s.String()
+	}
+
+	for i, suggestion := range t.suggestions(known) {
+		if i == 0 {
+			s.WriteString("\n" + selectedMenuStyle.Render("▶ "+suggestion))
+		} else {
+			s.WriteString("\n" + menuItemStyle.Render(suggestion))
+		}
+	}
+	return s.String()
+}
+
+// loadTags fetches the existing tags, used for autocompletion.
+func (m *model) loadTags() {
+	req, err := http.NewRequest("GET", apiBaseURL+"/tag", nil)
+	if err != nil {
+		m.transactionMessage = fmt.Sprintf("Error creating request: %v", err)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+m.authToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		m.transactionMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		m.transactionMessage = "Error: " + apiError(resp)
+		return
+	}
+
+	var tags []tag
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		m.transactionMessage = fmt.Sprintf("Error decoding response: %v", err)
+		return
+	}
+	m.tags = tags
+}
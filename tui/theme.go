@@ -0,0 +1,229 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// theme is the palette every style of the TUI is built from.
+type theme struct {
+	accent     lipgloss.TerminalColor
+	accentDim  lipgloss.TerminalColor
+	background lipgloss.TerminalColor
+	text       lipgloss.TerminalColor
+	border     lipgloss.TerminalColor
+	menuText   lipgloss.TerminalColor
+	errorColor lipgloss.TerminalColor
+	success    lipgloss.TerminalColor
+	muted      lipgloss.TerminalColor
+}
+
+var themes = map[string]theme{
+	"dark": {
+		accent:     lipgloss.Color("#fc595f"),
+		accentDim:  lipgloss.Color("#a63c40"),
+		background: lipgloss.Color("#252525"),
+		text:       lipgloss.Color("#ffffff"),
+		border:     lipgloss.Color("#FFFDF5"),
+		menuText:   lipgloss.Color("255"),
+		errorColor: lipgloss.Color("196"),
+		success:    lipgloss.Color("42"),
+		muted:      lipgloss.Color("245"),
+	},
+	"light": {
+		accent:     lipgloss.Color("#c0262d"),
+		accentDim:  lipgloss.Color("#8a1c20"),
+		background: lipgloss.Color("#f0e6e6"),
+		text:       lipgloss.Color("#1a1a1a"),
+		border:     lipgloss.Color("#3a3a3a"),
+		menuText:   lipgloss.Color("235"),
+		errorColor: lipgloss.Color("160"),
+		success:    lipgloss.Color("28"),
+		muted:      lipgloss.Color("242"),
+	},
+	"high-contrast": {
+		accent:     lipgloss.Color("#ffff00"),
+		accentDim:  lipgloss.Color("#ffffff"),
+		background: lipgloss.Color("#000000"),
+		text:       lipgloss.Color("#ffffff"),
+		border:     lipgloss.Color("#ffffff"),
+		menuText:   lipgloss.Color("#ffffff"),
+		errorColor: lipgloss.Color("#ff0000"),
+		success:    lipgloss.Color("#00ff00"),
+		muted:      lipgloss.Color("#c0c0c0"),
+	},
+}
+
+// noColorTheme is used when NO_COLOR is set, see https://no-color.org
+var noColorTheme = theme{
+	accent:     lipgloss.NoColor{},
+	accentDim:  lipgloss.NoColor{},
+	background: lipgloss.NoColor{},
+	text:       lipgloss.NoColor{},
+	border:     lipgloss.NoColor{},
+	menuText:   lipgloss.NoColor{},
+	errorColor: lipgloss.NoColor{},
+	success:    lipgloss.NoColor{},
+	muted:      lipgloss.NoColor{},
+}
+
+// current is the theme the styles were last built from.
+var current = themes["dark"]
+
+var (
+	titleStyle        lipgloss.Style
+	infoStyle         lipgloss.Style
+	logStyle          lipgloss.Style
+	errorStyle        lipgloss.Style
+	timestampStyle    lipgloss.Style
+	menuStyle         lipgloss.Style
+	selectedMenuStyle lipgloss.Style
+	menuItemStyle     lipgloss.Style
+	inputStyle        lipgloss.Style
+	successStyle      lipgloss.Style
+	focusedStyle      lipgloss.Style
+	noStyle           lipgloss.Style
+	tableStyle        lipgloss.Style
+	barStyle          lipgloss.Style
+	mutedStyle        lipgloss.Style
+	highlightStyle    lipgloss.Style
+)
+
+func init() {
+	applyTheme(current)
+}
+
+// colorSetters maps the color names accepted in the config file to the theme fields.
+func (t *theme) colorSetters() map[string]*lipgloss.TerminalColor {
+	return map[string]*lipgloss.TerminalColor{
+		"accent":     &t.accent,
+		"accent_dim": &t.accentDim,
+		"background": &t.background,
+		"text":       &t.text,
+		"border":     &t.border,
+		"menu_text":  &t.menuText,
+		"error":      &t.errorColor,
+		"success":    &t.success,
+		"muted":      &t.muted,
+	}
+}
+
+// buildTheme resolves the named preset and applies the color overrides on top
+// of it. With NO_COLOR set, the config is still checked before every color is
+// dropped.
+func buildTheme(name string, colors map[string]string) (theme, error) {
+	if name == "" {
+		name = "dark"
+	}
+	t, ok := themes[name]
+	if !ok {
+		names := make([]string, 0, len(themes))
+		for n := range themes {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return t, fmt.Errorf("unknown theme %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+
+	setters := t.colorSetters()
+	for colorName, value := range colors {
+		setter, ok := setters[colorName]
+		if !ok {
+			return t, fmt.Errorf("unknown theme color %q", colorName)
+		}
+		if value == "" {
+			return t, fmt.Errorf("empty value for theme color %q", colorName)
+		}
+		*setter = lipgloss.Color(value)
+	}
+
+	if os.Getenv("NO_COLOR") != "" {
+		return noColorTheme, nil
+	}
+	return t, nil
+}
+
+// applyTheme rebuilds every style from the given theme.
+func applyTheme(t theme) {
+	current = t
+
+	titleStyle = lipgloss.NewStyle().
+		Foreground(t.accent).
+		Background(t.background).
+		Padding(0, 1)
+
+	infoStyle = lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(t.border)
+
+	logStyle = lipgloss.NewStyle().
+		Foreground(t.text)
+
+	errorStyle = lipgloss.NewStyle().
+		Foreground(t.errorColor)
+
+	timestampStyle = lipgloss.NewStyle().
+		Foreground(t.accentDim)
+
+	menuStyle = lipgloss.NewStyle().
+		Foreground(t.menuText).
+		Padding(1, 2)
+
+	selectedMenuStyle = lipgloss.NewStyle().
+		Foreground(t.accent).
+		Background(t.background).
+		Padding(0, 1)
+
+	menuItemStyle = lipgloss.NewStyle().
+		Foreground(t.text).
+		Padding(0, 2)
+
+	inputStyle = lipgloss.NewStyle().
+		Foreground(t.text).
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(t.accent).
+		Padding(1, 2)
+
+	successStyle = lipgloss.NewStyle().
+		Foreground(t.success)
+
+	focusedStyle = lipgloss.NewStyle().
+		Foreground(t.accent)
+
+	noStyle = lipgloss.NewStyle()
+
+	tableStyle = lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(t.accent)
+
+	barStyle = lipgloss.NewStyle().
+		Foreground(t.accent)
+
+	mutedStyle = lipgloss.NewStyle().
+		Foreground(t.muted)
+
+	highlightStyle = lipgloss.NewStyle().
+		Foreground(t.background).
+		Background(t.accent).
+		Bold(true)
+}
+
+// tableStyles returns the styles shared by the category and transaction tables.
+func tableStyles() table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(current.accent).
+		BorderBottom(true).
+		Bold(false)
+	s.Selected = s.Selected.
+		Foreground(current.accent).
+		Background(current.background).
+		Bold(false)
+	return s
+}
@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+const (
+	transactionPageSize = 200
+	// Fetch the next page once the cursor gets this close to the last loaded row
+	transactionPrefetchRows = 20
+)
+
+var sortColumns = map[string]string{
+	"date":     "Date",
+	"cost":     "Cost",
+	"name":     "Name",
+	"category": "Category",
+}
+
+// loadTransactions reloads the transaction table from its first page.
+func (m *model) loadTransactions() {
+	m.transactions = nil
+	m.filteredTransactions = nil
+	m.transactionTotal = 0
+	m.transactionsExhausted = false
+	m.transactionNameFiltered = false
+	m.transactionTable.SetCursor(0)
+	m.loadMoreTransactions()
+}
+
+// loadMoreTransactions appends the next page of transactions to the table.
+func (m *model) loadMoreTransactions() {
+	if m.transactionsExhausted {
+		return
+	}
+
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(transactionPageSize))
+	params.Set("offset", strconv.Itoa(len(m.transactions)))
+	params.Set("sort", m.transactionSortKey)
+	if m.transactionSortDesc {
+		params.Set("order", "desc")
+	} else {
+		params.Set("order", "asc")
+	}
+	if search := m.transactionSearch.Value(); search != "" {
+		params.Set("q", search)
+	}
+	if !m.transactionFrom.IsZero() {
+		params.Set("from", m.transactionFrom.Format(dateLayout))
+	}
+	if !m.transactionTo.IsZero() {
+		params.Set("to", m.transactionTo.Format(dateLayout))
+	}
+
+	client := &http.Client{}
+	req, err := http.NewRequest("GET", apiBaseURL+"/transaction?"+params.Encode(), nil)
+	if err != nil {
+		m.transactionMessage = fmt.Sprintf("Error creating request: %v", err)
+		return
+	}
+
+	req.Header.Set("Authorization", "Bearer "+m.authToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		m.transactionMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		m.transactionMessage = "Error: " + apiError(resp)
+		return
+	}
+
+	var page []transaction
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		m.transactionMessage = fmt.Sprintf("Error decoding response: %v", err)
+		return
+	}
+
+	if total, err := strconv.ParseInt(resp.Header.Get("X-Total-Count"), 10, 64); err == nil {
+		m.transactionTotal = total
+	}
+	m.transactionsExhausted = len(page) < transactionPageSize
+
+	m.transactions = append(m.transactions, page...)
+	m.filteredTransactions = m.transactions
+	m.updateTransactionTable()
+}
+
+// loadMoreTransactionsIfNeeded fetches the next page when the cursor nears the
+// end of the loaded rows.
+func (m *model) loadMoreTransactionsIfNeeded() {
+	if m.transactionNameFiltered || m.transactionsExhausted {
+		return
+	}
+	if m.transactionTable.Cursor() >= len(m.transactions)-transactionPrefetchRows {
+		m.loadMoreTransactions()
+	}
+}
+
+// sortTransactionsBy sorts on the given column, flipping the direction when
+// it is already the sort column.
+func (m *model) sortTransactionsBy(sortKey string) {
+	if m.transactionSortKey == sortKey {
+		m.transactionSortDesc = !m.transactionSortDesc
+	} else {
+		m.transactionSortKey = sortKey
+		m.transactionSortDesc = sortKey == "date" || sortKey == "cost"
+	}
+	m.loadTransactions()
+}
+
+func (m model) columnTitle(title string) string {
+	if sortColumns[m.transactionSortKey] != title {
+		return title
+	}
+	if m.transactionSortDesc {
+		return title + " ▼"
+	}
+	return title + " ▲"
+}
+
+// transactionStatus describes how many rows are loaded and how they are sorted.
+func (m model) transactionStatus() string {
+	order := "ascending"
+	if m.transactionSortDesc {
+		order = "descending"
+	}
+	if m.transactionNameFiltered {
+		return fmt.Sprintf("%d transactions matching %q", len(m.filteredTransactions), m.transactionNameFilter.Value())
+	}
+	return fmt.Sprintf("Showing %d of %d • sorted by %s %s", len(m.transactions), m.transactionTotal, m.transactionSortKey, order)
+}
+
+func (m *model) updateTransactionTable() {
+	columns := []table.Column{
+		{Title: "ID", Width: 8},
+		{Title: m.columnTitle("Name"), Width: 20},
+		{Title: m.columnTitle("Cost"), Width: 10},
+		{Title: m.columnTitle("Date"), Width: 15},
+		{Title: m.columnTitle("Category"), Width: 15},
+		{Title: "Tags", Width: 20},
+		// Number of attachments, with a star when there are notes
+		{Title: "Files", Width: 5},
+	}
+
+	rows := make([]table.Row, 0, len(m.filteredTransactions))
+	for _, t := range m.filteredTransactions {
+		name := t.categoryLabel()
+		rows = append(rows, table.Row{
+			strconv.FormatInt(t.ID, 10),
+			t.Name,
+			fmt.Sprintf("%.2f", t.Cost),
+			t.Date.Format("2006-01-02"),
+			name,
+			strings.Join(t.Tags, ", "),
+			t.attachmentIndicator(),
+		})
+	}
+
+	// Keep the cursor where it was when more rows are appended
+	cursor := m.transactionTable.Cursor()
+	m.transactionTable = table.New(
+		table.WithColumns(columns),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(10),
+	)
+	// Style similar to categories
+	m.transactionTable.SetStyles(tableStyles())
+	m.transactionTable.SetCursor(min(cursor, max(0, len(rows)-1)))
+}
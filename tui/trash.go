@@ -0,0 +1,239 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type trashKind string
+
+const (
+	trashedTransaction trashKind = "transaction"
+	trashedCategory    trashKind = "category"
+)
+
+// trashItem is a deleted transaction or category, which can be restored until
+// the server purges it.
+type trashItem struct {
+	kind      trashKind
+	id        int64
+	name      string
+	detail    string
+	deletedAt time.Time
+	purgeAt   *time.Time
+}
+
+type trashResponse struct {
+	Transactions []struct {
+		transaction
+		DeletedAt time.Time  `json:"deleted_at"`
+		PurgeAt   *time.Time `json:"purge_at"`
+	} `json:"transactions"`
+	Categories []struct {
+		category
+		DeletedAt time.Time  `json:"deleted_at"`
+		PurgeAt   *time.Time `json:"purge_at"`
+	} `json:"categories"`
+}
+
+// loadTrash fetches the trash, categories first as they have to be restored
+// before their transactions.
+func (m *model) loadTrash() {
+	req, err := http.NewRequest("GET", apiBaseURL+"/trash", nil)
+	if err != nil {
+		m.trashMessage = fmt.Sprintf("Error creating request: %v", err)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+m.authToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		m.trashMessage = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		m.trashMessage = "Error: " + apiError(resp)
+		return
+	}
+
+	var trash trashResponse
+	if err := json.NewDecoder(resp.Body).Decode(&trash); err != nil {
+		m.trashMessage = fmt.Sprintf("Error decoding response: %v", err)
+		return
+	}
+
+	items := make([]trashItem, 0, len(trash.Categories)+len(trash.Transactions))
+	for _, c := range trash.Categories {
+		items = append(items, trashItem{
+			kind:      trashedCategory,
+			id:        c.ID,
+			name:      c.Name,
+			deletedAt: c.DeletedAt,
+			purgeAt:   c.PurgeAt,
+		})
+	}
+	for _, t := range trash.Transactions {
+		items = append(items, trashItem{
+			kind:      trashedTransaction,
+			id:        t.ID,
+			name:      t.Name,
+			detail:    fmt.Sprintf("%.2f, %s", t.Cost, t.categoryLabel()),
+			deletedAt: t.DeletedAt,
+			purgeAt:   t.PurgeAt,
+		})
+	}
+	m.trashItems = items
+	m.updateTrashTable()
+}
+
+func (m *model) updateTrashTable() {
+	columns := []table.Column{
+		{Title: "Kind", Width: 12},
+		{Title: "ID", Width: 8},
+		{Title: "Name", Width: 20},
+		{Title: "Details", Width: 25},
+		{Title: "Deleted", Width: 12},
+		{Title: "Purged", Width: 12},
+	}
+
+	rows := make([]table.Row, 0, len(m.trashItems))
+	for _, item := range m.trashItems {
+		purge := "never"
+		if item.purgeAt != nil {
+			purge = item.purgeAt.Local().Format("2006-01-02")
+		}
+		rows = append(rows, table.Row{
+			string(item.kind),
+			strconv.FormatInt(item.id, 10),
+			item.name,
+			item.detail,
+			item.deletedAt.Local().Format("2006-01-02"),
+			purge,
+		})
+	}
+
+	cursor := m.trashTable.Cursor()
+	m.trashTable = table.New(
+		table.WithColumns(columns),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(10),
+	)
+	m.trashTable.SetStyles(tableStyles())
+	m.trashTable.SetCursor(min(cursor, max(0, len(rows)-1)))
+}
+
+// restore takes an item out of the trash.
+func (m *model) restore(kind trashKind, id int64) error {
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/%s/restore?id=%d", apiBaseURL, kind, id), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.authToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to restore %s: %s", kind, apiError(resp))
+	}
+	return nil
+}
+
+// undoDelete restores the transaction or category deleted last, returning the
+// message to show.
+func (m *model) undoDelete() string {
+	if m.lastDeleted == nil {
+		return "Nothing to undo"
+	}
+	if err := m.restore(m.lastDeleted.kind, m.lastDeleted.id); err != nil {
+		return fmt.Sprintf("Error: %v", err)
+	}
+	message := fmt.Sprintf("Restored %s %d successfully!", m.lastDeleted.kind, m.lastDeleted.id)
+	m.lastDeleted = nil
+	return message
+}
+
+// deletedMessage confirms a delete and tells how to undo it.
+func deletedMessage(kind string) string {
+	return fmt.Sprintf("%s moved to the trash successfully! Press '%s' to undo", kind, helpKey(keys.undo))
+}
+
+func (m *model) updateTrash(msg tea.KeyMsg) tea.Cmd {
+	var cmd tea.Cmd
+
+	switch {
+	case key.Matches(msg, keys.back):
+		m.currentScreen = menuScreen
+	case key.Matches(msg, keys.help):
+		m.showHelp = !m.showHelp
+	case key.Matches(msg, keys.refresh):
+		m.trashMessage = ""
+		m.loadTrash()
+	case key.Matches(msg, keys.undo):
+		m.trashMessage = m.undoDelete()
+		m.loadTrash()
+	case key.Matches(msg, keys.enter):
+		cursor := m.trashTable.Cursor()
+		if cursor < 0 || cursor >= len(m.trashItems) {
+			break
+		}
+		item := m.trashItems[cursor]
+		if err := m.restore(item.kind, item.id); err != nil {
+			m.trashMessage = fmt.Sprintf("Error: %v", err)
+			break
+		}
+		if m.lastDeleted != nil && m.lastDeleted.kind == item.kind && m.lastDeleted.id == item.id {
+			m.lastDeleted = nil
+		}
+		m.trashMessage = fmt.Sprintf("Restored %s %q successfully!", item.kind, item.name)
+		m.loadTrash()
+	default:
+		m.trashTable, cmd = m.trashTable.Update(msg)
+	}
+	return cmd
+}
+
+func (m model) trashView() string {
+	var s strings.Builder
+
+	title := titleStyle.Render("QuattriniTrack - Trash")
+	s.WriteString(title + "\n\n")
+
+	if len(m.trashItems) == 0 {
+		s.WriteString("The trash is empty.\n")
+	} else {
+		s.WriteString(tableStyle.Render(m.trashTable.View()) + "\n")
+	}
+
+	if m.trashMessage != "" {
+		if strings.Contains(m.trashMessage, "successful") {
+			s.WriteString(successStyle.Render(m.trashMessage))
+		} else {
+			s.WriteString(errorStyle.Render(m.trashMessage))
+		}
+		s.WriteString("\n")
+	}
+
+	s.WriteString("\n")
+	if m.showHelp {
+		s.WriteString(helpLine(hint(keys.up, "move up"), hint(keys.down, "move down"), hint(keys.enter, "restore"), hint(keys.undo, "undo last delete"), hint(keys.refresh, "refresh"), hint(keys.back, "back to menu"), hint(keys.help, "toggle help")) + "\n")
+	} else {
+		s.WriteString(helpLine(hint(keys.enter, "restore"), hint(keys.undo, "undo"), hint(keys.refresh, "refresh"), hint(keys.back, "back"), hint(keys.help, "help")) + "\n")
+	}
+
+	return s.String()
+}
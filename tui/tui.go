@@ -5,7 +5,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"quattrinitrack/logger"
 	"strconv"
@@ -20,57 +19,6 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-var (
-	titleStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#fc595f")).
-			Background(lipgloss.Color("#252525")).
-			Padding(0, 1)
-
-	infoStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("#FFFDF5"))
-
-	logStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#ffffff"))
-
-	errorStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196"))
-
-	timestampStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#a63c40"))
-
-	menuStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("255")).
-			Padding(1, 2)
-
-	selectedMenuStyle = lipgloss.NewStyle().
-				Foreground(lipgloss.Color("#fc595f")).
-				Background(lipgloss.Color("#252525")).
-				Padding(0, 1)
-
-	menuItemStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#ffffff")).
-			Padding(0, 2)
-
-	inputStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#ffffff")).
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("#fc595f")).
-			Padding(1, 2)
-
-	successStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("42"))
-
-	focusedStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#fc595f"))
-
-	noStyle = lipgloss.NewStyle()
-
-	tableStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("#fc595f"))
-)
-
 type screen int
 
 const (
@@ -79,6 +27,8 @@ const (
 	authScreen
 	categoryScreen
 	transactionScreen
+	dashboardScreen
+	trashScreen
 )
 
 type authMode int
@@ -96,79 +46,6 @@ const (
 	deleteCategoryMode
 )
 
-type keyMap struct {
-	up      key.Binding
-	down    key.Binding
-	quit    key.Binding
-	help    key.Binding
-	clear   key.Binding
-	enter   key.Binding
-	back    key.Binding
-	tab     key.Binding
-	add     key.Binding
-	del     key.Binding
-	refresh key.Binding
-}
-
-func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.help, k.quit}
-}
-
-func (k keyMap) FullHelp() [][]key.Binding {
-	return [][]key.Binding{
-		{k.up, k.down, k.enter},
-		{k.back, k.clear, k.quit},
-		{k.add, k.del, k.refresh},
-	}
-}
-
-var keys = keyMap{
-	up: key.NewBinding(
-		key.WithKeys("up", "k"),
-		key.WithHelp("↑/k", "move up"),
-	),
-	down: key.NewBinding(
-		key.WithKeys("down", "j"),
-		key.WithHelp("↓/j", "move down"),
-	),
-	quit: key.NewBinding(
-		key.WithKeys("q", "ctrl+c"),
-		key.WithHelp("q", "quit"),
-	),
-	help: key.NewBinding(
-		key.WithKeys("?"),
-		key.WithHelp("?", "toggle help"),
-	),
-	clear: key.NewBinding(
-		key.WithKeys("c"),
-		key.WithHelp("c", "clear logs"),
-	),
-	enter: key.NewBinding(
-		key.WithKeys("enter"),
-		key.WithHelp("enter", "select"),
-	),
-	back: key.NewBinding(
-		key.WithKeys("esc", "backspace"),
-		key.WithHelp("esc", "back to menu"),
-	),
-	tab: key.NewBinding(
-		key.WithKeys("tab"),
-		key.WithHelp("tab", "next field"),
-	),
-	add: key.NewBinding(
-		key.WithKeys("ctrl+a"),
-		key.WithHelp("ctrl+a", "add transaction/category"),
-	),
-	del: key.NewBinding(
-		key.WithKeys("ctrl+d"),
-		key.WithHelp("ctrl+d", "delete transaction/category"),
-	),
-	refresh: key.NewBinding(
-		key.WithKeys("r"),
-		key.WithHelp("r", "refresh"),
-	),
-}
-
 type menuItem struct {
 	title       string
 	description string
@@ -184,8 +61,13 @@ type authResponse struct {
 }
 
 type category struct {
-	ID   int64  `json:"id"`
-	Name string `json:"name"`
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	ParentID *int64 `json:"parent_id"`
+
+	// Set from the position of the category in the tree
+	depth int
+	path  string
 }
 
 type transactionMode int
@@ -198,11 +80,32 @@ const (
 )
 
 type transaction struct {
-	ID           int64     `json:"id"`
-	Name         string    `json:"name"`
-	Cost         float64   `json:"cost"`
-	Date         time.Time `json:"date"`
-	CategoriesID int64     `json:"categoriesid"`
+	ID           int64        `json:"id"`
+	Name         string       `json:"name"`
+	Cost         float64      `json:"cost"`
+	Date         time.Time    `json:"date"`
+	CategoriesID int64        `json:"categories_id"`
+	CategoryName string       `json:"category_name"`
+	Tags         []string     `json:"tags"`
+	Splits       []split      `json:"splits"`
+	Notes        string       `json:"notes"`
+	Attachments  []attachment `json:"attachments"`
+}
+
+// categoryLabel names the category of the transaction, as sent by the server,
+// or those of its lines when it is split.
+func (t transaction) categoryLabel() string {
+	if len(t.Splits) > 0 {
+		names := make([]string, 0, len(t.Splits))
+		for _, s := range t.Splits {
+			names = append(names, s.CategoryName)
+		}
+		return strings.Join(names, " + ")
+	}
+	if t.CategoryName == "" {
+		return fmt.Sprintf("#%d", t.CategoriesID)
+	}
+	return t.CategoryName
 }
 
 type model struct {
@@ -235,20 +138,53 @@ type model struct {
 	focusedCategoryInput int
 
 	// Transaction fields
-	transactionMode            transactionMode
-	transactionTable           table.Model
-	transactions               []transaction
-	transactionInput           textinput.Model
-	transactionIDInput         textinput.Model
-	transactionCostInput       textinput.Model
-	transactionDateInput       textinput.Model
-	transactionCategoryIDInput textinput.Model
-	transactionMessage         string
-	transactionNameFilter      textinput.Model
-	transactionDateFrom        textinput.Model
-	transactionDateTo          textinput.Model
-	filteredTransactions       []transaction
-	focusedTransactionInput    int
+	transactionMode         transactionMode
+	transactionTable        table.Model
+	transactions            []transaction
+	transactionInput        textinput.Model
+	transactionIDInput      textinput.Model
+	transactionCostInput    textinput.Model
+	transactionDateInput    textinput.Model
+	categoryPicker          categoryPicker
+	tagInput                tagInput
+	splitEditor             splitEditor
+	tags                    []tag
+	transactionMessage      string
+	transactionNameFilter   textinput.Model
+	transactionDateFrom     textinput.Model
+	transactionDateTo       textinput.Model
+	filteredTransactions    []transaction
+	transactionTotal        int64
+	transactionsExhausted   bool
+	transactionNameFiltered bool
+	transactionSearch       textinput.Model
+	transactionSearching    bool
+	transactionSortKey      string
+	transactionSortDesc     bool
+	transactionFrom         time.Time
+	transactionTo           time.Time
+	focusedTransactionInput int
+	calendar                calendar
+
+	// Dashboard fields
+	dashboard        dashboard
+	dashboardMessage string
+
+	// Trash fields
+	trashTable   table.Model
+	trashItems   []trashItem
+	trashMessage string
+	// lastDeleted is restored by the undo key, nil once restored
+	lastDeleted *trashItem
+
+	// Logs fields
+	logLevel     int
+	logWindow    int
+	logSearch    textinput.Model
+	logSearching bool
+	logPaused    bool
+	logVersion   uint64
+	logExpiry    time.Time
 }
 
 type tickMsg time.Time
@@ -288,9 +224,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				switch m.selectedItem {
 				case 0: // View Logs
 					m.currentScreen = logsScreen
-					if m.ready {
-						m.viewport.SetContent(m.formatLogs())
-					}
+					m.refreshLogs()
 				case 1: // Login/Register
 					m.currentScreen = authScreen
 					m.authMode = loginMode
@@ -313,26 +247,28 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.currentScreen = transactionScreen
 					m.transactionMode = viewTransactionsMode
 					m.transactionMessage = ""
+					m.loadCategories()
 					m.loadTransactions()
-				case 4: // Exit
+				case 4: // Dashboard
+					if !m.isLoggedIn {
+						break
+					}
+					m.currentScreen = dashboardScreen
+					m.loadDashboard()
+				case 5: // Trash
+					if !m.isLoggedIn {
+						break
+					}
+					m.currentScreen = trashScreen
+					m.trashMessage = ""
+					m.loadTrash()
+				case 6: // Exit
 					return m, tea.Quit
 				}
 			}
 
 		case logsScreen:
-			switch {
-			case key.Matches(msg, keys.back):
-				m.currentScreen = menuScreen
-			case key.Matches(msg, keys.help):
-				m.showHelp = !m.showHelp
-			case key.Matches(msg, keys.clear):
-				logger.ClearLogs()
-				m.viewport.SetContent(m.formatLogs())
-			default:
-				// Handle viewport navigation
-				m.viewport, cmd = m.viewport.Update(msg)
-				cmds = append(cmds, cmd)
-			}
+			cmds = append(cmds, m.updateLogs(msg))
 
 		case authScreen:
 			switch {
@@ -367,7 +303,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.authMessage = "Registration successful!"
 					}
 				}
-			case msg.String() == "ctrl+r":
+			case key.Matches(msg, keys.switchAuth):
 				if m.authMode == loginMode {
 					m.authMode = registerMode
 				} else {
@@ -402,6 +338,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.categoryIDInput.Focus()
 				case key.Matches(msg, keys.refresh):
 					m.loadCategories()
+				case key.Matches(msg, keys.undo):
+					m.categoryMessage = m.undoDelete()
+					m.loadCategories()
 				case key.Matches(msg, keys.help):
 					m.showHelp = !m.showHelp
 				default:
@@ -471,7 +410,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if err != nil {
 						m.categoryMessage = fmt.Sprintf("Error: %v", err)
 					} else {
-						m.categoryMessage = "Category deleted successfully!"
+						m.lastDeleted = &trashItem{kind: trashedCategory, id: id}
+						m.categoryMessage = deletedMessage("Category")
 						m.categoryIDInput.SetValue("")
 						m.loadCategories()
 					}
@@ -480,12 +420,66 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					cmds = append(cmds, cmd)
 				}
 			}
+
+		case dashboardScreen:
+			switch {
+			case key.Matches(msg, keys.back):
+				m.currentScreen = menuScreen
+			case key.Matches(msg, keys.refresh):
+				m.loadDashboard()
+			case key.Matches(msg, keys.help):
+				m.showHelp = !m.showHelp
+			}
+
+		case trashScreen:
+			cmds = append(cmds, m.updateTrash(msg))
 		}
 
 		switch m.currentScreen {
 		case transactionScreen:
+			if m.calendar.active {
+				if key.Matches(msg, keys.back) {
+					m.calendar.close()
+					return m, nil
+				}
+				var picked bool
+				m.calendar, picked = m.calendar.update(msg)
+				if picked {
+					m.dateInput(m.calendar.target).SetValue(m.calendar.cursor.Format(dateLayout))
+					m.calendar.close()
+				}
+				return m, nil
+			}
+			if key.Matches(msg, keys.calendar) {
+				if field := m.focusedDateField(); field != noDateField {
+					m.calendar.open(field, m.dateInput(field).Value(), time.Now())
+					return m, nil
+				}
+			}
+
 			switch m.transactionMode {
 			case viewTransactionsMode:
+				if m.transactionSearching {
+					switch msg.String() {
+					case "esc":
+						m.transactionSearching = false
+						m.transactionSearch.Blur()
+						m.transactionSearch.SetValue("")
+						m.loadTransactions()
+					case "enter":
+						m.transactionSearching = false
+						m.transactionSearch.Blur()
+					default:
+						before := m.transactionSearch.Value()
+						m.transactionSearch, cmd = m.transactionSearch.Update(msg)
+						cmds = append(cmds, cmd)
+						if m.transactionSearch.Value() != before {
+							m.loadTransactions()
+						}
+					}
+					break
+				}
+
 				// No filter inputs shown by default; shortcuts only
 				switch {
 				case key.Matches(msg, keys.quit):
@@ -500,14 +494,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.transactionIDInput.SetValue("")
 					m.transactionCostInput.SetValue("")
 					m.transactionDateInput.SetValue("")
-					m.transactionCategoryIDInput.SetValue("")
+					m.categoryPicker.reset()
+					m.tagInput.reset()
+					m.splitEditor.reset()
+					m.loadTags()
 					m.transactionInput.Focus()
 				case key.Matches(msg, keys.del):
 					m.transactionMode = deleteTransactionMode
 					m.transactionMessage = ""
 					m.transactionIDInput.SetValue("")
 					m.transactionIDInput.Focus()
-				case msg.String() == "ctrl+f":
+				case key.Matches(msg, keys.filter):
 					m.transactionMode = filterTransactionMode
 					m.transactionMessage = ""
 					m.transactionNameFilter.Focus()
@@ -517,10 +514,27 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.loadTransactions()
 				case key.Matches(msg, keys.help):
 					m.showHelp = !m.showHelp
+				case key.Matches(msg, keys.search):
+					m.transactionSearching = true
+					m.transactionSearch.Focus()
+				case key.Matches(msg, keys.sortDate):
+					m.sortTransactionsBy("date")
+				case key.Matches(msg, keys.sortCost):
+					m.sortTransactionsBy("cost")
+				case key.Matches(msg, keys.sortName):
+					m.sortTransactionsBy("name")
+				case key.Matches(msg, keys.sortCategory):
+					m.sortTransactionsBy("category")
+				case key.Matches(msg, keys.open):
+					m.openAttachments()
+				case key.Matches(msg, keys.undo):
+					m.transactionMessage = m.undoDelete()
+					m.loadTransactions()
 				default:
 					// Table navigation
 					m.transactionTable, cmd = m.transactionTable.Update(msg)
 					cmds = append(cmds, cmd)
+					m.loadMoreTransactionsIfNeeded()
 				}
 			case filterTransactionMode:
 				inputs := []*textinput.Model{&m.transactionNameFilter, &m.transactionDateFrom, &m.transactionDateTo}
@@ -550,12 +564,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						if name != "" {
 							m.filterTransactionsByName()
 						} else if from != "" || to != "" {
-							m.filterTransactionsByDate()
+							if err := m.filterTransactionsByDate(); err != nil {
+								m.transactionMessage = fmt.Sprintf("Error: %v", err)
+								return m, nil
+							}
 						} else {
-							m.filteredTransactions = m.transactions
-							m.updateTransactionTable()
+							m.transactionFrom = time.Time{}
+							m.transactionTo = time.Time{}
+							m.loadTransactions()
 						}
 						m.transactionMode = viewTransactionsMode
+						m.transactionMessage = ""
 						for _, inp := range inputs {
 							inp.Blur()
 						}
@@ -599,7 +618,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.transactionDateTo.Blur()
 				}
 			case addTransactionMode:
-				inputs := []*textinput.Model{&m.transactionInput, &m.transactionCostInput, &m.transactionDateInput, &m.transactionCategoryIDInput}
+				inputs := []*textinput.Model{&m.transactionInput, &m.transactionCostInput, &m.transactionDateInput, &m.categoryPicker.input, &m.splitEditor.input, &m.tagInput.input}
 				anyFocused := false
 				for _, inp := range inputs {
 					if inp.Focused() {
@@ -609,17 +628,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				if anyFocused {
 					// Update the focused input first
-					for _, inp := range inputs {
-						if inp.Focused() {
-							newModel, cmd := inp.Update(msg)
-							*inp = newModel // Important: update the input model with the new state
-							cmds = append(cmds, cmd)
-							break
+					if m.categoryPicker.input.Focused() {
+						m.categoryPicker = m.categoryPicker.update(msg, m.categories)
+					} else {
+						for _, inp := range inputs {
+							if inp.Focused() {
+								newModel, cmd := inp.Update(msg)
+								*inp = newModel // Important: update the input model with the new state
+								cmds = append(cmds, cmd)
+								break
+							}
 						}
 					}
 
-					// Handle navigation after input update
-					if key.Matches(msg, keys.tab) {
+					// Handle navigation after input update, tab first completing
+					// the tag being typed
+					if key.Matches(msg, keys.tab) && m.tagInput.input.Focused() && m.tagInput.complete(m.tags) {
+						return m, tea.Batch(cmds...)
+					} else if key.Matches(msg, keys.tab) {
 						focused := -1
 						for i, inp := range inputs {
 							if inp.Focused() {
@@ -629,34 +655,54 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							}
 						}
 						next := (focused + 1) % len(inputs)
+						if inputs[next] == &m.categoryPicker.input {
+							m.loadSuggestions()
+						}
 						inputs[next].Focus()
 					} else if key.Matches(msg, keys.enter) {
 						name := m.transactionInput.Value()
 						costStr := m.transactionCostInput.Value()
 						date := strings.TrimSpace(m.transactionDateInput.Value())
-						categoryIDStr := m.transactionCategoryIDInput.Value()
-						if name == "" || costStr == "" || date == "" || categoryIDStr == "" {
+						if name == "" || costStr == "" || date == "" {
 							return m, nil
 						}
 						cost, err := strconv.ParseFloat(costStr, 64)
 						if err != nil {
 							return m, nil
 						}
-						dt, err := time.Parse("2006-01-02", date)
+						dates, err := ParseDateExpr(date, time.Now())
 						if err != nil {
+							m.transactionMessage = fmt.Sprintf("Error: %v", err)
 							return m, nil
 						}
-						categoryID, err := strconv.ParseInt(categoryIDStr, 10, 64)
+						if !dates.SingleDay() {
+							m.transactionMessage = fmt.Sprintf("Error: %q is a range of days, pick a single one", date)
+							return m, nil
+						}
+						dt := dates.Start
+						// A split transaction takes its categories from its lines
+						var categoryID int64
+						var splits []split
+						if m.splitEditor.empty() {
+							categoryID, err = m.resolvePickedCategory()
+						} else {
+							splits, err = m.splits()
+						}
 						if err != nil {
+							m.transactionMessage = fmt.Sprintf("Error: %v", err)
 							return m, nil
 						}
-						if err := m.addTransaction(name, cost, dt.Format("2006-01-02"), categoryID); err == nil {
+						if err := m.addTransaction(name, cost, dt.Format("2006-01-02"), categoryID, splits, m.tagInput.tags()); err == nil {
 							m.transactionMode = viewTransactionsMode
 							m.transactionInput.SetValue("")
 							m.transactionCostInput.SetValue("")
 							m.transactionDateInput.SetValue("")
-							m.transactionCategoryIDInput.SetValue("")
+							m.categoryPicker.reset()
+							m.splitEditor.reset()
+							m.tagInput.reset()
 							m.loadTransactions()
+						} else {
+							m.transactionMessage = fmt.Sprintf("Error: %v", err)
 						}
 					} else if key.Matches(msg, keys.back) {
 						// Just blur all inputs but stay in add mode
@@ -678,7 +724,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.transactionInput.Focus()
 					m.transactionCostInput.Blur()
 					m.transactionDateInput.Blur()
-					m.transactionCategoryIDInput.Blur()
+					m.categoryPicker.input.Blur()
+					m.splitEditor.input.Blur()
+					m.tagInput.input.Blur()
 				}
 			case deleteTransactionMode:
 				switch {
@@ -703,7 +751,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					if err != nil {
 						m.transactionMessage = fmt.Sprintf("Error: %v", err)
 					} else {
-						m.transactionMessage = "Transaction deleted successfully!"
+						m.lastDeleted = &trashItem{kind: trashedTransaction, id: id}
+						m.transactionMessage = deletedMessage("Transaction")
 						m.transactionIDInput.SetValue("")
 						m.transactionMode = viewTransactionsMode
 						m.loadTransactions()
@@ -728,8 +777,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if !m.ready {
 			m.viewport = viewport.New(msg.Width, msg.Height-verticalMarginHeight)
 			m.viewport.YPosition = headerHeight
-			m.viewport.SetContent(m.formatLogs())
 			m.ready = true
+			m.refreshLogs()
 		} else {
 			m.viewport.Width = msg.Width
 			m.viewport.Height = msg.Height - verticalMarginHeight
@@ -740,8 +789,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.categoryTable.SetHeight(msg.Height - 10)
 
 	case tickMsg:
-		if m.currentScreen == logsScreen && m.ready {
-			m.viewport.SetContent(m.formatLogs())
+		if m.currentScreen == logsScreen && m.logsChanged() {
+			m.refreshLogs()
 		}
 		m.lastUpdate = time.Time(msg)
 		cmds = append(cmds, tick())
@@ -763,9 +812,9 @@ func (m *model) performAuth(email, password string) error {
 
 	var endpoint string
 	if m.authMode == loginMode {
-		endpoint = "http://localhost:8080/login"
+		endpoint = apiBaseURL + "/login"
 	} else {
-		endpoint = "http://localhost:8080/register"
+		endpoint = apiBaseURL + "/register"
 	}
 
 	resp, err := http.Post(endpoint, "application/json", bytes.NewBuffer(jsonData))
@@ -775,7 +824,7 @@ func (m *model) performAuth(email, password string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("authentication failed with status: %d", resp.StatusCode)
+		return fmt.Errorf("authentication failed: %s", apiError(resp))
 	}
 
 	if m.authMode == loginMode {
@@ -791,7 +840,7 @@ func (m *model) performAuth(email, password string) error {
 
 func (m *model) loadCategories() {
 	client := &http.Client{}
-	req, err := http.NewRequest("GET", "http://localhost:8080/category", nil)
+	req, err := http.NewRequest("GET", apiBaseURL+"/category?tree=true", nil)
 	if err != nil {
 		m.categoryMessage = fmt.Sprintf("Error creating request: %v", err)
 		return
@@ -807,29 +856,43 @@ func (m *model) loadCategories() {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		m.categoryMessage = fmt.Sprintf("Error: Status %d", resp.StatusCode)
+		m.categoryMessage = "Error: " + apiError(resp)
 		return
 	}
 
-	var categories []category
-	if err := json.NewDecoder(resp.Body).Decode(&categories); err != nil {
+	var tree []categoryNode
+	if err := json.NewDecoder(resp.Body).Decode(&tree); err != nil {
 		m.categoryMessage = fmt.Sprintf("Error decoding response: %v", err)
 		return
 	}
 
-	m.categories = categories
+	m.categories = flattenCategories(tree, 0, "")
 	m.updateCategoryTable()
 }
 
-func (m *model) addCategory(name string) error {
-	categoryReq := category{Name: name}
+// addCategory creates a category. A path such as "Food > Groceries" creates
+// it under an existing parent.
+func (m *model) addCategory(path string) error {
+	categoryReq := struct {
+		Name     string `json:"name"`
+		ParentID int64  `json:"parent_id,omitempty"`
+	}{}
+	parentPath, name := splitCategoryPath(path)
+	categoryReq.Name = name
+	if parentPath != "" {
+		parent, err := m.categoryByPath(parentPath)
+		if err != nil {
+			return err
+		}
+		categoryReq.ParentID = parent.ID
+	}
 	jsonData, err := json.Marshal(categoryReq)
 	if err != nil {
 		return err
 	}
 
 	client := &http.Client{}
-	req, err := http.NewRequest("POST", "http://localhost:8080/category", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", apiBaseURL+"/category", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return err
 	}
@@ -844,7 +907,7 @@ func (m *model) addCategory(name string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to create category with status: %d", resp.StatusCode)
+		return fmt.Errorf("failed to create category: %s", apiError(resp))
 	}
 
 	return nil
@@ -852,7 +915,7 @@ func (m *model) addCategory(name string) error {
 
 func (m *model) deleteCategory(id int64) error {
 	client := &http.Client{}
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("http://localhost:8080/category?id=%d", id), nil)
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/category?id=%d", apiBaseURL, id), nil)
 	if err != nil {
 		return err
 	}
@@ -866,7 +929,7 @@ func (m *model) deleteCategory(id int64) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to delete category with status: %d", resp.StatusCode)
+		return fmt.Errorf("failed to delete category: %s", apiError(resp))
 	}
 
 	return nil
@@ -875,14 +938,14 @@ func (m *model) deleteCategory(id int64) error {
 func (m *model) updateCategoryTable() {
 	columns := []table.Column{
 		{Title: "ID", Width: 5},
-		{Title: "Name", Width: 15},
+		{Title: "Name", Width: 30},
 	}
 
 	rows := []table.Row{}
 	for _, cat := range m.categories {
 		rows = append(rows, table.Row{
 			strconv.FormatInt(cat.ID, 10),
-			cat.Name,
+			cat.treeLabel(),
 		})
 	}
 
@@ -893,88 +956,7 @@ func (m *model) updateCategoryTable() {
 		table.WithHeight(10),
 	)
 
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("#fc595f")).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("#fc595f")).
-		Background(lipgloss.Color("#252525")).
-		Bold(false)
-	m.categoryTable.SetStyles(s)
-}
-
-func (m *model) loadTransactions() {
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", "http://localhost:8080/transaction", nil)
-	if err != nil {
-		m.transactionMessage = fmt.Sprintf("Error creating request: %v", err)
-		return
-	}
-
-	req.Header.Set("Authorization", "Bearer "+m.authToken)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		m.transactionMessage = fmt.Sprintf("Error: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		m.transactionMessage = fmt.Sprintf("Error: Status %d", resp.StatusCode)
-		return
-	}
-
-	var transactions []transaction
-	if err := json.NewDecoder(resp.Body).Decode(&transactions); err != nil {
-		m.transactionMessage = fmt.Sprintf("Error decoding response: %v", err)
-		return
-	}
-
-	m.transactions = transactions
-	m.filteredTransactions = transactions
-	m.updateTransactionTable()
-}
-
-func (m *model) updateTransactionTable() {
-	columns := []table.Column{
-		{Title: "ID", Width: 8},
-		{Title: "Name", Width: 20},
-		{Title: "Cost", Width: 10},
-		{Title: "Date", Width: 15},
-		{Title: "CategoryID", Width: 10},
-	}
-	rows := []table.Row{}
-	for _, t := range m.filteredTransactions {
-		rows = append(rows, table.Row{
-			strconv.FormatInt(t.ID, 10),
-			t.Name,
-			fmt.Sprintf("%.2f", t.Cost),
-			t.Date.Format("2006-01-02"),
-			strconv.FormatInt(t.CategoriesID, 10),
-		})
-	}
-	m.transactionTable = table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(10),
-	)
-	// Style similar to categories
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("#fc595f")).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("#fc595f")).
-		Background(lipgloss.Color("#252525")).
-		Bold(false)
-	m.transactionTable.SetStyles(s)
+	m.categoryTable.SetStyles(tableStyles())
 }
 
 func (m *model) filterTransactionsByName() {
@@ -986,7 +968,7 @@ func (m *model) filterTransactionsByName() {
 	}
 
 	client := &http.Client{}
-	req, err := http.NewRequest("GET", "http://localhost:8080/transaction?name="+name, nil)
+	req, err := http.NewRequest("GET", apiBaseURL+"/transaction?name="+name, nil)
 	if err != nil {
 		m.filteredTransactions = nil
 		m.updateTransactionTable()
@@ -1008,62 +990,69 @@ func (m *model) filterTransactionsByName() {
 	} else {
 		m.filteredTransactions = nil
 	}
+	m.transactionNameFiltered = true
 	m.updateTransactionTable()
 }
 
-func (m *model) filterTransactionsByDate() {
+func (m *model) filterTransactionsByDate() error {
+	now := time.Now()
 	from := m.transactionDateFrom.Value()
 	to := m.transactionDateTo.Value()
-	var filtered []transaction
-	for _, t := range m.transactions {
-		if from != "" {
-			fromTime, err := time.Parse("2006-01-02", from)
-			if err != nil {
-				continue
-			}
-			if t.Date.Before(fromTime) {
-				continue
-			}
+
+	var fromTime, toTime time.Time
+	if strings.TrimSpace(from) != "" {
+		r, err := ParseDateExpr(from, now)
+		if err != nil {
+			return fmt.Errorf("from date: %w", err)
 		}
-		if to != "" {
-			toTime, err := time.Parse("2006-01-02", to)
-			if err != nil {
-				continue
-			}
-			if t.Date.After(toTime) {
-				continue
-			}
+		fromTime = r.Start
+	}
+	if strings.TrimSpace(to) != "" {
+		r, err := ParseDateExpr(to, now)
+		if err != nil {
+			return fmt.Errorf("to date: %w", err)
 		}
-		filtered = append(filtered, t)
+		// The end date is inclusive, so stop at the start of the following day
+		toTime = r.End.AddDate(0, 0, 1)
 	}
-	m.filteredTransactions = filtered
-	m.updateTransactionTable()
+	if !fromTime.IsZero() && !toTime.IsZero() && !fromTime.Before(toTime) {
+		return fmt.Errorf("from date is after to date")
+	}
+
+	m.transactionFrom = fromTime
+	m.transactionTo = toTime
+	m.loadTransactions()
+	return nil
 }
 
 // Add a transaction via HTTP POST
-func (m *model) addTransaction(name string, cost float64, date string, categoryID int64) error {
+func (m *model) addTransaction(name string, cost float64, date string, categoryID int64, splits []split, tags []string) error {
 	// Parse date and format as RFC3339
 	dt, err := time.Parse("2006-01-02", date)
 	if err != nil {
 		return fmt.Errorf("invalid date format: %v", err)
 	}
 	transactionReq := struct {
-		Name         string  `json:"name"`
-		Cost         float64 `json:"cost"`
-		Date         string  `json:"date"`
-		CategoriesID int64   `json:"categoriesid"`
+		Name         string   `json:"name"`
+		Cost         float64  `json:"cost"`
+		Date         string   `json:"date"`
+		CategoriesID int64    `json:"categories_id,omitempty"`
+		Splits       []split  `json:"splits,omitempty"`
+		Tags         []string `json:"tags,omitempty"`
 	}{
 		Name:         name,
 		Cost:         cost,
 		Date:         dt.Format(time.RFC3339),
 		CategoriesID: categoryID,
+		Splits:       splits,
+		Tags:         tags,
 	}
 	jsonData, err := json.Marshal(transactionReq)
 	if err != nil {
 		return err
 	}
 	client := &http.Client{}
-	req, err := http.NewRequest("POST", "http://localhost:8080/transaction", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", apiBaseURL+"/transaction", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return err
 	}
@@ -1074,9 +1063,8 @@ func (m *model) addTransaction(name string, cost float64, date string, categoryI
 		return err
 	}
 	defer resp.Body.Close()
-	respBody, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to create transaction with status: %d, body: %s", resp.StatusCode, string(respBody))
+		return fmt.Errorf("failed to create transaction: %s", apiError(resp))
 	}
 	return nil
 }
@@ -1084,7 +1072,7 @@ func (m *model) addTransaction(name string, cost float64, date string, categoryI
 // Delete a transaction via HTTP DELETE
 func (m *model) deleteTransaction(id int64) error {
 	client := &http.Client{}
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("http://localhost:8080/transaction?id=%d", id), nil)
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/transaction?id=%d", apiBaseURL, id), nil)
 	if err != nil {
 		return err
 	}
@@ -1094,11 +1082,8 @@ func (m *model) deleteTransaction(id int64) error {
 		return err
 	}
 	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusNotFound {
-		return fmt.Errorf("transaction not found")
-	}
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to delete transaction with status: %d", resp.StatusCode)
+		return fmt.Errorf("failed to delete transaction: %s", apiError(resp))
 	}
 	m.loadTransactions()
 	return nil
@@ -1119,7 +1104,14 @@ func (m model) View() string {
 	case categoryScreen:
 		return m.categoryView()
 	case transactionScreen:
+		if m.calendar.active {
+			return m.transactionView() + "\n" + m.calendar.view(time.Now())
+		}
 		return m.transactionView()
+	case dashboardScreen:
+		return m.dashboardView()
+	case trashScreen:
+		return m.trashView()
 	default:
 		return m.menuView()
 	}
@@ -1153,9 +1145,9 @@ func (m model) menuView() string {
 
 	s.WriteString("\n")
 	if m.showHelp {
-		s.WriteString("↑/k: move up • ↓/j: move down • enter: select • ?: toggle help\n")
+		s.WriteString(helpLine(hint(keys.up, "move up"), hint(keys.down, "move down"), hint(keys.enter, "select"), hint(keys.help, "toggle help")) + "\n")
 	} else {
-		s.WriteString("Press ? for help\n")
+		s.WriteString(fmt.Sprintf("Press %s for help\n", helpKey(keys.help)))
 	}
 
 	return s.String()
@@ -1197,12 +1189,12 @@ func (m model) authView() string {
 		s.WriteString("\n\n")
 	}
 
-	s.WriteString("Tab: next field • Enter: submit • Ctrl+R: toggle login/register • Esc: back to menu\n")
+	s.WriteString(helpLine(hint(keys.tab, "next field"), hint(keys.enter, "submit"), hint(keys.switchAuth, "toggle login/register"), "esc: back to menu") + "\n")
 
 	if m.authMode == loginMode {
-		s.WriteString("Press 'Ctrl+R' to switch to register mode\n")
+		s.WriteString(fmt.Sprintf("Press '%s' to switch to register mode\n", helpKey(keys.switchAuth)))
 	} else {
-		s.WriteString("Press 'Ctrl+R' to switch to login mode\n")
+		s.WriteString(fmt.Sprintf("Press '%s' to switch to login mode\n", helpKey(keys.switchAuth)))
 	}
 
 	return s.String()
@@ -1217,7 +1209,7 @@ func (m model) categoryView() string {
 		s.WriteString(title + "\n\n")
 
 		if len(m.categories) == 0 {
-			s.WriteString("No categories found. Press 'a' to add a category.\n")
+			s.WriteString(fmt.Sprintf("No categories found. Press '%s' to add a category.\n", helpKey(keys.add)))
 		} else {
 			s.WriteString(tableStyle.Render(m.categoryTable.View()) + "\n")
 		}
@@ -1233,9 +1225,9 @@ func (m model) categoryView() string {
 
 		s.WriteString("\n")
 		if m.showHelp {
-			s.WriteString("↑/k: move up • ↓/j: move down • ctrl+a: add category • ctrl+d: delete category • r: refresh • esc: back to menu •?: toggle help\n")
+			s.WriteString(helpLine(hint(keys.up, "move up"), hint(keys.down, "move down"), hint(keys.add, "add category"), hint(keys.del, "delete category"), hint(keys.undo, "undo delete"), hint(keys.refresh, "refresh"), hint(keys.back, "back to menu"), hint(keys.help, "toggle help")) + "\n")
 		} else {
-			s.WriteString("ctrl+a: add • ctrl+d: delete • r: refresh • esc: back • ?: help\n")
+			s.WriteString(helpLine(hint(keys.add, "add"), hint(keys.del, "delete"), hint(keys.refresh, "refresh"), hint(keys.back, "back"), hint(keys.help, "help")) + "\n")
 		}
 
 	case addCategoryMode:
@@ -1244,6 +1236,7 @@ func (m model) categoryView() string {
 
 		s.WriteString("Category Name:\n")
 		s.WriteString(inputStyle.Render(m.categoryInput.View()))
+		s.WriteString("\n" + mutedStyle.Render("Use \"Food > Groceries\" to add a subcategory of Food"))
 		s.WriteString("\n\n")
 
 		if m.categoryMessage != "" {
@@ -1255,7 +1248,7 @@ func (m model) categoryView() string {
 			s.WriteString("\n\n")
 		}
 
-		s.WriteString("Enter: submit • Esc: back to categories\n")
+		s.WriteString(helpLine(hint(keys.enter, "submit"), hint(keys.back, "back to categories")) + "\n")
 
 	case deleteCategoryMode:
 		title := titleStyle.Render("QuattriniTrack - Delete Category")
@@ -1274,7 +1267,7 @@ func (m model) categoryView() string {
 			s.WriteString("\n\n")
 		}
 
-		s.WriteString("Enter: submit • Esc: back to categories\n")
+		s.WriteString(helpLine(hint(keys.enter, "submit"), hint(keys.back, "back to categories")) + "\n")
 	}
 
 	return s.String()
@@ -1289,9 +1282,13 @@ func (m model) transactionView() string {
 		s.WriteString(title + "\n\n")
 
 		// No filter inputs shown in view mode
+		if m.transactionSearching || m.transactionSearch.Value() != "" {
+			s.WriteString("Search: " + m.transactionSearch.View() + "\n")
+		}
+		s.WriteString(mutedStyle.Render(m.transactionStatus()) + "\n")
 
 		if len(m.filteredTransactions) == 0 {
-			s.WriteString("No transactions found. Press 'a' to add a transaction.\n")
+			s.WriteString(fmt.Sprintf("No transactions found. Press '%s' to add a transaction.\n", helpKey(keys.add)))
 		} else {
 			s.WriteString(tableStyle.Render(m.transactionTable.View()) + "\n")
 		}
@@ -1307,17 +1304,26 @@ func (m model) transactionView() string {
 
 		s.WriteString("\n")
 		if m.showHelp {
-			s.WriteString("↑/k: move up • ↓/j: move down • ctrl+a: add transaction • ctrl+d: delete transaction • ctrl+f: filter • r: refresh • esc: back to menu • ?: toggle help\n")
+			s.WriteString(helpLine(hint(keys.up, "move up"), hint(keys.down, "move down"), hint(keys.search, "search"),
+				hint(keys.sortDate, "sort by date"), hint(keys.sortCost, "sort by cost"), hint(keys.sortName, "sort by name"), hint(keys.sortCategory, "sort by category"),
+				hint(keys.add, "add transaction"), hint(keys.del, "delete transaction"), hint(keys.filter, "filter"), hint(keys.refresh, "refresh"),
+				hint(keys.open, "open attachments"), hint(keys.undo, "undo delete"), hint(keys.back, "back to menu"), hint(keys.help, "toggle help")) + "\n")
 		} else {
-			s.WriteString("ctrl+a: add • ctrl+d: delete • ctrl+f: filter • r: refresh • esc: back • ?: help\n")
+			s.WriteString(helpLine(hint(keys.search, "search"), hint(keys.sortDate, "sort"), hint(keys.add, "add"), hint(keys.del, "delete"), hint(keys.filter, "filter"), hint(keys.refresh, "refresh"), hint(keys.back, "back"), hint(keys.help, "help")) + "\n")
 		}
 	case addTransactionMode:
 		title := titleStyle.Render("QuattriniTrack - Add Transaction")
 		s.WriteString(title + "\n\n")
 		s.WriteString(inputStyle.Render("Name: "+m.transactionInput.View()) + "\n")
 		s.WriteString(inputStyle.Render("Cost: "+m.transactionCostInput.View()) + "\n")
-		s.WriteString(inputStyle.Render("Date (YYYY-MM-DD): "+m.transactionDateInput.View()) + "\n")
-		s.WriteString(inputStyle.Render("Category ID: "+m.transactionCategoryIDInput.View()) + "\n\n")
+		s.WriteString(inputStyle.Render("Date: "+withDateHint(m.transactionDateInput)) + "\n")
+		if m.splitEditor.empty() {
+			s.WriteString(inputStyle.Render("Category: "+m.categoryPicker.view(m.categories)) + "\n")
+		} else {
+			s.WriteString(inputStyle.Render("Category: "+mutedStyle.Render("from the split lines")) + "\n")
+		}
+		s.WriteString(inputStyle.Render("Split: "+m.splitEditorView()) + "\n")
+		s.WriteString(inputStyle.Render("Tags: "+m.tagInput.view(m.tags)) + "\n\n")
 		if m.transactionMessage != "" {
 			if strings.Contains(m.transactionMessage, "successful") {
 				s.WriteString(successStyle.Render(m.transactionMessage))
@@ -1326,7 +1332,7 @@ func (m model) transactionView() string {
 			}
 			s.WriteString("\n")
 		}
-		s.WriteString("Tab: next field • Enter: submit • Esc: back to transactions\n")
+		s.WriteString(helpLine(hint(keys.tab, "next field/complete tag"), "↑/↓: pick category", hint(keys.calendar, "calendar"), hint(keys.enter, "submit"), hint(keys.back, "back to transactions")) + "\n")
 	case deleteTransactionMode:
 		title := titleStyle.Render("QuattriniTrack - Delete Transaction")
 		s.WriteString(title + "\n\n")
@@ -1341,13 +1347,13 @@ func (m model) transactionView() string {
 			}
 			s.WriteString("\n\n")
 		}
-		s.WriteString("Enter: submit • Esc: back to transactions\n")
+		s.WriteString(helpLine(hint(keys.enter, "submit"), hint(keys.back, "back to transactions")) + "\n")
 	case filterTransactionMode:
 		title := titleStyle.Render("QuattriniTrack - Filter Transactions")
 		s.WriteString(title + "\n\n")
 		s.WriteString(inputStyle.Render("Name: "+m.transactionNameFilter.View()) + "\n")
-		s.WriteString(inputStyle.Render("Date From: "+m.transactionDateFrom.View()) + "\n")
-		s.WriteString(inputStyle.Render("Date To: "+m.transactionDateTo.View()) + "\n\n")
+		s.WriteString(inputStyle.Render("Date From: "+withDateHint(m.transactionDateFrom)) + "\n")
+		s.WriteString(inputStyle.Render("Date To: "+withDateHint(m.transactionDateTo)) + "\n\n")
 		if m.transactionMessage != "" {
 			if strings.Contains(m.transactionMessage, "successful") {
 				s.WriteString(successStyle.Render(m.transactionMessage))
@@ -1356,7 +1362,7 @@ func (m model) transactionView() string {
 			}
 			s.WriteString("\n")
 		}
-		s.WriteString("Tab: next field • Enter: apply filter • Esc: cancel\n")
+		s.WriteString(helpLine(hint(keys.tab, "next field"), hint(keys.calendar, "calendar"), hint(keys.enter, "apply filter"), hint(keys.back, "cancel")) + "\n")
 	}
 
 	return s.String()
@@ -1364,50 +1370,25 @@ func (m model) transactionView() string {
 
 func (m model) headerView() string {
 	title := titleStyle.Render("QuattriniTrack - Server Logs")
-	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)))
-	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
+	status := mutedStyle.Render(" " + m.logStatus() + " ")
+	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)-lipgloss.Width(status)))
+	return lipgloss.JoinHorizontal(lipgloss.Center, title, line, status)
 }
 
 func (m model) footerView() string {
 	info := infoStyle.Render(fmt.Sprintf("%3.f%%", m.viewport.ScrollPercent()*100))
-	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(info)))
+	search := ""
+	if m.logSearching || m.logSearch.Value() != "" {
+		search = m.logSearch.View() + " "
+	}
+	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(info)-lipgloss.Width(search)))
 
 	helpText := ""
 	if m.showHelp {
-		helpText = "\n" + "↑/k: scroll up • ↓/j: scroll down • c: clear logs • esc: back to menu • ?: toggle help"
-	}
-
-	return lipgloss.JoinHorizontal(lipgloss.Center, line, info) + helpText
-}
-
-func (m model) formatLogs() string {
-	logs := logger.GetLogs()
-
-	if len(logs) == 0 {
-		return logStyle.Render("No logs yet... Server is running and waiting for requests.")
-	}
-
-	var content strings.Builder
-
-	for _, log := range logs {
-		timestamp := timestampStyle.Render(log.Timestamp.Format("15:04:05"))
-		level := log.Level
-
-		message := strings.TrimSpace(log.Message)
-
-		var styledMessage string
-		if strings.Contains(strings.ToLower(message), "error") ||
-			strings.Contains(strings.ToLower(message), "failed") ||
-			strings.Contains(strings.ToLower(message), "panic") {
-			styledMessage = errorStyle.Render(message)
-		} else {
-			styledMessage = logStyle.Render(message)
-		}
-
-		content.WriteString(fmt.Sprintf("%s [%s] %s\n", timestamp, level, styledMessage))
+		helpText = "\n" + helpLine(hint(keys.up, "scroll up"), hint(keys.down, "scroll down"), hint(keys.search, "search"), hint(keys.logLevel, "level"), hint(keys.logWindow, "time window"), hint(keys.follow, "pause/follow"), hint(keys.clear, "clear logs"), hint(keys.back, "back to menu"), hint(keys.help, "toggle help"))
 	}
 
-	return content.String()
+	return lipgloss.JoinHorizontal(lipgloss.Center, search, line, info) + helpText
 }
 
 func max(a, b int) int {
@@ -1433,8 +1414,8 @@ func Init() {
 	passwordInput.EchoCharacter = '*'
 
 	categoryInput := textinput.New()
-	categoryInput.Placeholder = "Enter category name"
-	categoryInput.CharLimit = 50
+	categoryInput.Placeholder = "Name, or Parent > Name"
+	categoryInput.CharLimit = 100
 	categoryInput.Width = 30
 
 	categoryIDInput := textinput.New()
@@ -1449,17 +1430,22 @@ func Init() {
 	transactionNameFilter.Width = 30
 
 	transactionDateFrom := textinput.New()
-	transactionDateFrom.Placeholder = "From date (YYYY-MM-DD)"
-	transactionDateFrom.CharLimit = 10
+	transactionDateFrom.Placeholder = "From date (YYYY-MM-DD, last month, -7d)"
+	transactionDateFrom.CharLimit = 30
 	transactionDateFrom.Width = 30
 
 	transactionDateTo := textinput.New()
-	transactionDateTo.Placeholder = "To date (YYYY-MM-DD)"
-	transactionDateTo.CharLimit = 10
+	transactionDateTo.Placeholder = "To date (YYYY-MM-DD, today, yesterday)"
+	transactionDateTo.CharLimit = 30
 	transactionDateTo.Width = 30
 
 	transactionNameFilter.Focus() // Set initial focus to name filter
 
+	transactionSearch := textinput.New()
+	transactionSearch.Placeholder = "Type to search name or category"
+	transactionSearch.CharLimit = 50
+	transactionSearch.Width = 30
+
 	// Initialize transaction inputs
 	transactionInput := textinput.New()
 	transactionInput.Placeholder = "Enter transaction name"
@@ -1477,14 +1463,11 @@ func Init() {
 	transactionCostInput.Width = 30
 
 	transactionDateInput := textinput.New()
-	transactionDateInput.Placeholder = "Enter date (YYYY-MM-DD)"
-	transactionDateInput.CharLimit = 10
+	transactionDateInput.Placeholder = "Enter date (YYYY-MM-DD, today, -3d)"
+	transactionDateInput.CharLimit = 30
 	transactionDateInput.Width = 30
 
-	transactionCategoryIDInput := textinput.New()
-	transactionCategoryIDInput.Placeholder = "Enter category ID"
-	transactionCategoryIDInput.CharLimit = 10
-	transactionCategoryIDInput.Width = 30
+	transactionCategoryPicker := newCategoryPicker()
 
 	menuItems := []menuItem{
 		{
@@ -1503,6 +1486,14 @@ func Init() {
 			title:       "Transactions",
 			description: "Manage transactions (view, add, delete, filter) - requires login",
 		},
+		{
+			title:       "Dashboard",
+			description: "Monthly totals, spending by category and recent trends - requires login",
+		},
+		{
+			title:       "Trash",
+			description: "Restore deleted transactions and categories - requires login",
+		},
 		{
 			title:       "Exit",
 			description: "Close the application",
@@ -1528,7 +1519,8 @@ func Init() {
 		{Title: "Name", Width: 20},
 		{Title: "Cost", Width: 10},
 		{Title: "Date", Width: 15},
-		{Title: "CategoryID", Width: 10},
+		{Title: "Category", Width: 15},
+		{Title: "Tags", Width: 20},
 	}
 
 	transactionTable := table.New(
@@ -1541,29 +1533,35 @@ func Init() {
 
 	p := tea.NewProgram(
 		model{
-			lastUpdate:                 time.Now(),
-			currentScreen:              menuScreen,
-			menuItems:                  menuItems,
-			selectedItem:               0,
-			emailInput:                 emailInput,
-			passwordInput:              passwordInput,
-			focusedInput:               0,
-			authMode:                   loginMode,
-			categoryInput:              categoryInput,
-			categoryIDInput:            categoryIDInput,
-			categoryTable:              categoryTable,
-			categoryMode:               viewCategoriesMode,
-			transactionInput:           transactionInput,
-			transactionIDInput:         transactionIDInput,
-			transactionCostInput:       transactionCostInput,
-			transactionDateInput:       transactionDateInput,
-			transactionCategoryIDInput: transactionCategoryIDInput,
-			transactionTable:           transactionTable,
-			transactionMode:            viewTransactionsMode,
-			transactionNameFilter:      transactionNameFilter,
-			transactionDateFrom:        transactionDateFrom,
-			transactionDateTo:          transactionDateTo,
-			focusedTransactionInput:    0,
+			lastUpdate:              time.Now(),
+			currentScreen:           menuScreen,
+			menuItems:               menuItems,
+			selectedItem:            0,
+			emailInput:              emailInput,
+			passwordInput:           passwordInput,
+			focusedInput:            0,
+			authMode:                loginMode,
+			categoryInput:           categoryInput,
+			categoryIDInput:         categoryIDInput,
+			categoryTable:           categoryTable,
+			categoryMode:            viewCategoriesMode,
+			transactionInput:        transactionInput,
+			transactionIDInput:      transactionIDInput,
+			transactionCostInput:    transactionCostInput,
+			transactionDateInput:    transactionDateInput,
+			categoryPicker:          transactionCategoryPicker,
+			tagInput:                newTagInput(),
+			splitEditor:             newSplitEditor(),
+			transactionTable:        transactionTable,
+			transactionMode:         viewTransactionsMode,
+			transactionNameFilter:   transactionNameFilter,
+			transactionDateFrom:     transactionDateFrom,
+			transactionDateTo:       transactionDateTo,
+			transactionSearch:       transactionSearch,
+			transactionSortKey:      "date",
+			transactionSortDesc:     true,
+			focusedTransactionInput: 0,
+			logSearch:               newLogSearch(),
 		},
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),